@@ -1,22 +1,32 @@
 package access
 
 import (
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/keyStore"
+	"github.com/echocat/caretakerd/values"
 	"os"
-	"os/user"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 // Access represents an initiated access management for a service node of caretakerd.
 type Access struct {
-	name              string
-	t                 Type
-	permission        Permission
-	pem               []byte
-	cert              *x509.Certificate
-	temporaryFilename *string
+	name               string
+	t                  Type
+	permission         Permission
+	pem                []byte
+	cert               *x509.Certificate
+	temporaryFilenames []string
+	uids               map[uint32]bool
+	gids               map[uint32]bool
+	tokens             []string
+	roles              []string
+	config             Config
+	lock               *sync.RWMutex
 }
 
 // NewAccess creates a new instance of Access using the given configuration.
@@ -25,6 +35,29 @@ func NewAccess(conf Config, name string, ks *keyStore.KeyStore) (*Access, error)
 	if err != nil {
 		return nil, err
 	}
+	result, err := newAccess(conf, name, ks)
+	if err != nil {
+		return nil, err
+	}
+	if result.t != None {
+		for _, role := range conf.Roles {
+			result.roles = append(result.roles, role.String())
+		}
+	}
+	result.config = conf
+	result.lock = new(sync.RWMutex)
+	return result, nil
+}
+
+func newAccess(conf Config, name string, ks *keyStore.KeyStore) (*Access, error) {
+	switch conf.Type {
+	case PeerCredentials:
+		return newPeerCredentialsInstance(conf, name)
+	case Token:
+		return newTokenInstance(conf, name)
+	case GenerateTokenToEnvironment:
+		return newGenerateTokenToEnvironmentInstance(conf, name)
+	}
 	if !ks.IsEnabled() {
 		return newNoneInstance(name)
 	}
@@ -98,53 +131,226 @@ func newGenerateToFileInstance(conf Config, name string, ks *keyStore.KeyStore)
 	if err := checkForIsCa(name, ks); err != nil {
 		return nil, err
 	}
-	pem, cert, err := ks.GeneratePem(name)
-	if err != nil {
-		return nil, errors.New("Could not generate pem for '%v'.", name).CausedBy(err)
+	pem, cert, ok := loadReusablePemFile(conf, name, ks)
+	if !ok {
+		var err error
+		pem, cert, err = ks.GeneratePem(name)
+		if err != nil {
+			return nil, errors.New("Could not generate pem for '%v'.", name).CausedBy(err)
+		}
 	}
-	file, err := generateFileForPem(conf, pem)
+	files, err := generateFilesForPem(conf, name, pem, ks)
 	if err != nil {
 		return nil, errors.New("Could not generate pem file for '%v'.", name).CausedBy(err)
 	}
 	return &Access{
-		t:                 GenerateToFile,
-		permission:        conf.Permission,
-		name:              name,
-		pem:               pem,
-		cert:              cert,
-		temporaryFilename: &file,
+		t:                  GenerateToFile,
+		permission:         conf.Permission,
+		name:               name,
+		pem:                pem,
+		cert:               cert,
+		temporaryFilenames: files,
 	}, nil
 }
 
-func generateFileForPem(conf Config, pem []byte) (string, error) {
+// Loads the already existing pemFile if it was generated for the given name with the current certificate of
+// caretakerd, is not revoked and has not to be renewed yet. This keeps pemFiles valid over restarts of caretakerd if its
+// certificate is stored in the stateDirectory of the keyStore.
+func loadReusablePemFile(conf Config, name string, ks *keyStore.KeyStore) ([]byte, *x509.Certificate, bool) {
+	pem, err := os.ReadFile(conf.PemFile.String())
+	if err != nil {
+		return nil, nil, false
+	}
+	keyPair, err := tls.X509KeyPair(pem, pem)
+	if err != nil {
+		return nil, nil, false
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil || cert.Subject.CommonName != name || !ks.HasIssued(cert) || ks.IsRevoked(cert) || ks.IsRenewalRequiredFor(cert) {
+		return nil, nil, false
+	}
+	return pem, cert, true
+}
+
+// Writes the pemFile and every other configured file that is derived of the given pem.
+// Returns the names of the written files.
+func generateFilesForPem(conf Config, name string, p []byte, ks *keyStore.KeyStore) ([]string, error) {
+	files := []struct {
+		filename values.String
+		content  func() ([]byte, error)
+	}{
+		{conf.PemFile, func() ([]byte, error) { return p, nil }},
+		{conf.CertFile, func() ([]byte, error) { return pemBlocksOf(p, isCertificateBlock), nil }},
+		{conf.KeyFile, func() ([]byte, error) { return pemBlocksOf(p, isPrivateKeyBlock), nil }},
+		{conf.CaBundleFile, func() ([]byte, error) { return ks.CABundle(), nil }},
+		{conf.Pkcs12File, func() ([]byte, error) { return pkcs12Of(conf, name, p) }},
+	}
+	var result []string
+	for _, file := range files {
+		if file.filename.IsTrimmedEmpty() {
+			continue
+		}
+		content, err := file.content()
+		if err != nil {
+			return nil, errors.New("Could not generate content of '%s'.", file.filename).CausedBy(err)
+		}
+		if err := generateFile(conf, file.filename.String(), content); err != nil {
+			return nil, err
+		}
+		result = append(result, file.filename.String())
+	}
+	return result, nil
+}
+
+// Writes the given content to a temporary file first and replaces the file afterwards. This prevents clients
+// from reading partially written files or a new certificate with an old key while the files are renewed.
+func generateFile(conf Config, filename string, content []byte) (err error) {
+	temporaryFilename := filename + ".tmp"
+	defer func() {
+		if err != nil {
+			_ = os.Remove(temporaryFilename)
+		}
+	}()
+	if err := writeFile(conf, filename, temporaryFilename, content); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryFilename, filename); err != nil {
+		return errors.New("Could not write file '%s'.", filename).CausedBy(err)
+	}
+	return nil
+}
+
+// Writes the given content to temporaryFilename. Problems are reported for filename.
+func writeFile(conf Config, filename string, temporaryFilename string, content []byte) error {
 	permission := conf.PemFilePermission.ThisOrDefault().AsFileMode()
-	f, err := os.OpenFile(conf.PemFile.String(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, permission)
+	f, err := os.OpenFile(temporaryFilename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, permission)
 	if err != nil {
-		return "", errors.New("Could not create pemFile '%s'.", conf.PemFile).CausedBy(err)
+		return errors.New("Could not create file '%s'.", filename).CausedBy(err)
 	}
 	defer func() { _ = f.Close() }()
-	if !conf.PemFileUser.IsEmpty() {
-		_, lerr := user.Lookup(conf.PemFileUser.String())
+	// The permission of OpenFile is only applied if the file is created.
+	if err := f.Chmod(permission); err != nil {
+		return errors.New("Could not set permission of file '%s' to %v.", filename, conf.PemFilePermission.ThisOrDefault()).CausedBy(err)
+	}
+	if !conf.PemFileUser.IsEmpty() || !conf.PemFileGroup.IsEmpty() {
+		uid, gid, lerr := ownerOf(conf)
 		if lerr != nil {
-			return "", errors.New("Could not set ownership of pemFile '%s' to '%s'.", conf.PemFile, conf.PemFileUser).CausedBy(err)
+			return errors.New("Could not set ownership of file '%s' to '%s:%s'.", filename, conf.PemFileUser, conf.PemFileGroup).CausedBy(lerr)
+		}
+		if err := f.Chown(uid, gid); err != nil {
+			return errors.New("Could not set ownership of file '%s' to '%s:%s'.", filename, conf.PemFileUser, conf.PemFileGroup).CausedBy(err)
 		}
-		//f.Chown(kfu.Uid, kfu.Gid) TODO!
 	}
-	if _, err := f.Write(pem); err != nil {
-		return "", errors.New("Could not write pemFile '%s'.", conf.PemFile).CausedBy(err)
+	if _, err := f.Write(content); err != nil {
+		return errors.New("Could not write file '%s'.", filename).CausedBy(err)
 	}
 	if err := f.Sync(); err != nil {
-		return "", errors.New("Could not sync pemFile '%s'.", conf.PemFile).CausedBy(err)
+		return errors.New("Could not sync file '%s'.", filename).CausedBy(err)
+	}
+	return f.Close()
+}
+
+// Returns the ids of the configured pemFileUser and pemFileGroup. If one of them is not configured -1 is returned for it.
+func ownerOf(conf Config) (uid int, gid int, err error) {
+	uid, gid = -1, -1
+	if !conf.PemFileUser.IsEmpty() {
+		id, err := resolveID(conf.PemFileUser, lookupUserID)
+		if err != nil {
+			return 0, 0, errors.New("Could not resolve user '%v'.", conf.PemFileUser).CausedBy(err)
+		}
+		uid = int(id)
+	}
+	if !conf.PemFileGroup.IsEmpty() {
+		id, err := resolveID(conf.PemFileGroup, lookupGroupID)
+		if err != nil {
+			return 0, 0, errors.New("Could not resolve group '%v'.", conf.PemFileGroup).CausedBy(err)
+		}
+		gid = int(id)
+	}
+	return uid, gid, nil
+}
+
+func isCertificateBlock(block *pem.Block) bool {
+	return block.Type == "CERTIFICATE"
+}
+
+func isPrivateKeyBlock(block *pem.Block) bool {
+	return strings.HasSuffix(block.Type, "PRIVATE KEY")
+}
+
+// Returns every block of the given pem that matches the given predicate.
+func pemBlocksOf(p []byte, predicate func(block *pem.Block) bool) []byte {
+	var result []byte
+	for rest := p; len(rest) > 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if predicate(block) {
+			result = append(result, pem.EncodeToMemory(block)...)
+		}
 	}
-	return conf.PemFile.String(), nil
+	return result
+}
+
+func pkcs12Of(conf Config, name string, p []byte) ([]byte, error) {
+	password := os.Getenv(conf.Pkcs12PasswordEnv.String())
+	if password == "" {
+		return nil, errors.New("The environment variable '%v' that should contain the password of pkcs12File '%v' is empty.", conf.Pkcs12PasswordEnv, conf.Pkcs12File)
+	}
+	return keyStore.EncodePKCS12(p, name, password)
 }
 
 // Pem queries the contained private and public key pair.
 // This can be empty.
 func (instance Access) Pem() []byte {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
 	return instance.pem
 }
 
+// Certificate queries the certificate of this access object.
+// This can be nil.
+func (instance Access) Certificate() *x509.Certificate {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
+	return instance.cert
+}
+
+// IsRenewable returns "true" if the certificate of this access object is generated by caretakerd
+// and could be renewed using Renew.
+func (instance Access) IsRenewable() bool {
+	return instance.t.IsGenerating()
+}
+
+// Renew generates a new certificate for this access object using the given KeyStore.
+// If the type is GenerateToFile the pemFile is rewritten.
+func (instance *Access) Renew(ks *keyStore.KeyStore) error {
+	if !instance.IsRenewable() {
+		return errors.New("The certificate of '%v' with access type %v could not be renewed.", instance.name, instance.t)
+	}
+	pem, cert, err := ks.GeneratePem(instance.name)
+	if err != nil {
+		return errors.New("Could not generate pem for '%v'.", instance.name).CausedBy(err)
+	}
+	if instance.t == GenerateToFile {
+		if _, err := generateFilesForPem(instance.config, instance.name, pem, ks); err != nil {
+			return errors.New("Could not generate pem file for '%v'.", instance.name).CausedBy(err)
+		}
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	instance.pem = pem
+	instance.cert = cert
+	return nil
+}
+
+// Name queries the name of the service/node this access object belongs to.
+func (instance Access) Name() string {
+	return instance.name
+}
+
 // Type queries the type of this access object.
 func (instance Access) Type() Type {
 	return instance.t
@@ -153,11 +359,16 @@ func (instance Access) Type() Type {
 // Cleanup cleans up tasks when the given object is not longer required.
 // This could delete action of temporary files ...
 func (instance Access) Cleanup() {
-	if instance.temporaryFilename != nil {
-		_ = os.Remove(*instance.temporaryFilename)
+	for _, filename := range instance.temporaryFilenames {
+		_ = os.Remove(filename)
 	}
 }
 
+// Roles queries the names of the roles that are bound to this access instance.
+func (instance Access) Roles() []string {
+	return instance.roles
+}
+
 // HasReadPermission queries whether the service/node that this access instance belongs to
 // can execute read actions in caretakerd.
 func (instance Access) HasReadPermission() bool {
@@ -175,8 +386,8 @@ func (instance Access) HasWritePermission() bool {
 // IsCertValid queries whether the given Certificate is valid in combination
 // with this access instance.
 func (instance *Access) IsCertValid(cert *x509.Certificate) bool {
-	instanceCert := instance.cert
-	if instance.t == None {
+	instanceCert := instance.Certificate()
+	if instance.t == None || instance.t == PeerCredentials || instance.t.IsUsingTokens() {
 		return false
 	} else if cert == nil && instanceCert == nil {
 		return false
@@ -0,0 +1,135 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"crypto/tls"
+	"github.com/echocat/caretakerd/keyStore"
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+type AccessTest struct{}
+
+func init() {
+	Suite(&AccessTest{})
+}
+
+func (s *AccessTest) TestGenerateToFileWithOutputFiles(c *C) {
+	ksConf := keyStore.NewConfig()
+	ksConf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, ksConf)
+	c.Assert(err, IsNil)
+
+	directory := c.MkDir()
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(directory, "test.pem")))
+	conf.PemFilePermission = FilePermission(0640)
+	conf.PemFileGroup = values.String(strconv.Itoa(os.Getgid()))
+	conf.CertFile = values.String(filepath.Join(directory, "test.crt"))
+	conf.KeyFile = values.String(filepath.Join(directory, "test.key"))
+	conf.CaBundleFile = values.String(filepath.Join(directory, "ca.crt"))
+	conf.Pkcs12File = values.String(filepath.Join(directory, "test.p12"))
+	conf.Pkcs12PasswordEnv = values.String("CTD_TEST_PKCS12_PASSWORD")
+	c.Assert(os.Setenv("CTD_TEST_PKCS12_PASSWORD", "secret"), IsNil)
+	defer func() { _ = os.Unsetenv("CTD_TEST_PKCS12_PASSWORD") }()
+
+	acc, err := NewAccess(conf, "test", ks)
+	c.Assert(err, IsNil)
+	for _, filename := range []values.String{conf.PemFile, conf.CertFile, conf.KeyFile, conf.CaBundleFile, conf.Pkcs12File} {
+		info, err := os.Stat(filename.String())
+		c.Assert(err, IsNil)
+		c.Assert(info.Mode().Perm(), Equals, os.FileMode(0640))
+	}
+
+	_, err = tls.LoadX509KeyPair(conf.CertFile.String(), conf.KeyFile.String())
+	c.Assert(err, IsNil)
+	certs, err := os.ReadFile(conf.CertFile.String())
+	c.Assert(err, IsNil)
+	c.Assert(string(certs), Not(Matches), "(?s).*PRIVATE KEY.*")
+	caBundle, err := os.ReadFile(conf.CaBundleFile.String())
+	c.Assert(err, IsNil)
+	c.Assert(string(caBundle), Matches, "(?s)-----BEGIN CERTIFICATE-----.*")
+
+	acc.Cleanup()
+	for _, filename := range []values.String{conf.PemFile, conf.CertFile, conf.KeyFile, conf.CaBundleFile, conf.Pkcs12File} {
+		_, err := os.Stat(filename.String())
+		c.Assert(os.IsNotExist(err), Equals, true)
+	}
+}
+
+func (s *AccessTest) TestGenerateToFileWithoutPkcs12Password(c *C) {
+	ksConf := keyStore.NewConfig()
+	ksConf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, ksConf)
+	c.Assert(err, IsNil)
+
+	directory := c.MkDir()
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(directory, "test.pem")))
+	conf.Pkcs12File = values.String(filepath.Join(directory, "test.p12"))
+	conf.Pkcs12PasswordEnv = values.String("CTD_TEST_MISSING_PKCS12_PASSWORD")
+	_, err = NewAccess(conf, "test", ks)
+	c.Assert(err, ErrorMatches, "(?s)Could not generate pem file for 'test'.*The environment variable 'CTD_TEST_MISSING_PKCS12_PASSWORD' .* is empty.*")
+}
+
+func (s *AccessTest) TestGenerateToFileWithEmptyPkcs12Password(c *C) {
+	ksConf := keyStore.NewConfig()
+	ksConf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, ksConf)
+	c.Assert(err, IsNil)
+
+	directory := c.MkDir()
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(directory, "test.pem")))
+	conf.Pkcs12File = values.String(filepath.Join(directory, "test.p12"))
+	conf.Pkcs12PasswordEnv = values.String("CTD_TEST_EMPTY_PKCS12_PASSWORD")
+	c.Assert(os.Setenv("CTD_TEST_EMPTY_PKCS12_PASSWORD", ""), IsNil)
+	defer func() { _ = os.Unsetenv("CTD_TEST_EMPTY_PKCS12_PASSWORD") }()
+	_, err = NewAccess(conf, "test", ks)
+	c.Assert(err, ErrorMatches, "(?s)Could not generate pem file for 'test'.*The environment variable 'CTD_TEST_EMPTY_PKCS12_PASSWORD' .* is empty.*")
+	_, err = os.Stat(conf.Pkcs12File.String())
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *AccessTest) TestGenerateToFileReusesOnlyNotRevokedPemFile(c *C) {
+	ksConf := keyStore.NewConfig()
+	ksConf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, ksConf)
+	c.Assert(err, IsNil)
+
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(c.MkDir(), "test.pem")))
+	first, err := NewAccess(conf, "test", ks)
+	c.Assert(err, IsNil)
+	reused, err := NewAccess(conf, "test", ks)
+	c.Assert(err, IsNil)
+	c.Assert(reused.Certificate().SerialNumber.Cmp(first.Certificate().SerialNumber), Equals, 0)
+
+	_, err = ks.Revoke(first.Certificate().SerialNumber.Text(16))
+	c.Assert(err, IsNil)
+	renewed, err := NewAccess(conf, "test", ks)
+	c.Assert(err, IsNil)
+	c.Assert(renewed.Certificate().SerialNumber.Cmp(first.Certificate().SerialNumber), Not(Equals), 0)
+	c.Assert(ks.IsRevoked(renewed.Certificate()), Equals, false)
+}
+
+func (s *AccessTest) TestOwnerOf(c *C) {
+	uid, gid, err := ownerOf(Config{})
+	c.Assert(err, IsNil)
+	c.Assert(uid, Equals, -1)
+	c.Assert(gid, Equals, -1)
+
+	uid, gid, err = ownerOf(Config{PemFileUser: "4711", PemFileGroup: "4712"})
+	c.Assert(err, IsNil)
+	c.Assert(uid, Equals, 4711)
+	c.Assert(gid, Equals, 4712)
+
+	uid, gid, err = ownerOf(Config{PemFileGroup: "4712"})
+	c.Assert(err, IsNil)
+	c.Assert(uid, Equals, -1)
+	c.Assert(gid, Equals, 4712)
+
+	_, _, err = ownerOf(Config{PemFileUser: "caretakerd-test-unknown-user"})
+	c.Assert(err, ErrorMatches, "(?s)Could not resolve user 'caretakerd-test-unknown-user'.*")
+	_, _, err = ownerOf(Config{PemFileGroup: "caretakerd-test-unknown-group"})
+	c.Assert(err, ErrorMatches, "(?s)Could not resolve group 'caretakerd-test-unknown-group'.*")
+}
@@ -0,0 +1,62 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+func (s *AccessTest) TestGenerateFileWithOwner(c *C) {
+	// Only root is allowed to give files away. Everyone is allowed to keep its own user and groups.
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		uid, gid = 65534, 65534
+	}
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(c.MkDir(), "test.pem")))
+	conf.PemFileUser = values.String(strconv.Itoa(uid))
+	conf.PemFileGroup = values.String(strconv.Itoa(gid))
+	c.Assert(generateFile(conf, conf.PemFile.String(), []byte("foo")), IsNil)
+
+	info, err := os.Stat(conf.PemFile.String())
+	c.Assert(err, IsNil)
+	c.Assert(int(info.Sys().(*syscall.Stat_t).Uid), Equals, uid)
+	c.Assert(int(info.Sys().(*syscall.Stat_t).Gid), Equals, gid)
+	c.Assert(info.Mode().Perm(), Equals, DefaultFilePermission().AsFileMode())
+	content, err := os.ReadFile(conf.PemFile.String())
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "foo")
+}
+
+func (s *AccessTest) TestGenerateFileWithUnknownOwner(c *C) {
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(c.MkDir(), "test.pem")))
+	conf.PemFileGroup = values.String("caretakerd-test-unknown-group")
+	err := generateFile(conf, conf.PemFile.String(), []byte("foo"))
+	c.Assert(err, ErrorMatches, "(?s)Could not set ownership of file '.*test.pem' to ':caretakerd-test-unknown-group'.*")
+}
+
+func (s *AccessTest) TestGenerateFileReplacesExistingFile(c *C) {
+	conf := NewGenerateToFileConfig(ReadOnly, values.String(filepath.Join(c.MkDir(), "test.pem")))
+	c.Assert(generateFile(conf, conf.PemFile.String(), []byte("foo")), IsNil)
+	old, err := os.Open(conf.PemFile.String())
+	c.Assert(err, IsNil)
+	defer func() { _ = old.Close() }()
+
+	c.Assert(generateFile(conf, conf.PemFile.String(), []byte("bar")), IsNil)
+	// Clients that have opened the file before still read the complete old content.
+	content, err := io.ReadAll(old)
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "foo")
+	content, err = os.ReadFile(conf.PemFile.String())
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "bar")
+	_, err = os.Stat(conf.PemFile.String() + ".tmp")
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
@@ -36,14 +36,104 @@ type Config struct {
 
 	// @default 0600
 	//
-	// Permission in filesystem of the generated {@ref #PemFile pem file}.
+	// Permission in filesystem of the generated {@ref #PemFile pem file} and of every other generated file
+	// like the {@ref #CertFile cert file}.
 	PemFilePermission FilePermission `json:"pemFilePermission,omitempty" yaml:"pemFilePermission"`
 
 	// @default ""
 	//
-	// If set, this user owns the generated {@ref #PemFile pem file}.
-	// Otherwise it is owned by the user caretakerd is running with.
+	// If set, this user (name or id) owns the generated {@ref #PemFile pem file} and every other generated file.
+	// Otherwise they are owned by the user caretakerd is running with.
+	//
+	// > **Hint:** Changing the owner requires that caretakerd is running as root.
 	PemFileUser values.String `json:"pemFileUser,omitempty" yaml:"pemFileUser"`
+
+	// @default ""
+	//
+	// If set, this group (name or id) owns the generated {@ref #PemFile pem file} and every other generated file.
+	// Otherwise they are owned by the group caretakerd is running with.
+	PemFileGroup values.String `json:"pemFileGroup,omitempty" yaml:"pemFileGroup,omitempty"`
+
+	// @default ""
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#GenerateToFile generateToFile},
+	// caretakerd writes the certificates of the {@ref #PemFile pem file} - without the private key - also to this file.
+	CertFile values.String `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+
+	// @default ""
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#GenerateToFile generateToFile},
+	// caretakerd writes the private key of the {@ref #PemFile pem file} also to this file.
+	KeyFile values.String `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+
+	// @default ""
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#GenerateToFile generateToFile},
+	// caretakerd writes the certificates that are required to verify caretakerd to this file. These are the certificate
+	// of caretakerd and the certificates of the {@ref github.com/echocat/caretakerd/keyStore.Config#CaFile caFile}.
+	CaBundleFile values.String `json:"caBundleFile,omitempty" yaml:"caBundleFile,omitempty"`
+
+	// @default ""
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#GenerateToFile generateToFile},
+	// caretakerd writes the certificates and the private key of the {@ref #PemFile pem file} also to this file using the
+	// PKCS#12 format. The private key is encrypted with the password of {@ref #Pkcs12PasswordEnv pkcs12PasswordEnv}.
+	Pkcs12File values.String `json:"pkcs12File,omitempty" yaml:"pkcs12File,omitempty"`
+
+	// @default ""
+	//
+	// Name of the environment variable of caretakerd that contains the password of the {@ref #Pkcs12File pkcs12File}.
+	//
+	// > **Important:** If the property {@ref #Pkcs12File pkcs12File} is set, this property is required.
+	Pkcs12PasswordEnv values.String `json:"pkcs12PasswordEnv,omitempty" yaml:"pkcs12PasswordEnv,omitempty"`
+
+	// @default []
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#PeerCredentials peerCredentials},
+	// local processes running with one of these users (names or ids) are trusted.
+	//
+	// > **Important:** Every process of these users is trusted - this includes services that are running
+	// > with one of them. Services without a {@ref github.com/echocat/caretakerd/service.Config#User user}
+	// > are running with the same user as caretakerd.
+	//
+	// > **Hint:** At least one user or {@ref #Groups group} is required.
+	Users []values.String `json:"users,omitempty" yaml:"users,omitempty"`
+
+	// @default []
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#PeerCredentials peerCredentials},
+	// local processes running with a user that is member of one of these groups (names or ids) are trusted.
+	//
+	// > **Hint:** At least one group or {@ref #Users user} is required.
+	Groups []values.String `json:"groups,omitempty" yaml:"groups,omitempty"`
+
+	// @default []
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#Token token},
+	// requests with one of these tokens are trusted.
+	//
+	// > **Hint:** These tokens are never revealed - not even by the config endpoint of caretakerd.
+	Tokens []values.Secret `json:"tokens,omitempty" yaml:"tokens,omitempty"`
+
+	// @default ""
+	//
+	// If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#Token token},
+	// requests with one of the tokens in this file are trusted. The file contains one token per line.
+	// Empty lines and lines starting with ``#`` are ignored.
+	//
+	// > **Important:** If the property {@ref #Type type} = {@ref github.com/echocat/caretakerd/access.Type#Token token},
+	// > this property or {@ref #Tokens tokens} has to provide at least one token.
+	TokenFile values.String `json:"tokenFile,omitempty" yaml:"tokenFile,omitempty"`
+
+	// @default []
+	//
+	// Names of {@ref github.com/echocat/caretakerd/rpc.Config#Roles roles} that are bound to this access.
+	// Every verb that is granted by one of these roles is allowed in addition to the {@ref #Permission permission}.
+	//
+	// This makes it possible to grant only specific actions on specific services - like restarting
+	// only the siblings of a service - if the {@ref #Permission permission} is
+	// {@ref github.com/echocat/caretakerd/access.Permission#Forbidden forbidden}.
+	Roles []values.String `json:"roles,omitempty" yaml:"roles,omitempty,flow"`
 }
 
 // NewNoneConfig creates a new Config that denies access to anything.
@@ -83,6 +173,26 @@ func NewGenerateToFileConfig(permission Permission, pemFile values.String) Confi
 	}
 }
 
+// NewPeerCredentialsConfig creates a new Config with the given permission
+// that trusts local processes of the given users.
+func NewPeerCredentialsConfig(permission Permission, users ...values.String) Config {
+	return Config{
+		Type:       PeerCredentials,
+		Permission: permission,
+		Users:      users,
+	}
+}
+
+// NewTokenConfig creates a new Config with the given permission
+// that trusts requests with one of the given tokens.
+func NewTokenConfig(permission Permission, tokens ...values.Secret) Config {
+	return Config{
+		Type:       Token,
+		Permission: permission,
+		Tokens:     tokens,
+	}
+}
+
 // Validate validates an action on this object and returns an error object if there is any.
 func (instance Config) Validate() error {
 	err := instance.Type.Validate()
@@ -95,9 +205,42 @@ func (instance Config) Validate() error {
 	if err == nil {
 		err = instance.validateStringOnlyAllowedValue(instance.PemFileUser, "pemFileUser", instance.Type.IsTakingFileUser, values.String(""))
 	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.PemFileGroup, "pemFileGroup", instance.Type.IsTakingFileGroup, values.String(""))
+	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.CertFile, "certFile", instance.Type.IsTakingFilename, values.String(""))
+	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.KeyFile, "keyFile", instance.Type.IsTakingFilename, values.String(""))
+	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.CaBundleFile, "caBundleFile", instance.Type.IsTakingFilename, values.String(""))
+	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.Pkcs12File, "pkcs12File", instance.Type.IsTakingFilename, values.String(""))
+	}
+	if err == nil && instance.Pkcs12File.IsTrimmedEmpty() != instance.Pkcs12PasswordEnv.IsTrimmedEmpty() {
+		err = errors.New("The properties pkcs12File and pkcs12PasswordEnv have to be set together for type %v.", instance.Type)
+	}
 	if err == nil {
 		err = instance.validateUint32OnlyAllowedValue(uint32(instance.PemFilePermission), "pemFilePermission", instance.Type.IsTakingFilePermission, uint32(DefaultFilePermission()))
 	}
+	if err == nil {
+		err = instance.validateStringsOnlyAllowedValue(instance.Users, "users", instance.Type.IsTakingPeers)
+	}
+	if err == nil {
+		err = instance.validateStringsOnlyAllowedValue(instance.Groups, "groups", instance.Type.IsTakingPeers)
+	}
+	if err == nil && instance.Type.IsTakingPeers() && len(instance.Users) == 0 && len(instance.Groups) == 0 {
+		err = errors.New("There are neither users nor groups set for type %v.", instance.Type)
+	}
+	if err == nil {
+		err = instance.validateStringOnlyAllowedValue(instance.TokenFile, "tokenFile", instance.Type.IsTakingTokens, values.String(""))
+	}
+	if err == nil && !instance.Type.IsTakingTokens() && len(instance.Tokens) > 0 {
+		err = errors.New("There is no tokens allowed for type %v.", instance.Type)
+	}
 	return err
 }
 
@@ -117,6 +260,13 @@ func (instance Config) validateStringOnlyAllowedValue(value values.String, field
 	return nil
 }
 
+func (instance Config) validateStringsOnlyAllowedValue(value []values.String, fieldName string, isAllowedMethod func() bool) error {
+	if !isAllowedMethod() && len(value) > 0 {
+		return errors.New("There is no %s allowed for type %v.", fieldName, instance.Type)
+	}
+	return nil
+}
+
 func (instance Config) validateUint32OnlyAllowedValue(value uint32, fieldName string, isAllowedMethod func() bool, defaultValue uint32) error {
 	if !isAllowedMethod() && value != defaultValue && value != 0 {
 		return errors.New("There is no %s allowed for type %v.", fieldName, instance.Type)
@@ -91,6 +91,9 @@ func (s *ConfigTest) TestValidateNotRequiredPemFile(c *C) {
 				Permission: ReadOnly,
 				PemFile:    values.String(""),
 			}
+			if t.IsTakingPeers() {
+				actual.Users = []values.String{"root"}
+			}
 			c.Assert(actual.Validate(), IsNil)
 			actual.PemFile = values.String("foo/bar.pem")
 			c.Assert(actual.Validate(), IsNil)
@@ -122,6 +125,9 @@ func (s *ConfigTest) TestValidateNotAllowedPemFileUser(c *C) {
 				Permission: ReadOnly,
 				PemFile:    values.String(""),
 			}
+			if t.IsTakingPeers() {
+				actual.Users = []values.String{"root"}
+			}
 			actual.PemFileUser = values.String("")
 			c.Assert(actual.Validate(), IsNil)
 			actual.PemFileUser = values.String("foo")
@@ -130,6 +136,42 @@ func (s *ConfigTest) TestValidateNotAllowedPemFileUser(c *C) {
 	}
 }
 
+func (s *ConfigTest) TestValidateNotAllowedPemFileGroup(c *C) {
+	for _, t := range AllTypes {
+		if !t.IsTakingFileGroup() {
+			actual := Config{
+				Type:       t,
+				Permission: ReadOnly,
+			}
+			actual.PemFileGroup = values.String("foo")
+			c.Assert(actual.Validate(), ErrorMatches, "There is no pemFileGroup allowed for type "+t.String()+".")
+		}
+	}
+}
+
+func (s *ConfigTest) TestValidateOutputFiles(c *C) {
+	actual := NewGenerateToFileConfig(ReadOnly, values.String("foo/bar.pem"))
+	actual.PemFileGroup = values.String("foo")
+	actual.CertFile = values.String("foo/bar.crt")
+	actual.KeyFile = values.String("foo/bar.key")
+	actual.CaBundleFile = values.String("foo/ca.crt")
+	c.Assert(actual.Validate(), IsNil)
+
+	actual.Pkcs12File = values.String("foo/bar.p12")
+	c.Assert(actual.Validate(), ErrorMatches, "The properties pkcs12File and pkcs12PasswordEnv have to be set together for type generateToFile.")
+	actual.Pkcs12PasswordEnv = values.String("BAR_PASSWORD")
+	c.Assert(actual.Validate(), IsNil)
+	actual.Pkcs12File = values.String("")
+	c.Assert(actual.Validate(), ErrorMatches, "The properties pkcs12File and pkcs12PasswordEnv have to be set together for type generateToFile.")
+
+	actual = NewGenerateToEnvironmentConfig(ReadOnly)
+	actual.CertFile = values.String("foo/bar.crt")
+	c.Assert(actual.Validate(), ErrorMatches, "There is no certFile allowed for type generateToEnvironment.")
+	actual.CertFile = values.String("")
+	actual.Pkcs12File = values.String("foo/bar.p12")
+	c.Assert(actual.Validate(), ErrorMatches, "There is no pkcs12File allowed for type generateToEnvironment.")
+}
+
 func (s *ConfigTest) TestValidateAllowedPemFilePermission(c *C) {
 	for _, t := range AllTypes {
 		if t.IsTakingFilePermission() {
@@ -154,6 +196,9 @@ func (s *ConfigTest) TestValidateNotAllowedFilePermission(c *C) {
 				Permission: ReadOnly,
 				PemFile:    values.String(""),
 			}
+			if t.IsTakingPeers() {
+				actual.Users = []values.String{"root"}
+			}
 			actual.PemFilePermission = FilePermission(0)
 			c.Assert(actual.Validate(), IsNil)
 			actual.PemFilePermission = DefaultFilePermission()
@@ -163,3 +208,48 @@ func (s *ConfigTest) TestValidateNotAllowedFilePermission(c *C) {
 		}
 	}
 }
+
+func (s *ConfigTest) TestNewPeerCredentialsConfig(c *C) {
+	actual := NewPeerCredentialsConfig(ReadWrite, values.String("root"))
+	c.Assert(actual.Type, Equals, PeerCredentials)
+	c.Assert(actual.Permission, Equals, ReadWrite)
+	c.Assert(actual.Users, DeepEquals, []values.String{"root"})
+	c.Assert(actual.Validate(), IsNil)
+}
+
+func (s *ConfigTest) TestValidateRequiredPeers(c *C) {
+	actual := NewPeerCredentialsConfig(ReadWrite)
+	c.Assert(actual.Validate(), ErrorMatches, "There are neither users nor groups set for type peerCredentials.")
+	actual.Groups = []values.String{"root"}
+	c.Assert(actual.Validate(), IsNil)
+}
+
+func (s *ConfigTest) TestValidateUsersOnlyForPeerCredentials(c *C) {
+	actual := NewTrustedConfig(ReadOnly)
+	actual.Users = []values.String{"root"}
+	c.Assert(actual.Validate(), ErrorMatches, "There is no users allowed for type trusted.")
+	actual.Users = nil
+	actual.Groups = []values.String{"root"}
+	c.Assert(actual.Validate(), ErrorMatches, "There is no groups allowed for type trusted.")
+}
+
+func (s *ConfigTest) TestNewTokenConfig(c *C) {
+	actual := NewTokenConfig(ReadOnly, values.Secret("foo"))
+	c.Assert(actual.Type, Equals, Token)
+	c.Assert(actual.Permission, Equals, ReadOnly)
+	c.Assert(actual.Tokens, DeepEquals, []values.Secret{"foo"})
+	c.Assert(actual.Validate(), IsNil)
+}
+
+func (s *ConfigTest) TestValidateTokens(c *C) {
+	actual := NewTokenConfig(ReadOnly)
+	actual.TokenFile = values.String("tokens.txt")
+	c.Assert(actual.Validate(), IsNil)
+
+	actual = NewTrustedConfig(ReadOnly)
+	actual.Tokens = []values.Secret{"foo"}
+	c.Assert(actual.Validate(), ErrorMatches, "There is no tokens allowed for type trusted.")
+	actual.Tokens = nil
+	actual.TokenFile = values.String("tokens.txt")
+	c.Assert(actual.Validate(), ErrorMatches, "There is no tokenFile allowed for type trusted.")
+}
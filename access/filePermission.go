@@ -12,6 +12,7 @@ var filePermissionOctPattern = regexp.MustCompile(`^(\d?)(\d)(\d)(\d)$`)
 
 // FilePermission represents a operating system file permission.
 // @inline
+// @serializedAs string
 type FilePermission os.FileMode
 
 func (instance FilePermission) String() string {
@@ -0,0 +1,112 @@
+package access
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/keyStore"
+	"sync"
+)
+
+// Object identifier of the certificate extension that contains the permission and roles of an issued certificate.
+// caretakerd has no registered private enterprise number, so it is located in the experimental arc (1.3.6.1.3) of RFC 1155.
+var issuedExtensionID = asn1.ObjectIdentifier{1, 3, 6, 1, 3, 4711, 1}
+
+// ASN.1 representation of the extension. The roles are raw values to encode them as UTF8String.
+type issuedExtension struct {
+	Permission string `asn1:"utf8"`
+	Roles      []asn1.RawValue
+}
+
+// IssuePem generates a new pem for the given name using the given KeyStore. The given permission and
+// roles are embedded into the certificate and are honored by NewIssuedAccess.
+func IssuePem(ks *keyStore.KeyStore, name string, permission Permission, roles []string) ([]byte, *x509.Certificate, error) {
+	if err := permission.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if permission == Forbidden && len(roles) == 0 {
+		return nil, nil, errors.New("There is neither a permission nor a role to issue a certificate for '%v' with.", name)
+	}
+	if err := checkForIsCa(name, ks); err != nil {
+		return nil, nil, err
+	}
+	ext := issuedExtension{
+		Permission: permission.String(),
+	}
+	for _, role := range roles {
+		ext.Roles = append(ext.Roles, asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(role)})
+	}
+	value, err := asn1.Marshal(ext)
+	if err != nil {
+		return nil, nil, errors.New("Could not encode permission of '%v'.", name).CausedBy(err)
+	}
+	pem, cert, err := ks.GeneratePemWithExtensions(name, pkix.Extension{Id: issuedExtensionID, Value: value})
+	if err != nil {
+		return nil, nil, errors.New("Could not generate pem for '%v'.", name).CausedBy(err)
+	}
+	return pem, cert, nil
+}
+
+// NewIssuedAccess creates an access instance from the permission and roles that are embedded into the given
+// certificate by IssuePem. Returns "false" if the certificate does not contain them.
+// It is not checked if the certificate was issued by the KeyStore of caretakerd.
+func NewIssuedAccess(cert *x509.Certificate) (*Access, bool) {
+	if cert == nil {
+		return nil, false
+	}
+	for _, candidate := range cert.Extensions {
+		if !candidate.Id.Equal(issuedExtensionID) {
+			continue
+		}
+		var ext issuedExtension
+		if rest, err := asn1.Unmarshal(candidate.Value, &ext); err != nil || len(rest) > 0 {
+			return nil, false
+		}
+		var permission Permission
+		if err := permission.Set(ext.Permission); err != nil {
+			return nil, false
+		}
+		result := &Access{
+			t:          Trusted,
+			permission: permission,
+			name:       cert.Subject.CommonName,
+			cert:       cert,
+			lock:       new(sync.RWMutex),
+		}
+		for _, role := range ext.Roles {
+			result.roles = append(result.roles, string(role.Bytes))
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// IsAllowedToIssue queries whether the given permission and roles are covered by the given access instances of the
+// caller. The permission has to be held by one of them and every role has to be bound to one of them. The permission
+// readWrite covers every permission and every role. This prevents to issue certificates with more rights than the
+// own ones.
+func IsAllowedToIssue(accesses []*Access, permission Permission, roles []string) bool {
+	permitted := permission == Forbidden
+	held := map[string]bool{}
+	for _, acc := range accesses {
+		if acc.HasWritePermission() {
+			return true
+		}
+		if permission == ReadOnly && acc.HasReadPermission() {
+			permitted = true
+		}
+		for _, role := range acc.Roles() {
+			held[role] = true
+		}
+	}
+	if !permitted {
+		return false
+	}
+	for _, role := range roles {
+		if !held[role] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/echocat/caretakerd/keyStore"
+)
+
+type IssuedTest struct{}
+
+func init() {
+	Suite(&IssuedTest{})
+}
+
+func (s *IssuedTest) newKeyStore(c *C) *keyStore.KeyStore {
+	conf := keyStore.NewConfig()
+	conf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	return ks
+}
+
+func (s *IssuedTest) TestIssuePem(c *C) {
+	ks := s.newKeyStore(c)
+	pem, cert, err := IssuePem(ks, "alice", ReadOnly, []string{"restart_workers", "configReader"})
+	c.Assert(err, IsNil)
+	c.Assert(cert.Subject.CommonName, Equals, "alice")
+	c.Assert(ks.HasIssued(cert), Equals, true)
+
+	keyPair, err := tls.X509KeyPair(pem, pem)
+	c.Assert(err, IsNil)
+	parsed, err := x509.ParseCertificate(keyPair.Certificate[0])
+	c.Assert(err, IsNil)
+
+	acc, ok := NewIssuedAccess(parsed)
+	c.Assert(ok, Equals, true)
+	c.Assert(acc.Name(), Equals, "alice")
+	c.Assert(acc.HasReadPermission(), Equals, true)
+	c.Assert(acc.HasWritePermission(), Equals, false)
+	c.Assert(acc.Roles(), DeepEquals, []string{"restart_workers", "configReader"})
+	c.Assert(acc.IsCertValid(parsed), Equals, true)
+}
+
+func (s *IssuedTest) TestIssuePemWithoutPermission(c *C) {
+	ks := s.newKeyStore(c)
+	_, cert, err := IssuePem(ks, "bob", Forbidden, []string{"restart_workers"})
+	c.Assert(err, IsNil)
+	acc, ok := NewIssuedAccess(cert)
+	c.Assert(ok, Equals, true)
+	c.Assert(acc.HasReadPermission(), Equals, false)
+	c.Assert(acc.Roles(), DeepEquals, []string{"restart_workers"})
+
+	_, _, err = IssuePem(ks, "bob", Forbidden, nil)
+	c.Assert(err, ErrorMatches, "There is neither a permission nor a role to issue a certificate for 'bob' with.")
+	_, _, err = IssuePem(ks, "bob", Permission(66), nil)
+	c.Assert(err, ErrorMatches, "illegal permission: 66")
+}
+
+func (s *IssuedTest) TestNewIssuedAccessOfGeneratedCertificate(c *C) {
+	ks := s.newKeyStore(c)
+	_, cert, err := ks.GeneratePem("caretakerctl")
+	c.Assert(err, IsNil)
+	_, ok := NewIssuedAccess(cert)
+	c.Assert(ok, Equals, false)
+	_, ok = NewIssuedAccess(nil)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *IssuedTest) TestIsAllowedToIssue(c *C) {
+	reader := &Access{permission: ReadOnly, roles: []string{"restart_workers"}}
+	issuer := &Access{permission: Forbidden, roles: []string{"issuer"}}
+	writer := &Access{permission: ReadWrite}
+
+	c.Assert(IsAllowedToIssue([]*Access{reader}, ReadOnly, []string{"restart_workers"}), Equals, true)
+	c.Assert(IsAllowedToIssue([]*Access{reader}, Forbidden, []string{"restart_workers"}), Equals, true)
+	c.Assert(IsAllowedToIssue([]*Access{reader}, ReadWrite, nil), Equals, false)
+	c.Assert(IsAllowedToIssue([]*Access{reader}, ReadOnly, []string{"issuer"}), Equals, false)
+	c.Assert(IsAllowedToIssue([]*Access{issuer}, ReadOnly, nil), Equals, false)
+	c.Assert(IsAllowedToIssue([]*Access{issuer}, Forbidden, []string{"issuer"}), Equals, true)
+	c.Assert(IsAllowedToIssue([]*Access{reader, issuer}, ReadOnly, []string{"restart_workers", "issuer"}), Equals, true)
+	c.Assert(IsAllowedToIssue([]*Access{writer}, ReadWrite, []string{"restart_workers", "issuer"}), Equals, true)
+	c.Assert(IsAllowedToIssue(nil, Forbidden, []string{"issuer"}), Equals, false)
+}
@@ -0,0 +1,100 @@
+package access
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"os/user"
+	"strconv"
+)
+
+// Peer describes the process on the other side of a local connection.
+// It is provided by the operating system and could not be faked by the remote process.
+type Peer struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+func newPeerCredentialsInstance(conf Config, name string) (*Access, error) {
+	uids := map[uint32]bool{}
+	gids := map[uint32]bool{}
+	for _, plain := range conf.Users {
+		uid, err := resolveID(plain, lookupUserID)
+		if err != nil {
+			return nil, errors.New("Could not resolve user '%v' of '%v'.", plain, name).CausedBy(err)
+		}
+		uids[uid] = true
+	}
+	for _, plain := range conf.Groups {
+		gid, err := resolveID(plain, lookupGroupID)
+		if err != nil {
+			return nil, errors.New("Could not resolve group '%v' of '%v'.", plain, name).CausedBy(err)
+		}
+		gids[gid] = true
+	}
+	return &Access{
+		t:          PeerCredentials,
+		permission: conf.Permission,
+		name:       name,
+		uids:       uids,
+		gids:       gids,
+	}, nil
+}
+
+func lookupUserID(name string) (string, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	return u.Uid, nil
+}
+
+func lookupGroupID(name string) (string, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return "", err
+	}
+	return g.Gid, nil
+}
+
+func resolveID(plain values.String, lookup func(name string) (string, error)) (uint32, error) {
+	if id, err := strconv.ParseUint(plain.String(), 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	plainID, err := lookup(plain.String())
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(plainID, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id), nil
+}
+
+// IsPeerValid queries whether the process with the given credentials is trusted by this access instance.
+func (instance *Access) IsPeerValid(peer *Peer) bool {
+	if instance.t != PeerCredentials || peer == nil {
+		return false
+	}
+	if instance.uids[peer.UID] || instance.gids[peer.GID] {
+		return true
+	}
+	if len(instance.gids) == 0 {
+		return false
+	}
+	u, err := user.LookupId(strconv.FormatUint(uint64(peer.UID), 10))
+	if err != nil {
+		return false
+	}
+	groupIDs, err := u.GroupIds()
+	if err != nil {
+		return false
+	}
+	for _, plain := range groupIDs {
+		if gid, err := strconv.ParseUint(plain, 10, 32); err == nil && instance.gids[uint32(gid)] {
+			return true
+		}
+	}
+	return false
+}
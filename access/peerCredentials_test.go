@@ -0,0 +1,34 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type PeerCredentialsTest struct{}
+
+func init() {
+	Suite(&PeerCredentialsTest{})
+}
+
+func (s *PeerCredentialsTest) TestIsPeerValidWithIDs(c *C) {
+	conf := NewPeerCredentialsConfig(ReadOnly, values.String("4711"))
+	conf.Groups = []values.String{"4712"}
+	acc, err := NewAccess(conf, "test", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.Type(), Equals, PeerCredentials)
+	c.Assert(acc.HasReadPermission(), Equals, true)
+	c.Assert(acc.HasWritePermission(), Equals, false)
+
+	c.Assert(acc.IsPeerValid(&Peer{UID: 4711, GID: 1}), Equals, true)
+	c.Assert(acc.IsPeerValid(&Peer{UID: 1, GID: 4712}), Equals, true)
+	c.Assert(acc.IsPeerValid(&Peer{UID: 1, GID: 1}), Equals, false)
+	c.Assert(acc.IsPeerValid(nil), Equals, false)
+	c.Assert(acc.IsCertValid(nil), Equals, false)
+}
+
+func (s *PeerCredentialsTest) TestUnknownUser(c *C) {
+	_, err := NewAccess(NewPeerCredentialsConfig(ReadWrite, values.String("caretakerd-test-unknown-user")), "test", nil)
+	c.Assert(err, ErrorMatches, "(?s)Could not resolve user 'caretakerd-test-unknown-user' of 'test'.*")
+}
@@ -0,0 +1,115 @@
+package access
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+)
+
+// Target describes a service an action should be executed on.
+type Target struct {
+	Name   string
+	Labels values.Labels
+}
+
+// # Description
+//
+// A role grants verbs on specific services. Roles are bound to caretakerctl or services
+// using {@ref .Config#Roles roles} of their access configuration.
+type Role struct {
+	// @default []
+	//
+	// Verbs this role grants.
+	//
+	// For details see possible values {@ref .Verb}.
+	Verbs []Verb `json:"verbs" yaml:"verbs,flow"`
+
+	// @default []
+	//
+	// Names of the services this role grants the {@ref #Verbs verbs} on.
+	//
+	// If this property and {@ref #Selector selector} are empty, the verbs are granted on every service.
+	Services []values.String `json:"services,omitempty" yaml:"services,omitempty,flow"`
+
+	// @default {}
+	//
+	// Grants the {@ref #Verbs verbs} also on every service that has all of these
+	// {@ref github.com/echocat/caretakerd/service.Config#Labels labels}.
+	Selector values.Labels `json:"selector,omitempty" yaml:"selector,omitempty"`
+}
+
+// Roles represents a couple of roles by their names.
+// @inline
+type Roles map[string]Role
+
+// Grants returns "true" if this role grants the given verb on the given target.
+// If target is nil the verb has to be granted on every service.
+func (instance Role) Grants(verb Verb, target *Target) bool {
+	if !instance.hasVerb(verb) {
+		return false
+	}
+	if len(instance.Services) == 0 && len(instance.Selector) == 0 {
+		return true
+	}
+	if target == nil {
+		return false
+	}
+	for _, name := range instance.Services {
+		if name.String() == target.Name {
+			return true
+		}
+	}
+	return len(instance.Selector) > 0 && target.Labels.Matches(instance.Selector)
+}
+
+func (instance Role) hasVerb(verb Verb) bool {
+	for _, candidate := range instance.Verbs {
+		if candidate == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates an action on this object and returns an error object if there are any.
+func (instance Role) Validate() error {
+	for _, verb := range instance.Verbs {
+		if err := verb.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates an action on this object and returns an error object if there are any.
+func (instance Roles) Validate() error {
+	for name, role := range instance {
+		if err := role.Validate(); err != nil {
+			return errors.New("Role '%s' is not valid.", name).CausedBy(err)
+		}
+	}
+	return nil
+}
+
+// ValidateBindingsOf returns an error object if the given access configuration is bound to a role that does not exist.
+func (instance Roles) ValidateBindingsOf(conf Config, name string) error {
+	for _, role := range conf.Roles {
+		if _, ok := instance[role.String()]; !ok {
+			return errors.New("Role '%v' of %s does not exist.", role, name)
+		}
+	}
+	return nil
+}
+
+// IsGranted queries whether the given verb on the given target is allowed by the permission of this
+// access instance or by one of its roles. If target is nil the verb has to be allowed on every service.
+func (instance Access) IsGranted(verb Verb, target *Target, roles Roles) bool {
+	if instance.HasWritePermission() || (verb.IsReading() && instance.HasReadPermission()) {
+		return true
+	}
+	for _, name := range instance.roles {
+		if role, ok := roles[name]; ok && role.Grants(verb, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type RoleTest struct{}
+
+func init() {
+	Suite(&RoleTest{})
+}
+
+var (
+	worker1 = &Target{Name: "worker1", Labels: values.Labels{"group": "workers"}}
+	worker2 = &Target{Name: "worker2", Labels: values.Labels{"group": "workers"}}
+	web     = &Target{Name: "web", Labels: values.Labels{"group": "web"}}
+)
+
+func (s *RoleTest) TestGrantsWithSelector(c *C) {
+	role := Role{Verbs: []Verb{Get, Restart}, Selector: values.Labels{"group": "workers"}}
+	c.Assert(role.Grants(Restart, worker1), Equals, true)
+	c.Assert(role.Grants(Get, worker2), Equals, true)
+	c.Assert(role.Grants(Kill, worker1), Equals, false)
+	c.Assert(role.Grants(Restart, web), Equals, false)
+	c.Assert(role.Grants(Get, nil), Equals, false)
+}
+
+func (s *RoleTest) TestGrantsWithServices(c *C) {
+	role := Role{Verbs: []Verb{Stop}, Services: []values.String{"web"}, Selector: values.Labels{"group": "workers"}}
+	c.Assert(role.Grants(Stop, web), Equals, true)
+	c.Assert(role.Grants(Stop, worker1), Equals, true)
+	c.Assert(role.Grants(Stop, &Target{Name: "other"}), Equals, false)
+}
+
+func (s *RoleTest) TestGrantsOnEveryService(c *C) {
+	role := Role{Verbs: []Verb{ConfigVerb}}
+	c.Assert(role.Grants(ConfigVerb, web), Equals, true)
+	c.Assert(role.Grants(ConfigVerb, nil), Equals, true)
+	c.Assert(role.Grants(Get, nil), Equals, false)
+}
+
+func (s *RoleTest) TestIsGranted(c *C) {
+	roles := Roles{
+		"restartWorkers": {Verbs: []Verb{Get, Restart}, Selector: values.Labels{"group": "workers"}},
+	}
+	conf := NewPeerCredentialsConfig(Forbidden, values.String("nobody"))
+	conf.Roles = []values.String{"restartWorkers", "unknown"}
+	acc, err := NewAccess(conf, "worker1", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.Roles(), DeepEquals, []string{"restartWorkers", "unknown"})
+	c.Assert(acc.IsGranted(Restart, worker2, roles), Equals, true)
+	c.Assert(acc.IsGranted(Kill, worker2, roles), Equals, false)
+	c.Assert(acc.IsGranted(Restart, web, roles), Equals, false)
+
+	acc, err = NewAccess(NewPeerCredentialsConfig(ReadOnly, values.String("nobody")), "reader", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.IsGranted(Get, nil, roles), Equals, true)
+	c.Assert(acc.IsGranted(ConfigVerb, web, roles), Equals, true)
+	c.Assert(acc.IsGranted(Restart, worker1, roles), Equals, false)
+
+	acc, err = NewAccess(NewPeerCredentialsConfig(ReadWrite, values.String("nobody")), "writer", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.IsGranted(Kill, nil, roles), Equals, true)
+}
+
+func (s *RoleTest) TestValidateBindingsOf(c *C) {
+	roles := Roles{"restartWorkers": {Verbs: []Verb{Restart}}}
+	conf := NewPeerCredentialsConfig(Forbidden)
+	conf.Roles = []values.String{"restartWorkers"}
+	c.Assert(roles.ValidateBindingsOf(conf, "service 'a'"), IsNil)
+	conf.Roles = []values.String{"unknown"}
+	c.Assert(roles.ValidateBindingsOf(conf, "service 'a'"), ErrorMatches, "Role 'unknown' of service 'a' does not exist.")
+}
+
+func (s *RoleTest) TestValidate(c *C) {
+	c.Assert(Roles{"a": {Verbs: []Verb{Get}}}.Validate(), IsNil)
+	c.Assert(Roles{"a": {Verbs: []Verb{Verb(-1)}}}.Validate(), ErrorMatches, "(?s)Role 'a' is not valid.*")
+}
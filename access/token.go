@@ -0,0 +1,102 @@
+package access
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/echocat/caretakerd/errors"
+	"os"
+	"strings"
+)
+
+// Number of random bytes of a generated token.
+const generatedTokenLength = 32
+
+func newTokenInstance(conf Config, name string) (*Access, error) {
+	tokens := make([]string, 0, len(conf.Tokens))
+	for _, token := range conf.Tokens {
+		if trimmed := strings.TrimSpace(token.Reveal()); trimmed != "" {
+			tokens = append(tokens, trimmed)
+		}
+	}
+	if !conf.TokenFile.IsTrimmedEmpty() {
+		fromFile, err := loadTokensFromFile(conf.TokenFile.String())
+		if err != nil {
+			return nil, errors.New("Could not load tokens from tokenFile %v of '%v'.", conf.TokenFile, name).CausedBy(err)
+		}
+		tokens = append(tokens, fromFile...)
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("There are no tokens configured for '%v'.", name)
+	}
+	return &Access{
+		t:          Token,
+		permission: conf.Permission,
+		name:       name,
+		tokens:     tokens,
+	}, nil
+}
+
+func loadTokensFromFile(filename string) ([]string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			result = append(result, line)
+		}
+	}
+	return result, scanner.Err()
+}
+
+func newGenerateTokenToEnvironmentInstance(conf Config, name string) (*Access, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, errors.New("Could not generate token for '%v'.", name).CausedBy(err)
+	}
+	return &Access{
+		t:          GenerateTokenToEnvironment,
+		permission: conf.Permission,
+		name:       name,
+		tokens:     []string{token},
+	}, nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, generatedTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Token queries the generated token of this access instance.
+// This is empty if the type is not GenerateTokenToEnvironment.
+func (instance Access) Token() string {
+	if instance.t != GenerateTokenToEnvironment || len(instance.tokens) == 0 {
+		return ""
+	}
+	return instance.tokens[0]
+}
+
+// IsTokenValid queries whether the given bearer token is valid in combination
+// with this access instance.
+func (instance *Access) IsTokenValid(token string) bool {
+	if !instance.t.IsUsingTokens() || token == "" {
+		return false
+	}
+	result := false
+	for _, candidate := range instance.tokens {
+		// Compare every candidate in constant time to not reveal anything about the configured tokens.
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			result = true
+		}
+	}
+	return result
+}
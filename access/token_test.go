@@ -0,0 +1,62 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"path/filepath"
+)
+
+type TokenTest struct{}
+
+func init() {
+	Suite(&TokenTest{})
+}
+
+func (s *TokenTest) TestIsTokenValid(c *C) {
+	acc, err := NewAccess(NewTokenConfig(ReadOnly, values.Secret("foo"), values.Secret(" bar ")), "test", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.Type(), Equals, Token)
+	c.Assert(acc.HasReadPermission(), Equals, true)
+	c.Assert(acc.HasWritePermission(), Equals, false)
+
+	c.Assert(acc.IsTokenValid("foo"), Equals, true)
+	c.Assert(acc.IsTokenValid("bar"), Equals, true)
+	c.Assert(acc.IsTokenValid("fo"), Equals, false)
+	c.Assert(acc.IsTokenValid(""), Equals, false)
+	c.Assert(acc.IsCertValid(nil), Equals, false)
+	c.Assert(acc.Token(), Equals, "")
+}
+
+func (s *TokenTest) TestTokenFile(c *C) {
+	file := filepath.Join(c.MkDir(), "tokens.txt")
+	c.Assert(os.WriteFile(file, []byte("# comment\nfoo\n\n  bar\n"), 0600), IsNil)
+	conf := NewTokenConfig(ReadWrite)
+	conf.TokenFile = values.String(file)
+	acc, err := NewAccess(conf, "test", nil)
+	c.Assert(err, IsNil)
+	c.Assert(acc.IsTokenValid("foo"), Equals, true)
+	c.Assert(acc.IsTokenValid("bar"), Equals, true)
+	c.Assert(acc.IsTokenValid("# comment"), Equals, false)
+
+	conf.TokenFile = values.String(filepath.Join(c.MkDir(), "missing.txt"))
+	_, err = NewAccess(conf, "test", nil)
+	c.Assert(err, ErrorMatches, "(?s)Could not load tokens from tokenFile .* of 'test'.*")
+
+	_, err = NewAccess(NewTokenConfig(ReadWrite), "test", nil)
+	c.Assert(err, ErrorMatches, "There are no tokens configured for 'test'.")
+}
+
+func (s *TokenTest) TestGenerateTokenToEnvironment(c *C) {
+	conf := Config{Type: GenerateTokenToEnvironment, Permission: ReadWrite}
+	acc, err := NewAccess(conf, "test", nil)
+	c.Assert(err, IsNil)
+	other, err := NewAccess(conf, "other", nil)
+	c.Assert(err, IsNil)
+
+	c.Assert(acc.Token(), HasLen, 43)
+	c.Assert(acc.Token(), Not(Equals), other.Token())
+	c.Assert(acc.IsTokenValid(acc.Token()), Equals, true)
+	c.Assert(acc.IsTokenValid(other.Token()), Equals, false)
+}
@@ -29,6 +29,32 @@ const (
 	//
 	// Generates a new certificate to the configured {@ref github.com/echocat/caretakerd/access.Config#PemFile} and trusts it.
 	GenerateToFile Type = 3
+	// @id peerCredentials
+	//
+	// Trusts local processes that are connecting using an unix socket {@ref github.com/echocat/caretakerd/rpc.Config#Listen listen address}
+	// if they are running with one of the configured {@ref github.com/echocat/caretakerd/access.Config#Users users}
+	// or {@ref github.com/echocat/caretakerd/access.Config#Groups groups}.
+	// The user and group of the connecting process are provided by the operating system - no certificates are required.
+	//
+	// > **Important:** Every local process of the configured users and groups is trusted - including services of caretakerd
+	// > running with one of them. Run services with another {@ref github.com/echocat/caretakerd/service.Config#User user}
+	// > than the one that is trusted to control caretakerd.
+	//
+	// > **Hint:** This is only supported on Linux.
+	PeerCredentials Type = 4
+	// @id token
+	//
+	// Trusts requests with an ``Authorization: Bearer <token>`` header that contains one of the configured
+	// {@ref github.com/echocat/caretakerd/access.Config#Tokens tokens} or one of the tokens in the configured
+	// {@ref github.com/echocat/caretakerd/access.Config#TokenFile tokenFile}.
+	// This is an alternative for clients that could not easily handle client certificates.
+	Token Type = 5
+	// @id generateTokenToEnvironment
+	//
+	// Generates a new random token to the environment variable ``CTD_TOKEN`` and trusts it.
+	// It has to be sent with an ``Authorization: Bearer <token>`` header. The certificates to verify caretakerd
+	// are provided by the environment variable ``CTD_CA``.
+	GenerateTokenToEnvironment Type = 6
 )
 
 // AllTypes contains all possible variants of Type.
@@ -37,6 +63,9 @@ var AllTypes = []Type{
 	Trusted,
 	GenerateToEnvironment,
 	GenerateToFile,
+	PeerCredentials,
+	Token,
+	GenerateTokenToEnvironment,
 }
 
 func (instance Type) String() string {
@@ -59,6 +88,12 @@ func (instance Type) CheckedString() (string, error) {
 		return "generateToEnvironment", nil
 	case GenerateToFile:
 		return "generateToFile", nil
+	case PeerCredentials:
+		return "peerCredentials", nil
+	case Token:
+		return "token", nil
+	case GenerateTokenToEnvironment:
+		return "generateTokenToEnvironment", nil
 	}
 	return "", fmt.Errorf("illegal access type: %d", instance)
 }
@@ -132,6 +167,21 @@ func (instance Type) IsTakingFileUser() bool {
 	return instance == GenerateToFile
 }
 
+// IsTakingPeers returns true if this Type indicates that it accepts users and groups of peers.
+func (instance Type) IsTakingPeers() bool {
+	return instance == PeerCredentials
+}
+
+// IsTakingTokens returns true if this Type indicates that it accepts tokens.
+func (instance Type) IsTakingTokens() bool {
+	return instance == Token
+}
+
+// IsUsingTokens returns true if this Type indicates that requests are authorized using bearer tokens.
+func (instance Type) IsUsingTokens() bool {
+	return instance == Token || instance == GenerateTokenToEnvironment
+}
+
 // IsTakingFileGroup returns true if this Type indicates that it accepts a file group.
 func (instance Type) IsTakingFileGroup() bool {
 	return instance == GenerateToFile
@@ -20,6 +20,9 @@ func (s *TypeTest) TestString(c *C) {
 	c.Assert(Trusted.String(), Equals, "trusted")
 	c.Assert(GenerateToEnvironment.String(), Equals, "generateToEnvironment")
 	c.Assert(GenerateToFile.String(), Equals, "generateToFile")
+	c.Assert(PeerCredentials.String(), Equals, "peerCredentials")
+	c.Assert(Token.String(), Equals, "token")
+	c.Assert(GenerateTokenToEnvironment.String(), Equals, "generateTokenToEnvironment")
 }
 
 func (s *TypeTest) TestStringPanic(c *C) {
@@ -150,3 +153,10 @@ func (s *TypeTest) TestIsGenerating(c *C) {
 		c.Assert(t.IsGenerating(), Equals, t == GenerateToFile || t == GenerateToEnvironment)
 	}
 }
+
+func (s *TypeTest) TestIsUsingTokens(c *C) {
+	for _, t := range AllTypes {
+		c.Assert(t.IsTakingTokens(), Equals, t == Token)
+		c.Assert(t.IsUsingTokens(), Equals, t == Token || t == GenerateTokenToEnvironment)
+	}
+}
@@ -0,0 +1,177 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Verb represents an action on caretakerd or one of its services that could be granted by a {@ref .Role}.
+type Verb int
+
+const (
+	// @id get
+	//
+	// Query the status, pid and other information of a service and wait for it.
+	Get Verb = 0
+	// @id start
+	//
+	// Start a service.
+	Start Verb = 1
+	// @id stop
+	//
+	// Stop a service.
+	Stop Verb = 2
+	// @id restart
+	//
+	// Restart a service.
+	Restart Verb = 3
+	// @id kill
+	//
+	// Kill a service.
+	Kill Verb = 4
+	// @id signal
+	//
+	// Send a signal to a service.
+	Signal Verb = 5
+	// @id reload
+	//
+	// Reload a service.
+	//
+	// > **Hint:** There is currently no endpoint that requires this verb.
+	Reload Verb = 6
+	// @id logs
+	//
+	// Read the logs of a service.
+	//
+	// > **Hint:** There is currently no endpoint that requires this verb.
+	Logs Verb = 7
+	// @id config
+	//
+	// Read the configuration of a service. If granted for every service it also allows to read
+	// the configuration of caretakerd itself.
+	ConfigVerb Verb = 8
+	// @id revoke
+	//
+	// Revoke certificates that were issued by caretakerd. This is not related to a specific service and
+	// could therefore only be granted by roles for every service.
+	Revoke Verb = 9
+	// @id issue
+	//
+	// Issue new certificates with an embedded permission and roles. The permission and roles of an issued
+	// certificate could not exceed the own ones: The own permission has to be at least the issued one and
+	// every issued role has to be bound to the own access - except for the permission {@ref .Permission#ReadWrite readWrite}
+	// which covers everything. This is not related to a specific service and could therefore only be granted by
+	// roles for every service.
+	Issue Verb = 10
+)
+
+// AllVerbs contains all possible variants of Verb.
+var AllVerbs = []Verb{
+	Get,
+	Start,
+	Stop,
+	Restart,
+	Kill,
+	Signal,
+	Reload,
+	Logs,
+	ConfigVerb,
+	Revoke,
+	Issue,
+}
+
+func (instance Verb) String() string {
+	s, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// CheckedString is like String but also returns an optional error if there are
+// validation errors.
+func (instance Verb) CheckedString() (string, error) {
+	switch instance {
+	case Get:
+		return "get", nil
+	case Start:
+		return "start", nil
+	case Stop:
+		return "stop", nil
+	case Restart:
+		return "restart", nil
+	case Kill:
+		return "kill", nil
+	case Signal:
+		return "signal", nil
+	case Reload:
+		return "reload", nil
+	case Logs:
+		return "logs", nil
+	case ConfigVerb:
+		return "config", nil
+	case Revoke:
+		return "revoke", nil
+	case Issue:
+		return "issue", nil
+	}
+	return "", fmt.Errorf("illegal verb: %d", instance)
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are problems while transforming the string.
+func (instance *Verb) Set(value string) error {
+	lowerValue := strings.ToLower(value)
+	for _, candidate := range AllVerbs {
+		if candidate.String() == lowerValue {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal verb: %v", value)
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance Verb) MarshalYAML() (interface{}, error) {
+	return instance.CheckedString()
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *Verb) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Verb) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Verb) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates an action on this object and returns an error object if there are any.
+func (instance Verb) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
+
+// IsReading returns true if this Verb does not change anything. Every reading verb is
+// granted by the permission {@ref .Permission#ReadOnly readOnly}.
+func (instance Verb) IsReading() bool {
+	return instance == Get || instance == Logs || instance == ConfigVerb
+}
@@ -0,0 +1,34 @@
+package access
+
+import (
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v3"
+)
+
+type VerbTest struct{}
+
+func init() {
+	Suite(&VerbTest{})
+}
+
+func (s *VerbTest) TestSet(c *C) {
+	actual := Verb(-1)
+	c.Assert(actual.Set("restart"), IsNil)
+	c.Assert(actual, Equals, Restart)
+	c.Assert(actual.Set("Config"), IsNil)
+	c.Assert(actual, Equals, ConfigVerb)
+	c.Assert(actual.Set("xxx"), ErrorMatches, "illegal verb: xxx")
+	c.Assert(actual, Equals, ConfigVerb)
+}
+
+func (s *VerbTest) TestUnmarshalYAML(c *C) {
+	var actual []Verb
+	c.Assert(yaml.Unmarshal([]byte(`["get", "signal"]`), &actual), IsNil)
+	c.Assert(actual, DeepEquals, []Verb{Get, Signal})
+}
+
+func (s *VerbTest) TestIsReading(c *C) {
+	for _, verb := range AllVerbs {
+		c.Assert(verb.IsReading(), Equals, verb == Get || verb == Logs || verb == ConfigVerb)
+	}
+}
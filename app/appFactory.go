@@ -88,6 +88,23 @@ func newAppFor(config *ConfigWrapper, platform string, executableType Executable
 			SetValue(config)
 	}
 
+	app.Flag("config-dir", "Directory with additional configuration files (*.yaml, *.yml, *.json, *.toml) to include.").
+		Envar(configDirectoryEnvarFor(executableType)).
+		PlaceHolder("<dir>").
+		SetValue(config.ConfigDirectory())
+
+	if executableType != Control {
+		app.Flag("no-config", "Do not load any configuration file. The configuration is only build from environment variables, --service and --set.").
+			Envar(noConfigEnvarFor(executableType)).
+			BoolVar(&config.noConfig)
+		app.Flag("service", "Define a service with the given command. The first defined service becomes the master if there is no other. Could be repeated.").
+			PlaceHolder("<name>=<command>").
+			StringsVar(&config.services)
+		app.Flag("set", "Set a property of the configuration like services.<name>.stopSignal=KILL. Could be repeated.").
+			PlaceHolder("<path>=<value>").
+			StringsVar(&config.properties)
+	}
+
 	app.HelpFlag.Hidden()
 	app.Flag("address", "Listen address of the daemon.").
 		Short('a').
@@ -107,15 +124,41 @@ func newAppFor(config *ConfigWrapper, platform string, executableType Executable
 	return app
 }
 
+func configDirectoryEnvarFor(executableType ExecutableType) string {
+	switch executableType {
+	case Daemon:
+		return "CTD_CONFIG_DIR"
+	case Control:
+		return "CTCTL_CONFIG_DIR"
+	}
+	return "CT_CONFIG_DIR"
+}
+
+func noConfigEnvarFor(executableType ExecutableType) string {
+	if executableType == Daemon {
+		return "CTD_NO_CONFIG"
+	}
+	return "CT_NO_CONFIG"
+}
+
 func registerCommandsFor(config *ConfigWrapper, executableType ExecutableType, at *kingpin.Application) {
 	switch executableType {
 	case Daemon:
 		registerDaemonCommandsAt(config, executableType, at)
+		registerValidateCommandAt(config, at)
+		registerSchemaCommandAt(at)
+		registerHelpConfigAt(at)
+		registerCompletionCommandAt(at)
 	case Control:
 		registerControlCommands(config, at)
+		registerCompletionCommandAt(at)
 	default:
 		registerDaemonCommandsAt(config, executableType, at)
+		registerValidateCommandAt(config, at)
+		registerSchemaCommandAt(at)
+		registerHelpConfigAt(at)
 		registerControlCommands(config, at)
+		registerCompletionCommandAt(at)
 	}
 }
 
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd/client"
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"sort"
+	"time"
+)
+
+// Completion is executed while typing. A not responding daemon should not block the shell.
+const serviceNamesHintTimeout = time.Second
+
+// FishCompletionTemplate is the equivalent of kingpin.BashCompletionTemplate for the fish shell.
+// The current token is always passed (even if empty) so kingpin knows if the previous token is completed.
+var FishCompletionTemplate = `function __{{.App.Name}}_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    {{.App.Name}} --completion-bash $tokens (commandline -ct | string collect -a)
+end
+
+complete -c {{.App.Name}} -f -a "(__{{.App.Name}}_complete)"
+`
+
+var completionTemplates = map[string]string{
+	"bash": kingpin.BashCompletionTemplate,
+	"zsh":  kingpin.ZshCompletionTemplate,
+	"fish": FishCompletionTemplate,
+}
+
+// Registers "completion <shell>" which prints a script that enables the completion of every
+// command, flag and argument of the given application inside of the given shell.
+// The script calls the application itself with the hidden --completion-bash flag of kingpin.
+func registerCompletionCommandAt(app *kingpin.Application) {
+	cmd := app.Command("completion", "Print the shell completion script for "+app.Name+". Example: source <("+app.Name+" completion bash)")
+
+	shell := cmd.Arg("shell", "Shell to print the completion script for.").
+		Required().
+		Enum(completionShells()...)
+
+	cmd.Action(func(context *kingpin.ParseContext) error {
+		app.UsageWriter(os.Stdout)
+		return app.UsageForContextWithTemplate(context, 0, completionTemplates[*shell])
+	})
+}
+
+func completionShells() []string {
+	result := make([]string, 0, len(completionTemplates))
+	for shell := range completionTemplates {
+		result = append(result, shell)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Returns the names of every service of the running daemon. Completion should never fail,
+// so every error (like a not reachable daemon or a timeout) simply results in no suggestions.
+func serviceNamesHint(clientFactory *client.Factory, additional ...string) kingpin.HintAction {
+	return func() []string {
+		result := append([]string{}, additional...)
+		cli, err := clientFactory.NewClientWithTimeout(serviceNamesHintTimeout)
+		if err != nil {
+			return result
+		}
+		services, err := cli.GetServices()
+		if err != nil {
+			return result
+		}
+		names := make([]string, 0, len(services))
+		for name := range services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return append(result, names...)
+	}
+}
+
+func signalNamesHint() []string {
+	result := make([]string, 0, len(values.SignalToName))
+	for _, name := range values.SignalToName {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -6,17 +6,23 @@ import (
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/values"
 	"runtime"
+	"strings"
 	"sync"
 )
 
 // ConfigWrapper wraps the config of caretakerd and triggers the loading of this config file when
 // calling Set(string).
 type ConfigWrapper struct {
-	config        *caretakerd.Config
-	explicitSet   bool
-	listenAddress *FlagWrapper
-	pemFile       *FlagWrapper
-	platform      string
+	config          *caretakerd.Config
+	explicitSet     bool
+	configDirectory *FlagWrapper
+	listenAddress   *FlagWrapper
+	pemFile         *FlagWrapper
+	platform        string
+
+	noConfig   bool
+	services   []string
+	properties []string
 
 	loaded bool
 	mutex  *sync.Mutex
@@ -27,13 +33,15 @@ func NewConfigWrapperFor(platform string) *ConfigWrapper {
 	instance := caretakerd.NewConfigFor(platform)
 	defaultListenAddress := defaults.ListenAddressFor(platform)
 	defaultPemFile := defaults.AuthFileKeyFilenameFor(platform)
+	defaultConfigDirectory := values.String("")
 	return &ConfigWrapper{
-		config:        &instance,
-		explicitSet:   false,
-		listenAddress: NewFlagWrapper(&defaultListenAddress),
-		pemFile:       NewFlagWrapper(&defaultPemFile),
-		platform:      platform,
-		mutex:         new(sync.Mutex),
+		config:          &instance,
+		explicitSet:     false,
+		configDirectory: NewFlagWrapper(&defaultConfigDirectory),
+		listenAddress:   NewFlagWrapper(&defaultListenAddress),
+		pemFile:         NewFlagWrapper(&defaultPemFile),
+		platform:        platform,
+		mutex:           new(sync.Mutex),
 	}
 }
 
@@ -57,14 +65,39 @@ func (instance ConfigWrapper) loadConfigFrom(fileName values.String) (*caretaker
 	if len(fileName) == 0 {
 		return nil, errors.New("There is an empty filename for configuration provided.")
 	}
-	conf, err := caretakerd.LoadFromYamlFile(instance.platform, fileName)
+	conf, err := caretakerd.LoadFromFile(instance.platform, fileName)
 	if err != nil {
 		return nil, err
 	}
-	conf = conf.EnrichFromEnvironment()
 	return &conf, nil
 }
 
+func (instance ConfigWrapper) includeConfigDirectoryInto(conf *caretakerd.Config) error {
+	if !instance.configDirectory.IsExplicitSet() {
+		return nil
+	}
+	directory := *instance.configDirectory.Value().(*values.String)
+	return conf.IncludeDirectory(instance.platform, directory)
+}
+
+func (instance ConfigWrapper) applyFlagsTo(conf *caretakerd.Config) error {
+	for _, definition := range instance.services {
+		if err := conf.DefineService(definition); err != nil {
+			return err
+		}
+	}
+	for _, property := range instance.properties {
+		parts := strings.SplitN(property, "=", 2)
+		if len(parts) != 2 {
+			return errors.New("Illegal property '%s'. Expected format <path>=<value>.", property)
+		}
+		if err := conf.SetProperty(parts[0], parts[1]); err != nil {
+			return errors.New("Could not set property '%s'.", parts[0]).CausedBy(err)
+		}
+	}
+	return nil
+}
+
 func (instance *ConfigWrapper) populateAndValidate(forDaemon bool, conf *caretakerd.Config) error {
 	instance.listenAddress.AssignIfExplicitSet(&conf.RPC.Listen)
 	instance.pemFile.AssignIfExplicitSet(&conf.Control.Access.PemFile)
@@ -88,6 +121,11 @@ func (instance ConfigWrapper) ListenAddress() *FlagWrapper {
 	return instance.listenAddress
 }
 
+// ConfigDirectory returns the configDirectory FlagWrapper
+func (instance ConfigWrapper) ConfigDirectory() *FlagWrapper {
+	return instance.configDirectory
+}
+
 // PemFile returns the pemFile FlagWrapper
 func (instance ConfigWrapper) PemFile() *FlagWrapper {
 	return instance.pemFile
@@ -102,25 +140,46 @@ func (instance *ConfigWrapper) ProvideConfig(forDaemon bool) (*caretakerd.Config
 	}
 
 	var config *caretakerd.Config
-	if instance.explicitSet {
+	loaded := true
+	if instance.noConfig {
+		if instance.explicitSet {
+			return nil, errors.New("The --config flag could not be combined with --no-config.")
+		}
+		nConfig := caretakerd.NewConfigFor(instance.platform)
+		config = &nConfig
+	} else if instance.explicitSet {
 		config = instance.config
 	} else {
 		filename := defaults.ConfigFilenameFor(instance.platform)
 		if lConfig, err := instance.loadConfigFrom(filename); caretakerd.IsConfigNotExists(err) {
-			if forDaemon {
-				return nil, errors.New("There is neither the --config flag set nor does a configuration file under default position (%v) exist.", filename)
+			if forDaemon && !instance.configDirectory.IsExplicitSet() {
+				return nil, errors.New("There is neither the --config flag set nor does a configuration file under default position (%v) exist. Use --no-config to configure only by environment variables and flags.", filename)
 			}
 			if lConfig == nil {
 				nConfig := caretakerd.NewConfigFor(runtime.GOOS)
 				lConfig = &nConfig
 			}
 			config = lConfig
+			loaded = instance.configDirectory.IsExplicitSet()
 		} else if err != nil {
 			return nil, err
 		} else {
 			config = lConfig
 		}
 	}
+	if err := instance.includeConfigDirectoryInto(config); err != nil {
+		return nil, err
+	}
+	if instance.noConfig {
+		enriched := config.EnrichFromEnvironmentCreatingServices()
+		config = &enriched
+	} else if loaded {
+		enriched := config.EnrichFromEnvironment()
+		config = &enriched
+	}
+	if err := instance.applyFlagsTo(config); err != nil {
+		return nil, err
+	}
 
 	if err := instance.populateAndValidate(forDaemon, config); err != nil {
 		return nil, err
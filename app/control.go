@@ -1,13 +1,18 @@
 package app
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/client"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/rpc"
+	"github.com/echocat/caretakerd/service"
 	"github.com/echocat/caretakerd/stack"
 	"github.com/echocat/caretakerd/values"
 	"os"
+	"strings"
+	"time"
 )
 
 func actionWrapper(clientFactory *client.Factory, command func(client *client.Client) error) func(context *kingpin.ParseContext) error {
@@ -16,7 +21,13 @@ func actionWrapper(clientFactory *client.Factory, command func(client *client.Cl
 		if err == nil {
 			err = command(cli)
 		}
+		if err == nil {
+			return nil
+		}
 		switch err.(type) {
+		case conditionNotMetError:
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
 		case client.ConflictError, client.AccessDeniedError, client.ServiceNotFoundError:
 			_, _ = fmt.Fprintln(os.Stderr, err.Error())
 		default:
@@ -27,40 +38,29 @@ func actionWrapper(clientFactory *client.Factory, command func(client *client.Cl
 	}
 }
 
-func handleJSONResponse(response interface{}, err error) error {
+func handleResponse(output *Output, response interface{}, err error) error {
 	if err != nil {
 		return err
 	}
-	if s, ok := response.(string); ok {
-		_, err := fmt.Fprintln(os.Stdout, s)
-		return err
-	} else if i, ok := response.(values.Integer); ok {
-		_, err := fmt.Fprintln(os.Stdout, i)
-		return err
-	} else {
-		jConf, err := json.MarshalIndent(response, "", "   ")
-		if err != nil {
-			return err
-		}
-		_, err = fmt.Fprintln(os.Stdout, string(jConf))
-		return err
-	}
+	return output.Print(response, os.Stdout)
 }
 
-func getActionWrapper(clientFactory *client.Factory, action func(client *client.Client) (interface{}, error)) func(context *kingpin.ParseContext) error {
+func getActionWrapper(clientFactory *client.Factory, output *Output, action func(client *client.Client) (interface{}, error)) func(context *kingpin.ParseContext) error {
 	return actionWrapper(clientFactory, func(client *client.Client) error {
-		return handleJSONResponse(action(client))
+		response, err := action(client)
+		return handleResponse(output, response, err)
 	})
 }
 
-func registerConfigCommand(app *kingpin.Application, clientFactory *client.Factory) {
+func registerConfigCommand(app *kingpin.Application, clientFactory *client.Factory, output *Output) {
 	cmd := app.Command("config", "Returns configurations for defined service, '!daemon' or '!control'.")
 
 	target := cmd.Arg("target", "Could be either '!daemon' for the daemon itself, '!control' for the control of the daemon or each name of a configuration service.").
 		Required().
+		HintAction(serviceNamesHint(clientFactory, "!daemon", "!control")).
 		String()
 
-	cmd.Action(getActionWrapper(clientFactory, func(client *client.Client) (interface{}, error) {
+	cmd.Action(getActionWrapper(clientFactory, output, func(client *client.Client) (interface{}, error) {
 		if *target == "!daemon" {
 			return client.GetConfig()
 		}
@@ -71,42 +71,45 @@ func registerConfigCommand(app *kingpin.Application, clientFactory *client.Facto
 	}))
 }
 
-func registerGetCommand(at *kingpin.Application, clientFactory *client.Factory) {
+func registerGetCommand(at *kingpin.Application, clientFactory *client.Factory, output *Output) {
 	cmd := at.Command("get", "Query states for given service or if nothing specified for all services.")
 
 	target := cmd.Arg("target", "If specified this service will be queried otherwise all services will be queried.").
+		HintAction(serviceNamesHint(clientFactory)).
 		String()
 
-	cmd.Action(getActionWrapper(clientFactory, func(client *client.Client) (interface{}, error) {
+	cmd.Action(getActionWrapper(clientFactory, output, func(client *client.Client) (interface{}, error) {
 		if target != nil && len(*target) > 0 {
-			return client.GetService(*target)
+			information, err := client.GetService(*target)
+			return namedInformation{Information: information, name: *target}, err
 		}
 		return client.GetServices()
 	}))
 }
 
-func registerStatusCommand(at *kingpin.Application, clientFactory *client.Factory) {
+func registerStatusCommand(at *kingpin.Application, clientFactory *client.Factory, output *Output) {
 	cmd := at.Command("status", "Query status of a service.")
 
 	target := cmd.Arg("service", "Service to be queried.").
 		Required().
+		HintAction(serviceNamesHint(clientFactory)).
 		String()
 
-	cmd.Action(getActionWrapper(clientFactory, func(client *client.Client) (interface{}, error) {
+	cmd.Action(getActionWrapper(clientFactory, output, func(client *client.Client) (interface{}, error) {
 		return client.GetServiceStatus(*target)
 	}))
 }
 
-func registerPidCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "pid", "Query pid of a service.")
+func registerPidCommand(at *kingpin.Application, clientFactory *client.Factory, output *Output) {
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "pid", "Query pid of a service.")
 
-	cmd.Action(getActionWrapper(clientFactory, func(client *client.Client) (interface{}, error) {
+	cmd.Action(getActionWrapper(clientFactory, output, func(client *client.Client) (interface{}, error) {
 		return client.GetServicePid(*serviceName)
 	}))
 }
 
 func registerStartCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "start", "Starts a service.")
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "start", "Starts a service.")
 
 	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
 		return client.StartService(*serviceName)
@@ -114,7 +117,7 @@ func registerStartCommand(at *kingpin.Application, clientFactory *client.Factory
 }
 
 func registerRestartCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "restart", "Restarts a service.")
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "restart", "Restarts a service.")
 
 	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
 		return client.RestartService(*serviceName)
@@ -122,7 +125,7 @@ func registerRestartCommand(at *kingpin.Application, clientFactory *client.Facto
 }
 
 func registerStopCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "stop", "Stops a service.")
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "stop", "Stops a service.")
 
 	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
 		return client.StopService(*serviceName)
@@ -130,7 +133,7 @@ func registerStopCommand(at *kingpin.Application, clientFactory *client.Factory)
 }
 
 func registerKillCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "kill", "Kills a service.")
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "kill", "Kills a service.")
 
 	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
 		return client.KillService(*serviceName)
@@ -138,11 +141,12 @@ func registerKillCommand(at *kingpin.Application, clientFactory *client.Factory)
 }
 
 func registerSignalCommand(at *kingpin.Application, clientFactory *client.Factory) {
-	cmd, serviceName := registerServiceNameEnabledCommand(at, "signal", "Send a signal to service.")
+	cmd, serviceName := registerServiceNameEnabledCommand(at, clientFactory, "signal", "Send a signal to service.")
 
 	var signal values.Signal
 	cmd.Arg("signal", "Signal to be send").
 		Required().
+		HintOptions(signalNamesHint()...).
 		SetValue(&signal)
 
 	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
@@ -150,11 +154,121 @@ func registerSignalCommand(at *kingpin.Application, clientFactory *client.Factor
 	}))
 }
 
-func registerServiceNameEnabledCommand(at *kingpin.Application, name, description string) (cmd *kingpin.CmdClause, serviceName *string) {
+// Indicates that a service did not meet the condition of the wait command within the timeout.
+type conditionNotMetError struct {
+	error
+}
+
+// Value of the --for flag of the wait command like "status=ready".
+type waitCondition struct {
+	service.Condition
+}
+
+func (instance waitCondition) String() string {
+	return "status=" + instance.Condition.String()
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are problems while transforming the string.
+func (instance *waitCondition) Set(value string) error {
+	if !strings.HasPrefix(value, "status=") {
+		return errors.New("Expected a condition like 'status=ready' but got: %s", value)
+	}
+	return instance.Condition.Set(strings.TrimPrefix(value, "status="))
+}
+
+func registerWaitCommand(at *kingpin.Application, clientFactory *client.Factory) {
+	cmd := at.Command("wait", "Waits until every given service meets the condition. Exits with 0 if it is met, with 2 if the timeout elapsed before and with 1 on errors.")
+
+	serviceNames := cmd.Arg("service", "Services to wait for.").
+		Required().
+		HintAction(serviceNamesHint(clientFactory)).
+		Strings()
+
+	condition := &waitCondition{Condition: service.RunningCondition}
+	cmd.Flag("for", "Condition to wait for: status=running, status=ready or status=down.").
+		PlaceHolder(condition.String()).
+		HintOptions("status=running", "status=ready", "status=down").
+		SetValue(condition)
+
+	timeout := cmd.Flag("timeout", "Maximum time to wait for all services together.").
+		Default(rpc.DefaultWaitTimeout.String()).
+		Duration()
+
+	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
+		deadline := time.Now().Add(*timeout)
+		for _, serviceName := range *serviceNames {
+			remaining := time.Until(deadline)
+			if remaining < 0 {
+				remaining = 0
+			}
+			body, err := client.WaitForService(serviceName, condition.Condition, remaining)
+			if err != nil {
+				return err
+			}
+			if !body.Met {
+				return conditionNotMetError{error: errors.New("Service '%s' is not %v after %v. Current status is %v.", serviceName, condition.Condition, *timeout, body.Service.Status)}
+			}
+			_, _ = fmt.Fprintf(os.Stdout, "Service '%s' is %v.\n", serviceName, condition.Condition)
+		}
+		return nil
+	}))
+}
+
+func registerRevokeCommand(at *kingpin.Application, clientFactory *client.Factory) {
+	cmd := at.Command("revoke", "Revokes a certificate that was issued by caretakerd.")
+
+	target := cmd.Arg("serial|name", "Serial (hexadecimal) of the certificate to revoke or a name to revoke every certificate that was issued for it before.").
+		Required().
+		String()
+
+	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
+		return client.Revoke(*target)
+	}))
+}
+
+func registerIssueCommand(at *kingpin.Application, clientFactory *client.Factory) {
+	cmd := at.Command("issue", "Issues a new certificate with an embedded permission and roles to access caretakerd.")
+
+	name := cmd.Flag("name", "Name (common name) of the certificate to issue. It must not be the name of caretakerctl or a service.").
+		Required().
+		String()
+
+	permission := access.Forbidden
+	cmd.Flag("permission", "Permission of the certificate: forbidden, readOnly or readWrite.").
+		PlaceHolder(permission.String()).
+		HintOptions("forbidden", "readOnly", "readWrite").
+		SetValue(&permission)
+
+	roles := cmd.Flag("role", "Name of a role of the certificate. Could be specified multiple times.").
+		Strings()
+
+	out := cmd.Flag("out", "File to write the pem to. If not specified the pem is printed to stdout.").
+		String()
+
+	cmd.Action(actionWrapper(clientFactory, func(client *client.Client) error {
+		body, err := client.Issue(*name, permission, *roles)
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			_, _ = fmt.Fprint(os.Stdout, body.Pem)
+			return nil
+		}
+		if err := os.WriteFile(*out, []byte(body.Pem), 0600); err != nil {
+			return errors.New("Could not write pem to '%s'.", *out).CausedBy(err)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Issued certificate %s for '%s' to '%s'.\n", body.Serial, *name, *out)
+		return nil
+	}))
+}
+
+func registerServiceNameEnabledCommand(at *kingpin.Application, clientFactory *client.Factory, name, description string) (cmd *kingpin.CmdClause, serviceName *string) {
 	cmd = at.Command(name, description)
 
 	serviceName = cmd.Arg("service", "Service to execute the action on.").
 		Required().
+		HintAction(serviceNamesHint(clientFactory)).
 		String()
 
 	return
@@ -162,14 +276,24 @@ func registerServiceNameEnabledCommand(at *kingpin.Application, name, descriptio
 
 func registerControlCommands(config *ConfigWrapper, at *kingpin.Application) {
 	clientFactory := client.NewFactory(config)
+	output := &Output{Format: TableOutput}
+
+	at.Flag("output", "Output format of responses: table, wide, json, yaml or template=<template>. The template is executed on the JSON representation like template={{.status}}.").
+		Short('o').
+		HintOptions("table", "wide", "json", "yaml", "template=").
+		PlaceHolder(output.String()).
+		SetValue(output)
 
-	registerConfigCommand(at, clientFactory)
-	registerGetCommand(at, clientFactory)
-	registerStatusCommand(at, clientFactory)
-	registerPidCommand(at, clientFactory)
+	registerConfigCommand(at, clientFactory, output)
+	registerGetCommand(at, clientFactory, output)
+	registerStatusCommand(at, clientFactory, output)
+	registerPidCommand(at, clientFactory, output)
 	registerStartCommand(at, clientFactory)
 	registerRestartCommand(at, clientFactory)
 	registerStopCommand(at, clientFactory)
 	registerKillCommand(at, clientFactory)
 	registerSignalCommand(at, clientFactory)
+	registerWaitCommand(at, clientFactory)
+	registerRevokeCommand(at, clientFactory)
+	registerIssueCommand(at, clientFactory)
 }
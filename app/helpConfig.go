@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd/reference"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	excerptPattern    = regexp.MustCompile(`(?s)^(.*?(?:\.\s|$))`)
+	inlineCodePattern = regexp.MustCompile("``([^`\n]+?)``")
+)
+
+// Registers "help config [<path>]" which prints the reference of the configuration property with the
+// given path like "services.*.stopSignalTarget". The help command itself is created by kingpin while
+// parsing. So we intercept it before kingpin prints the usage of the requested command.
+// If the application has its own "config" command (like caretakerctl) "help config" without a path
+// still prints the usage of this command.
+func registerHelpConfigAt(app *kingpin.Application) {
+	app.PreAction(func(context *kingpin.ParseContext) error {
+		if app.HelpCommand == nil || context.SelectedCommand != app.HelpCommand {
+			return nil
+		}
+		var arguments []string
+		for _, element := range context.Elements {
+			if _, ok := element.Clause.(*kingpin.ArgClause); ok && element.Value != nil {
+				arguments = append(arguments, *element.Value)
+			}
+		}
+		if len(arguments) == 0 || arguments[0] != "config" || (len(arguments) == 1 && app.GetCommand("config") != nil) {
+			return nil
+		}
+		if len(arguments) > 2 {
+			return fmt.Errorf("expected only one path but got: %s", strings.Join(arguments[1:], " "))
+		}
+		path := "."
+		if len(arguments) == 2 {
+			path = arguments[1]
+		}
+		if err := printConfigReference(os.Stdout, path); err != nil {
+			return err
+		}
+		os.Exit(0)
+		return nil
+	})
+}
+
+func printConfigReference(to io.Writer, path string) error {
+	ref, err := reference.Get()
+	if err != nil {
+		return err
+	}
+	entry, err := ref.Find(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(to, "%s\n\n", entry.Path)
+	fmt.Fprintf(to, "    Type:    %s\n", entry.ValueType)
+	if entry.Property != nil && entry.Property.Default != nil {
+		fmt.Fprintf(to, "    Default: %s\n", *entry.Property.Default)
+	}
+	if entry.Property != nil {
+		printReferenceDescription(to, entry.Property.Description, "    ")
+	} else if entry.DataType != nil {
+		printReferenceDescription(to, entry.DataType.Description, "    ")
+	}
+
+	dataType := entry.DataType
+	if dataType == nil {
+		return nil
+	}
+	switch dataType.Kind {
+	case reference.Enum:
+		fmt.Fprintf(to, "\n    Allowed values:\n")
+		for _, element := range dataType.Elements {
+			fmt.Fprintf(to, "\n        %s\n", element.Key)
+			printReferenceDescription(to, element.Description, "            ")
+		}
+	case reference.Simple:
+		if entry.Property != nil && len(dataType.Description) > 0 {
+			fmt.Fprintf(to, "\n    About %s (%s):\n", dataType.Name, dataType.ValueType)
+			printReferenceDescription(to, dataType.Description, "        ")
+		}
+	case reference.Object:
+		fmt.Fprintf(to, "\n    Properties of %s:\n", dataType.Name)
+		for _, property := range dataType.Properties {
+			fmt.Fprintf(to, "\n        %s (%s)", property.Key, property.ValueType)
+			if property.Default != nil {
+				fmt.Fprintf(to, " = %s", *property.Default)
+			}
+			fmt.Fprintf(to, "\n            %s\n", excerptOf(property.Description))
+		}
+		fmt.Fprintf(to, "\n    Use \"help config %s\" for details of a property.\n", childPathPatternOf(entry))
+	}
+	return nil
+}
+
+func printReferenceDescription(to io.Writer, description string, indent string) {
+	description = strings.TrimSpace(inlineCodeOf(description))
+	if len(description) == 0 {
+		return
+	}
+	fmt.Fprintln(to)
+	for _, line := range strings.Split(description, "\n") {
+		if len(strings.TrimSpace(line)) == 0 {
+			fmt.Fprintln(to)
+		} else {
+			fmt.Fprintf(to, "%s%s\n", indent, line)
+		}
+	}
+}
+
+func excerptOf(description string) string {
+	description = strings.Join(strings.Fields(inlineCodeOf(description)), " ")
+	if match := excerptPattern.FindStringSubmatch(description); len(match) == 2 {
+		return strings.TrimSpace(match[1])
+	}
+	return description
+}
+
+// Converts the inline code of the descriptions (``x``) into markdown (`x`). Fenced code blocks are kept.
+func inlineCodeOf(description string) string {
+	return inlineCodePattern.ReplaceAllString(description, "`$1`")
+}
+
+func childPathPatternOf(entry *reference.Entry) string {
+	prefix := ""
+	if entry.Path != "." && len(entry.Path) > 0 {
+		prefix = entry.Path + "."
+	}
+	if strings.HasPrefix(entry.ValueType, "[") {
+		prefix += "*."
+	}
+	return prefix + "<property>"
+}
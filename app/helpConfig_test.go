@@ -0,0 +1,29 @@
+package app
+
+import (
+	. "gopkg.in/check.v1"
+
+	"bytes"
+)
+
+type HelpConfigTest struct{}
+
+func init() {
+	Suite(&HelpConfigTest{})
+}
+
+func (s *HelpConfigTest) TestPrintReferenceDescriptionKeepsFencedCode(c *C) {
+	buf := new(bytes.Buffer)
+	printReferenceDescription(buf, "Command to check with like ``curl``.\n\n```yaml\nreadinessCommand: [\"curl\", \"-sf\"]\n```\n", "    ")
+	c.Assert(buf.String(), Equals, "\n"+
+		"    Command to check with like `curl`.\n"+
+		"\n"+
+		"    ```yaml\n"+
+		"    readinessCommand: [\"curl\", \"-sf\"]\n"+
+		"    ```\n")
+}
+
+func (s *HelpConfigTest) TestExcerptOf(c *C) {
+	c.Assert(excerptOf("Uses ``foo`` and ``bar``. Other sentence."), Equals, "Uses `foo` and `bar`.")
+	c.Assert(excerptOf("Example:\n```yaml\nfoo: bar\n```"), Equals, "Example: ```yaml foo: bar ```")
+}
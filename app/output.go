@@ -0,0 +1,274 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/service"
+	"github.com/echocat/caretakerd/values"
+	"gopkg.in/yaml.v3"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// OutputFormat represents the format responses of the daemon are printed with.
+type OutputFormat int
+
+const (
+	// TableOutput prints services as a table and every other response as JSON.
+	TableOutput OutputFormat = 0
+	// WideOutput is like TableOutput but prints additional columns.
+	WideOutput OutputFormat = 1
+	// JSONOutput prints every response as indented JSON.
+	JSONOutput OutputFormat = 2
+	// YAMLOutput prints every response as YAML.
+	YAMLOutput OutputFormat = 3
+	// TemplateOutput prints every response using a Go template like "template={{.status}}".
+	// The template is executed on the JSON representation of the response.
+	TemplateOutput OutputFormat = 4
+)
+
+// AllOutputFormats contains all possible variants of OutputFormat.
+var AllOutputFormats = []OutputFormat{
+	TableOutput,
+	WideOutput,
+	JSONOutput,
+	YAMLOutput,
+	TemplateOutput,
+}
+
+func (instance OutputFormat) String() string {
+	result, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CheckedString is like String but returns also an optional error if there are some
+// validation errors.
+func (instance OutputFormat) CheckedString() (string, error) {
+	switch instance {
+	case TableOutput:
+		return "table", nil
+	case WideOutput:
+		return "wide", nil
+	case JSONOutput:
+		return "json", nil
+	case YAMLOutput:
+		return "yaml", nil
+	case TemplateOutput:
+		return "template", nil
+	}
+	return "", errors.New("Illegal output format: %d", instance)
+}
+
+// Combines the information of a service with its name which is not part of the information itself.
+// It is marshalled exactly like service.Information.
+type namedInformation struct {
+	service.Information
+	name string
+}
+
+// Output holds the selected OutputFormat and its optional template.
+// It is used as value of the "--output" flag.
+type Output struct {
+	Format   OutputFormat
+	Template string
+}
+
+func (instance Output) String() string {
+	if instance.Format == TemplateOutput {
+		return TemplateOutput.String() + "=" + instance.Template
+	}
+	return instance.Format.String()
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are problems while transforming the string.
+func (instance *Output) Set(value string) error {
+	name, tmpl, withTemplate := strings.Cut(value, "=")
+	for _, candidate := range AllOutputFormats {
+		if candidate.String() != name {
+			continue
+		}
+		if (candidate == TemplateOutput) != withTemplate {
+			return errors.New("Output format '%s' requires a template like 'template={{.status}}'.", value)
+		}
+		if withTemplate {
+			if _, err := template.New("output").Parse(tmpl); err != nil {
+				return errors.New("Illegal template '%s'.", tmpl).CausedBy(err)
+			}
+		}
+		(*instance).Format = candidate
+		(*instance).Template = tmpl
+		return nil
+	}
+	return errors.New("Illegal output format: %s. Possible values are: table, wide, json, yaml, template=<template>", value)
+}
+
+// Print prints the given response of the daemon in the selected format to the given writer.
+func (instance Output) Print(response interface{}, to io.Writer) error {
+	switch instance.Format {
+	case TableOutput, WideOutput:
+		return instance.printHumanReadable(response, to)
+	case JSONOutput:
+		return printAsJSON(response, to)
+	case YAMLOutput:
+		return printAsYAML(response, to)
+	case TemplateOutput:
+		return instance.printWithTemplate(response, to)
+	}
+	return errors.New("Illegal output format: %d", instance.Format)
+}
+
+func (instance Output) printHumanReadable(response interface{}, to io.Writer) error {
+	switch r := response.(type) {
+	case string:
+		_, err := fmt.Fprintln(to, r)
+		return err
+	case values.Integer:
+		_, err := fmt.Fprintln(to, r)
+		return err
+	case map[string]service.Information:
+		return instance.printServicesTable(r, to)
+	case namedInformation:
+		return instance.printServicesTable(map[string]service.Information{r.name: r.Information}, to)
+	case service.Status:
+		_, err := fmt.Fprintln(to, r)
+		return err
+	}
+	return printAsJSON(response, to)
+}
+
+func (instance Output) printServicesTable(services map[string]service.Information, to io.Writer) error {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	w := tabwriter.NewWriter(to, 0, 0, 3, ' ', 0)
+	header := "NAME\tTYPE\tSTATUS\tPID\tUPTIME\tRESTARTS"
+	if instance.Format == WideOutput {
+		header += "\tLAST EXIT\tCOMMAND"
+	}
+	fmt.Fprintln(w, header)
+	for _, name := range names {
+		information := services[name]
+		line := fmt.Sprintf("%s\t%v\t%v\t%s\t%s\t%d", name, information.Config.Type, information.Status, pidOf(information), uptimeOf(information, now), information.Restarts)
+		if instance.Format == WideOutput {
+			line += fmt.Sprintf("\t%s\t%s", lastExitOf(information, now), commandOf(information))
+		}
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
+
+func pidOf(information service.Information) string {
+	if information.PID <= 0 {
+		return "-"
+	}
+	return information.PID.String()
+}
+
+func uptimeOf(information service.Information, now time.Time) string {
+	if information.Status != service.Running || information.StartedAt == nil {
+		return "-"
+	}
+	return humanDurationOf(now.Sub(*information.StartedAt))
+}
+
+func lastExitOf(information service.Information, now time.Time) string {
+	lastExit := information.LastExit
+	if lastExit == nil {
+		return "-"
+	}
+	result := lastExit.Code.String()
+	if lastExit.Signal != nil {
+		result += " (" + lastExit.Signal.String() + ")"
+	}
+	return result + ", " + humanDurationOf(now.Sub(lastExit.Time)) + " ago"
+}
+
+func commandOf(information service.Information) string {
+	parts := make([]string, len(information.Config.Command))
+	for i, part := range information.Config.Command {
+		parts[i] = part.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func humanDurationOf(duration time.Duration) string {
+	duration = duration.Truncate(time.Second)
+	if duration < 0 {
+		duration = 0
+	}
+	if days := duration / (24 * time.Hour); days > 0 {
+		return fmt.Sprintf("%dd%s", days, (duration % (24 * time.Hour)).Truncate(time.Hour))
+	}
+	return duration.String()
+}
+
+func printAsJSON(response interface{}, to io.Writer) error {
+	if s, ok := response.(string); ok {
+		_, err := fmt.Fprintln(to, s)
+		return err
+	} else if i, ok := response.(values.Integer); ok {
+		_, err := fmt.Fprintln(to, i)
+		return err
+	}
+	jConf, err := json.MarshalIndent(response, "", "   ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(to, string(jConf))
+	return err
+}
+
+func printAsYAML(response interface{}, to io.Writer) error {
+	plain, err := plainOf(response)
+	if err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(plain)
+	if err != nil {
+		return err
+	}
+	_, err = to.Write(content)
+	return err
+}
+
+func (instance Output) printWithTemplate(response interface{}, to io.Writer) error {
+	tmpl, err := template.New("output").Parse(instance.Template)
+	if err != nil {
+		return errors.New("Illegal template '%s'.", instance.Template).CausedBy(err)
+	}
+	plain, err := plainOf(response)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(to, plain); err != nil {
+		return errors.New("Could not execute template '%s'.", instance.Template).CausedBy(err)
+	}
+	return nil
+}
+
+// Converts the given response into its JSON representation made of maps, slices and primitives.
+// This way YAML and templates use the same property names as JSON.
+func plainOf(response interface{}) (interface{}, error) {
+	content, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	. "gopkg.in/check.v1"
+
+	"bytes"
+	"github.com/echocat/caretakerd/service"
+	"github.com/echocat/caretakerd/values"
+	"strings"
+	"time"
+)
+
+type OutputTest struct{}
+
+func init() {
+	Suite(&OutputTest{})
+}
+
+func (s *OutputTest) TestHumanDurationOf(c *C) {
+	for _, t := range []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{1500 * time.Millisecond, "1s"},
+		{90 * time.Second, "1m30s"},
+		{-5 * time.Second, "0s"},
+		{3*time.Hour + 5*time.Second, "3h0m5s"},
+		{25*time.Hour + 30*time.Minute + 10*time.Second, "1d1h0m0s"},
+		{48 * time.Hour, "2d0s"},
+	} {
+		c.Assert(humanDurationOf(t.duration), Equals, t.expected, Commentf("duration: %v", t.duration))
+	}
+}
+
+func (s *OutputTest) TestSet(c *C) {
+	for _, t := range []struct {
+		value    string
+		expected Output
+	}{
+		{"table", Output{Format: TableOutput}},
+		{"wide", Output{Format: WideOutput}},
+		{"json", Output{Format: JSONOutput}},
+		{"yaml", Output{Format: YAMLOutput}},
+		{"template={{.status}}", Output{Format: TemplateOutput, Template: "{{.status}}"}},
+		{"template=a=b", Output{Format: TemplateOutput, Template: "a=b"}},
+	} {
+		var actual Output
+		c.Assert(actual.Set(t.value), IsNil, Commentf("value: %s", t.value))
+		c.Assert(actual, DeepEquals, t.expected, Commentf("value: %s", t.value))
+		c.Assert(actual.String(), Equals, t.value, Commentf("value: %s", t.value))
+	}
+}
+
+func (s *OutputTest) TestSetFails(c *C) {
+	for _, t := range []struct {
+		value    string
+		expected string
+	}{
+		{"foo", "Illegal output format: foo. Possible values are: table, wide, json, yaml, template=<template>"},
+		{"", "Illegal output format: . Possible values are: table, wide, json, yaml, template=<template>"},
+		{"template", "Output format 'template' requires a template like 'template={{.status}}'."},
+		{"json={{.status}}", "Output format 'json={{.status}}' requires a template like 'template={{.status}}'."},
+		{"template={{.status", "(?s)Illegal template '{{.status'.*"},
+	} {
+		actual := Output{Format: WideOutput}
+		c.Assert(actual.Set(t.value), ErrorMatches, t.expected, Commentf("value: %s", t.value))
+		c.Assert(actual, DeepEquals, Output{Format: WideOutput}, Commentf("value: %s", t.value))
+	}
+}
+
+func (s *OutputTest) services() map[string]service.Information {
+	startedAt := time.Now().Add(-90 * time.Second)
+	signal := values.KILL
+	master := service.NewConfig().WithCommand("sleep", "60")
+	master.Type = service.Master
+	return map[string]service.Information{
+		"worker": {
+			Config: service.NewConfig().WithCommand("sleep", "1"),
+			Status: service.Down,
+			LastExit: &service.ExitInformation{
+				Code:   values.ExitCode(1),
+				Signal: &signal,
+				Time:   time.Now().Add(-2 * time.Minute),
+			},
+		},
+		"king": {
+			Config:    master,
+			Status:    service.Running,
+			PID:       values.Integer(42),
+			StartedAt: &startedAt,
+			Restarts:  values.Integer(2),
+		},
+	}
+}
+
+func (s *OutputTest) printedLinesOf(c *C, output Output, response interface{}) [][]string {
+	buf := new(bytes.Buffer)
+	c.Assert(output.Print(response, buf), IsNil)
+	var result [][]string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		// Columns are separated by at least 3 spaces; single spaces are part of a value.
+		var columns []string
+		for _, column := range strings.Split(line, "   ") {
+			if column = strings.TrimSpace(column); len(column) > 0 {
+				columns = append(columns, column)
+			}
+		}
+		result = append(result, columns)
+	}
+	return result
+}
+
+func (s *OutputTest) TestPrintServicesTable(c *C) {
+	c.Assert(s.printedLinesOf(c, Output{Format: TableOutput}, s.services()), DeepEquals, [][]string{
+		{"NAME", "TYPE", "STATUS", "PID", "UPTIME", "RESTARTS"},
+		{"king", "master", "running", "42", "1m30s", "2"},
+		{"worker", "autoStart", "down", "-", "-", "0"},
+	})
+}
+
+func (s *OutputTest) TestPrintServicesTableWide(c *C) {
+	c.Assert(s.printedLinesOf(c, Output{Format: WideOutput}, s.services()), DeepEquals, [][]string{
+		{"NAME", "TYPE", "STATUS", "PID", "UPTIME", "RESTARTS", "LAST EXIT", "COMMAND"},
+		{"king", "master", "running", "42", "1m30s", "2", "-", "sleep 60"},
+		{"worker", "autoStart", "down", "-", "-", "0", "1 (KILL), 2m0s ago", "sleep 1"},
+	})
+}
+
+func (s *OutputTest) TestPrintSingleServiceAsTable(c *C) {
+	response := namedInformation{Information: s.services()["king"], name: "king"}
+	c.Assert(s.printedLinesOf(c, Output{Format: TableOutput}, response), DeepEquals, [][]string{
+		{"NAME", "TYPE", "STATUS", "PID", "UPTIME", "RESTARTS"},
+		{"king", "master", "running", "42", "1m30s", "2"},
+	})
+}
+
+func (s *OutputTest) TestPrint(c *C) {
+	king := namedInformation{Information: s.services()["king"], name: "king"}
+	for _, t := range []struct {
+		output   Output
+		response interface{}
+		expected string
+	}{
+		{Output{Format: TableOutput}, "foo", "foo\n"},
+		{Output{Format: TableOutput}, values.Integer(42), "42\n"},
+		{Output{Format: TableOutput}, service.Running, "running\n"},
+		{Output{Format: TableOutput}, map[string]string{"a": "b"}, "{\n   \"a\": \"b\"\n}\n"},
+		{Output{Format: JSONOutput}, "foo", "foo\n"},
+		{Output{Format: JSONOutput}, values.Integer(42), "42\n"},
+		{Output{Format: JSONOutput}, service.Running, "\"running\"\n"},
+		{Output{Format: YAMLOutput}, map[string]string{"a": "b"}, "a: b\n"},
+		{Output{Format: YAMLOutput}, service.Running, "running\n"},
+		// Templates and YAML are using the JSON representation and its property names.
+		{Output{Format: TemplateOutput, Template: "{{.status}} {{.pid}} {{.config.type}} {{index .config.command 0}}"}, king, "running 42 master sleep"},
+		{Output{Format: TemplateOutput, Template: "{{range $name, $s := .}}{{$name}}={{$s.status}} {{end}}"}, s.services(), "king=running worker=down "},
+		{Output{Format: TemplateOutput, Template: "{{.}}"}, "foo", "foo"},
+	} {
+		buf := new(bytes.Buffer)
+		c.Assert(t.output.Print(t.response, buf), IsNil, Commentf("output: %v", t.output))
+		c.Assert(buf.String(), Equals, t.expected, Commentf("output: %v", t.output))
+	}
+}
+
+func (s *OutputTest) TestPrintAsYAMLUsesJSONNames(c *C) {
+	buf := new(bytes.Buffer)
+	king := namedInformation{Information: s.services()["king"], name: "king"}
+	c.Assert(Output{Format: YAMLOutput}.Print(king, buf), IsNil)
+	c.Assert(buf.String(), Matches, "(?s)(.*\n)?status: running\n.*")
+	c.Assert(buf.String(), Matches, "(?s)(.*\n)?pid: 42\n.*")
+	c.Assert(buf.String(), Matches, "(?s)(.*\n)?restarts: 2\n.*")
+	c.Assert(buf.String(), Not(Matches), "(?s).*(Status|PID|StartedAt):.*")
+}
+
+func (s *OutputTest) TestPrintWithTemplateFails(c *C) {
+	buf := new(bytes.Buffer)
+	err := Output{Format: TemplateOutput, Template: "{{.status.foo}}"}.Print(service.Running, buf)
+	c.Assert(err, ErrorMatches, "(?s)Could not execute template '{{.status.foo}}'.*")
+	err = Output{Format: OutputFormat(66)}.Print("foo", buf)
+	c.Assert(err, ErrorMatches, "Illegal output format: 66")
+}
@@ -0,0 +1,16 @@
+package app
+
+import (
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/schema"
+	"os"
+)
+
+func registerSchemaCommandAt(app *kingpin.Application) {
+	app.Command("schema", "Print the JSON Schema of the configuration of "+caretakerd.DaemonName+". Could be used by editors and CI to validate configuration files.").
+		Action(func(*kingpin.ParseContext) error {
+			_, err := os.Stdout.Write(schema.JSON)
+			return err
+		})
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd"
+	"os"
+)
+
+func registerValidateCommandAt(config *ConfigWrapper, app *kingpin.Application) {
+	cmd := app.Command("validate", "Validate the configuration of "+caretakerd.DaemonName+" and report possible problems.")
+
+	printFormat := cmd.Flag("print", "Print the effective merged configuration in the given format (yaml, json or toml).").
+		PlaceHolder("yaml").
+		String()
+	strict := cmd.Flag("strict", "Fail also if there are warnings.").
+		Bool()
+
+	cmd.Action(func(*kingpin.ParseContext) error {
+		var format caretakerd.ConfigFormat
+		if len(*printFormat) > 0 {
+			if err := format.Set(*printFormat); err != nil {
+				return err
+			}
+		}
+		conf, err := config.ProvideConfig(true)
+		if err != nil {
+			return err
+		}
+		warnings := conf.Lint()
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "WARNING: %s\n", warning)
+		}
+		if len(*printFormat) > 0 {
+			content, err := conf.Marshal(format)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stdout.Write(content); err != nil {
+				return err
+			}
+		} else if len(warnings) == 0 {
+			fmt.Fprintln(os.Stderr, "Configuration is valid.")
+		}
+		if *strict && len(warnings) > 0 {
+			return fmt.Errorf("configuration has %d warning(s)", len(warnings))
+		}
+		return nil
+	})
+}
@@ -57,15 +57,18 @@ func buildLdFlagsFor(branch, commit string, forTesting bool) string {
 }
 
 func buildManual(branch string, t *target) {
-	outputName := filepath.Join("var", "dist", caretakerd.DaemonName+".html")
+	outputNames := []string{filepath.Join("var", "dist", caretakerd.DaemonName+".html")}
 	platform := "linux"
 	if t != nil {
-		outputName = t.manual()
+		outputNames = []string{t.manual()}
+		for _, manual := range offlineManuals {
+			outputNames = append(outputNames, t.offlineManual(manual.name))
+		}
 		platform = t.os
 	}
 	executeTo(func(cmd *exec.Cmd) {
 		cmd.Env = append(os.Environ(), "GO111MODULE=on")
-	}, os.Stderr, os.Stdout, "go", "run", "./manual", branch, platform, outputName)
+	}, os.Stderr, os.Stdout, append([]string{"go", "run", "./manual", branch, platform}, outputNames...)...)
 }
 
 func buildPackage(t target) {
@@ -98,6 +101,9 @@ func buildTarGzPackage(t target, outputName string) {
 	addFileToTar(t.executable(), caretakerd.DaemonName+t.executableExtension(), 0755, tw)
 	addLinkToTar(caretakerd.DaemonName+t.executableExtension(), caretakerd.ControlName+t.executableExtension(), 0755, tw)
 	addFileToTar(t.manual(), caretakerd.DaemonName+".html", 0644, tw)
+	for _, manual := range offlineManuals {
+		addFileToTar(t.offlineManual(manual.name), manual.path, 0644, tw)
+	}
 }
 
 func addFileToTar(sourceFile string, targetPath string, mode os.FileMode, to *tar.Writer) {
@@ -138,6 +144,9 @@ func buildZipPackage(t target, outputName string) {
 	addFileToZip(t.executable(), caretakerd.DaemonName+t.executableExtension(), 0755, zw)
 	addFileToZip(t.executable(), caretakerd.ControlName+t.executableExtension(), 0755, zw)
 	addFileToZip(t.manual(), caretakerd.DaemonName+".html", 0644, zw)
+	for _, manual := range offlineManuals {
+		addFileToZip(t.offlineManual(manual.name), manual.path, 0644, zw)
+	}
 }
 
 func addFileToZip(sourceFile string, targetPath string, mode os.FileMode, to *zip.Writer) {
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd/reference"
+	"github.com/echocat/caretakerd/schema"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+var (
+	_ = app.Command("generate", "generates sources of the project which are derived from other sources").
+		Action(func(*kingpin.ParseContext) error {
+			generate(branch)
+			return nil
+		})
+
+	generatedSources = []string{
+		filepath.Join("schema", schema.FileName),
+		filepath.Join("reference", reference.FileName),
+	}
+)
+
+func generate(branch string) {
+	generateSourcesTo(branch, generatedSources...)
+}
+
+func generateSourcesTo(branch string, outputNames ...string) {
+	executeTo(func(cmd *exec.Cmd) {
+		cmd.Env = append(os.Environ(), "GO111MODULE=on")
+	}, os.Stderr, os.Stdout, append([]string{"go", "run", "./manual", branch, "linux"}, outputNames...)...)
+}
+
+func testGeneratedSourcesAreUpToDate(branch string) {
+	var outputNames []string
+	for _, source := range generatedSources {
+		outputNames = append(outputNames, filepath.Join("var", "generated", filepath.Base(source)))
+	}
+	must(os.MkdirAll(filepath.Join("var", "generated"), 0755))
+	generateSourcesTo(branch, outputNames...)
+	for i, source := range generatedSources {
+		expected, err := os.ReadFile(outputNames[i])
+		must(err)
+		actual, err := os.ReadFile(source)
+		must(err)
+		if !bytes.Equal(expected, actual) {
+			panic(fmt.Sprintf("%s is outdated. Please run 'go run ./build generate' and commit the result.", source))
+		}
+	}
+}
@@ -10,7 +10,14 @@ import (
 var (
 	currentTarget = target{os: runtime.GOOS, arch: runtime.GOARCH}
 	linuxAmd64    = target{os: "linux", arch: "amd64"}
-	targets       = []target{
+	// offlineManuals contains every manual (besides the HTML one) that is shipped with the packages.
+	offlineManuals = []offlineManual{
+		{name: caretakerd.DaemonName + ".1", path: "man/man1/" + caretakerd.DaemonName + ".1"},
+		{name: caretakerd.ControlName + ".1", path: "man/man1/" + caretakerd.ControlName + ".1"},
+		{name: caretakerd.DaemonName + ".yaml.5", path: "man/man5/" + caretakerd.DaemonName + ".yaml.5"},
+		{name: caretakerd.DaemonName + ".md", path: caretakerd.DaemonName + ".md"},
+	}
+	targets = []target{
 		{os: "windows", arch: "amd64"},
 		{os: "windows", arch: "386"},
 		{os: "windows", arch: "arm64"},
@@ -22,6 +29,12 @@ var (
 	}
 )
 
+// offlineManual is a manual with its file name and its location inside of the packages.
+type offlineManual struct {
+	name string
+	path string
+}
+
 type target struct {
 	os   string
 	arch string
@@ -39,6 +52,10 @@ func (instance target) manual() string {
 	return filepath.Join("var", "manuals", instance.outputName()+".html")
 }
 
+func (instance target) offlineManual(name string) string {
+	return filepath.Join("var", "manuals", instance.outputName(), name)
+}
+
 func (instance target) archive() string {
 	return filepath.Join("var", "dist", instance.outputName()+instance.archiveExtension())
 }
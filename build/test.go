@@ -17,6 +17,7 @@ var (
 )
 
 func test(branch, commit string) {
+	testGeneratedSourcesAreUpToDate(branch)
 	testGoCode(currentTarget)
 
 	buildBinary(branch, commit, currentTarget, true)
@@ -1,6 +1,7 @@
 package caretakerd
 
 import (
+	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/control"
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/keyStore"
@@ -45,15 +46,20 @@ func NewCaretakerd(conf *Config, syncGroup *usync.Group) (*Caretakerd, error) {
 	if err != nil {
 		return nil, errors.New("Could not create logger for caretakerd.").CausedBy(err)
 	}
-	ks, err := keyStore.NewKeyStore(bool(conf.RPC.Enabled), conf.KeyStore)
+	ks, err := keyStore.NewKeyStore(conf.isKeyStoreRequired(), conf.KeyStore)
 	if err != nil {
 		return nil, err
 	}
-	ctl, err := control.NewControl(conf.Control, ks)
+	ctlConf := conf.Control
+	if conf.RPC.Enabled != values.Boolean(true) {
+		// Without RPC there is nothing caretakerctl could access.
+		ctlConf.Access = access.NewNoneConfig()
+	}
+	ctl, err := control.NewControl(ctlConf, ks)
 	if err != nil {
 		return nil, err
 	}
-	services, err := service.NewServices(conf.Services, syncGroup, ks)
+	services, err := service.NewServices(conf.Services, conf.Environment, syncGroup, ks)
 	if err != nil {
 		return nil, err
 	}
@@ -117,8 +123,12 @@ func (instance *Caretakerd) ConfigObject() interface{} {
 // This is a blocking method.
 func (instance *Caretakerd) Run() (values.ExitCode, error) {
 	var r *rpc.RPC
+	var cw *certificateWatcher
 	defer func() {
 		instance.uninstallTerminationNotificationHandler()
+		if cw != nil {
+			cw.Stop()
+		}
 		if r != nil {
 			r.Stop()
 		}
@@ -126,9 +136,17 @@ func (instance *Caretakerd) Run() (values.ExitCode, error) {
 
 	execution := NewExecution(instance)
 	if instance.config.RPC.Enabled == values.Boolean(true) {
-		r = rpc.NewRPC(instance.config.RPC, execution, instance, instance.logger)
+		var err error
+		if r, err = rpc.NewRPC(instance.config.RPC, execution, instance, instance.logger); err != nil {
+			instance.logger.LogProblem(err, logger.Fatal, "Could not start rpc.")
+			return values.ExitCode(1), err
+		}
 		r.Start()
 	}
+	if instance.keyStore.IsEnabled() {
+		cw = newCertificateWatcher(instance, execution)
+		cw.Start()
+	}
 	instance.installTerminationNotificationHandler()
 	instance.execution = execution
 	return execution.Run()
@@ -0,0 +1,147 @@
+package caretakerd
+
+import (
+	"crypto/x509"
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/panics"
+	"github.com/echocat/caretakerd/service"
+	"time"
+)
+
+// The certificates are checked at least in this interval - also if no expiry is expected before.
+const maximumCertificateCheckInterval = time.Hour
+
+// certificateWatcher tracks the expiry of the certificate of caretakerd and of every generated certificate.
+// It warns ahead of time and renews them if configured in the keyStore. Generated certificates that were
+// revoked are renewed immediately.
+type certificateWatcher struct {
+	caretakerd *Caretakerd
+	execution  *Execution
+	warned     map[string]bool
+	done       chan struct{}
+}
+
+func newCertificateWatcher(caretakerd *Caretakerd, execution *Execution) *certificateWatcher {
+	return &certificateWatcher{
+		caretakerd: caretakerd,
+		execution:  execution,
+		warned:     map[string]bool{},
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts the watcher in the background. It runs until Stop is called.
+func (instance *certificateWatcher) Start() {
+	go instance.run()
+}
+
+// Stop stops the watcher.
+func (instance *certificateWatcher) Stop() {
+	close(instance.done)
+}
+
+func (instance *certificateWatcher) run() {
+	defer panics.DefaultPanicHandler()
+	for {
+		changed := instance.caretakerd.KeyStore().Changed()
+		instance.check()
+		select {
+		case <-instance.done:
+			return
+		case <-changed:
+		case <-time.After(instance.nextCheckIn()):
+		}
+	}
+}
+
+func (instance *certificateWatcher) check() {
+	ks := instance.caretakerd.KeyStore()
+	if !ks.IsEnabled() {
+		return
+	}
+	renewAll := false
+	if cert := ks.Certificate(); cert != nil {
+		if ks.IsRenewable() && ks.IsRenewalRequiredFor(cert) {
+			if err := ks.Renew(); err != nil {
+				instance.logger().LogProblem(err, logger.Error, "Could not renew the certificate of caretakerd that expires at %v.", cert.NotAfter)
+			} else {
+				instance.logger().Log(logger.Info, "Renewed the certificate of caretakerd that expires at %v. Every generated certificate will be renewed now, too.", cert.NotAfter)
+				renewAll = true
+			}
+		} else {
+			instance.warnIfRequired(cert, "caretakerd")
+		}
+	}
+	instance.checkAccess(instance.caretakerd.Control().Access(), nil, renewAll)
+	for _, s := range *instance.caretakerd.Services() {
+		instance.checkAccess(s.Access(), s, renewAll)
+	}
+}
+
+func (instance *certificateWatcher) checkAccess(acc *access.Access, s *service.Service, force bool) {
+	ks := instance.caretakerd.KeyStore()
+	cert := acc.Certificate()
+	if cert == nil {
+		return
+	}
+	revoked := ks.IsRevoked(cert)
+	if !acc.IsRenewable() || !(force || revoked || ks.IsRenewalRequiredFor(cert)) {
+		instance.warnIfRequired(cert, acc.Name())
+		return
+	}
+	if err := acc.Renew(ks); err != nil {
+		instance.logger().LogProblem(err, logger.Error, "Could not renew the certificate of '%v' that expires at %v.", acc.Name(), cert.NotAfter)
+		return
+	}
+	if revoked {
+		instance.logger().Log(logger.Info, "Renewed the revoked certificate of '%v'.", acc.Name())
+	} else {
+		instance.logger().Log(logger.Info, "Renewed the certificate of '%v' that expires at %v.", acc.Name(), cert.NotAfter)
+	}
+	if s != nil && s.Config().RestartOnCertificateRenewal {
+		if _, running := instance.execution.GetFor(s); running {
+			instance.logger().Log(logger.Info, "Restart service '%v' to apply its renewed certificate.", s)
+			if err := instance.execution.Restart(s); err != nil {
+				instance.logger().LogProblem(err, logger.Error, "Could not restart service '%v' to apply its renewed certificate.", s)
+			}
+		}
+	}
+}
+
+func (instance *certificateWatcher) warnIfRequired(cert *x509.Certificate, owner string) {
+	key := cert.SerialNumber.String()
+	if instance.warned[key] || !instance.caretakerd.KeyStore().IsExpiryWarningRequiredFor(cert) {
+		return
+	}
+	instance.warned[key] = true
+	instance.logger().Log(logger.Warning, "The certificate of '%v' expires at %v.", owner, cert.NotAfter)
+}
+
+// Returns the time until the next certificate has to be renewed or a warning has to be logged.
+func (instance *certificateWatcher) nextCheckIn() time.Duration {
+	ks := instance.caretakerd.KeyStore()
+	conf := ks.Config()
+	certs := []*x509.Certificate{ks.Certificate(), instance.caretakerd.Control().Access().Certificate()}
+	for _, s := range *instance.caretakerd.Services() {
+		certs = append(certs, s.Access().Certificate())
+	}
+	now := time.Now()
+	next := now.Add(maximumCertificateCheckInterval)
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+		for _, before := range []time.Duration{conf.RenewBefore.Duration(), conf.WarnBefore.Duration()} {
+			if at := cert.NotAfter.Add(-before); before > 0 && at.After(now) && at.Before(next) {
+				next = at
+			}
+		}
+	}
+	// Wait a little bit longer to be sure that the certificate is really within the period.
+	return next.Sub(now) + time.Second
+}
+
+func (instance *certificateWatcher) logger() *logger.Logger {
+	return instance.caretakerd.Logger()
+}
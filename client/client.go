@@ -5,13 +5,16 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/control"
 	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/rpc"
 	"github.com/echocat/caretakerd/service"
 	"github.com/echocat/caretakerd/values"
 	"gopkg.in/jmcvetta/napping.v3"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -70,20 +73,36 @@ func (instance *Factory) NewClient() (*Client, error) {
 	return NewClient(config)
 }
 
+// NewClientWithTimeout creates a new Client which fails if a request (including the connect)
+// does not complete within the given timeout.
+func (instance *Factory) NewClientWithTimeout(timeout time.Duration) (*Client, error) {
+	result, err := instance.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	result.session.Client.Timeout = timeout
+	return result, nil
+}
+
 // Client is used to access caretakerd remotely.
 type Client struct {
 	address values.SocketAddress
+	baseURL string
 	session *napping.Session
 }
 
 // NewClient creates a new instance of Client with the given config.
 func NewClient(config *caretakerd.Config) (*Client, error) {
+	if config.RPC.Listen.Protocol == values.FD {
+		return nil, errors.New("Cannot connect to %v because file descriptors could only be used to listen. Use --address to specify another address.", config.RPC.Listen)
+	}
 	session, err := sessionFor(config)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
 		address: config.RPC.Listen,
+		baseURL: baseURLFor(config),
 		session: session,
 	}, nil
 }
@@ -93,9 +112,15 @@ func sessionFor(config *caretakerd.Config) (*napping.Session, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &napping.Session{
+	result := &napping.Session{
 		Client: httpClient,
-	}, nil
+	}
+	// Services with access type generateTokenToEnvironment get their token using this variable.
+	if token := os.Getenv("CTD_TOKEN"); token != "" {
+		result.Header = &http.Header{}
+		result.Header.Set("Authorization", "Bearer "+token)
+	}
+	return result, nil
 }
 
 func httpClientFor(config *caretakerd.Config) (*http.Client, error) {
@@ -108,14 +133,36 @@ func httpClientFor(config *caretakerd.Config) (*http.Client, error) {
 	}, nil
 }
 
+// Connections using unix sockets are not encrypted by default. The daemon authorizes them using the credentials
+// of the connecting process.
+func isUsingPeerCredentials(config *caretakerd.Config) bool {
+	return config.RPC.Security.ResolveFor(config.RPC.Listen) == rpc.PeerCredentials
+}
+
+func baseURLFor(config *caretakerd.Config) string {
+	if isUsingPeerCredentials(config) {
+		return "http://caretakerd/"
+	}
+	return "https://caretakerd/"
+}
+
 func transportFor(config *caretakerd.Config) (*http.Transport, error) {
+	if isUsingPeerCredentials(config) {
+		address := config.RPC.Listen
+		return &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, address.AsScheme(), address.AsAddress())
+			},
+		}, nil
+	}
 	tlsConfig, err := tlsConfigFor(config)
 	if err != nil {
 		return nil, err
 	}
 	return &http.Transport{
-		DialTLSContext: func(_ context.Context, network, addr string) (net.Conn, error) {
-			return dialTLSWithOwnChecks(config, tlsConfig)
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialTLSWithOwnChecks(ctx, config, tlsConfig)
 		},
 		TLSClientConfig: tlsConfig,
 	}, nil
@@ -123,7 +170,10 @@ func transportFor(config *caretakerd.Config) (*http.Transport, error) {
 
 func tlsConfigFor(config *caretakerd.Config) (*tls.Config, error) {
 	certificates, err := parseCertificatesInFile(config.Control.Access.PemFile)
-	if err != nil {
+	if err != nil && !isPemAvailable(config) && os.Getenv("CTD_TOKEN") != "" {
+		// Clients with a token are authorized by it - they connect without a certificate.
+		return tokenTLSConfigFor(config)
+	} else if err != nil {
 		return nil, err
 	}
 	certificatePool, err := certPoolFor(certificates)
@@ -137,6 +187,34 @@ func tlsConfigFor(config *caretakerd.Config) (*tls.Config, error) {
 	}, nil
 }
 
+func isPemAvailable(config *caretakerd.Config) bool {
+	if os.Getenv("CTD_PEM") != "" {
+		return true
+	}
+	_, err := os.Stat(config.Control.Access.PemFile.String())
+	return err == nil
+}
+
+// Creates a TLS config without a client certificate. caretakerd is verified using the certificates of the
+// environment variable CTD_CA or of the caFile of the keyStore.
+func tokenTLSConfigFor(config *caretakerd.Config) (*tls.Config, error) {
+	ca := []byte(os.Getenv("CTD_CA"))
+	if len(ca) == 0 && !config.KeyStore.CaFile.IsTrimmedEmpty() {
+		var err error
+		if ca, err = os.ReadFile(config.KeyStore.CaFile.String()); err != nil {
+			return nil, errors.New("Could not read caFile '%v'.", config.KeyStore.CaFile).CausedBy(err)
+		}
+	}
+	certificatePool := x509.NewCertPool()
+	if !certificatePool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("There are no certificates to verify caretakerd. Provide them using the environment variable CTD_CA or the caFile of the keyStore.")
+	}
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		RootCAs:            certificatePool,
+	}, nil
+}
+
 func parseCertificatesInFile(filename values.String) ([]tls.Certificate, error) {
 	pemInEnv := os.Getenv("CTD_PEM")
 	if len(pemInEnv) > 0 {
@@ -171,20 +249,15 @@ func certPoolFor(certificates []tls.Certificate) (*x509.CertPool, error) {
 	return result, nil
 }
 
-func dialTLSWithOwnChecks(config *caretakerd.Config, tlsConfig *tls.Config) (net.Conn, error) {
-	var err error
-	var tlsConn *tls.Conn
-
+func dialTLSWithOwnChecks(ctx context.Context, config *caretakerd.Config, tlsConfig *tls.Config) (net.Conn, error) {
 	address := config.RPC.Listen
-	tlsConn, err = tls.Dial(address.AsScheme(), address.AsAddress(), tlsConfig)
+	dialer := tls.Dialer{Config: tlsConfig}
+	// The connection is returned after the handshake is completed.
+	conn, err := dialer.DialContext(ctx, address.AsScheme(), address.AsAddress())
 	if err != nil {
 		return nil, err
 	}
-
-	if err = tlsConn.Handshake(); err != nil {
-		_ = tlsConn.Close()
-		return nil, err
-	}
+	tlsConn := conn.(*tls.Conn)
 
 	opts := x509.VerifyOptions{
 		Roots:         tlsConfig.RootCAs,
@@ -288,6 +361,21 @@ func (instance *Client) GetServicePid(name string) (values.Integer, error) {
 	return target, nil
 }
 
+// WaitForService blocks until the given service (by name) of the remote caretakerd instance meets the
+// given condition or the timeout elapsed. The returned body reports if the condition was met.
+func (instance *Client) WaitForService(name string, condition service.Condition, timeout time.Duration) (rpc.WaitBody, error) {
+	target := rpc.WaitBody{}
+	params := url.Values{}
+	params.Set("for", condition.String())
+	params.Set("timeout", timeout.String())
+	path := "service/" + name + "/wait"
+	resp, err := instance.session.Get(instance.baseURL+path, &params, &target, nil)
+	if err := instance.transformError(path, resp, err); err != nil {
+		return target, err
+	}
+	return target, nil
+}
+
 // StartService starts the given service (by name) of the remote caretakerd instance.
 func (instance *Client) StartService(name string) error {
 	err := instance.post("service/"+name+"/start", nil)
@@ -332,8 +420,34 @@ func (instance *Client) SignalService(name string, s values.Signal) error {
 	return err
 }
 
+// Revoke revokes the certificate with the given serial (hexadecimal) or every certificate
+// with the given common name at the remote caretakerd instance.
+func (instance *Client) Revoke(serialOrName string) error {
+	payload := map[string]string{
+		"target": serialOrName,
+	}
+	return instance.post("keystore/revoke", &payload)
+}
+
+// Issue issues a new certificate for the given name with the given permission and roles embedded
+// at the remote caretakerd instance. The returned body contains the pem with the private key.
+func (instance *Client) Issue(name string, permission access.Permission, roles []string) (rpc.IssuedBody, error) {
+	payload := rpc.IssueBody{
+		Name:       name,
+		Permission: permission,
+		Roles:      roles,
+	}
+	target := rpc.IssuedBody{}
+	path := "keystore/issue"
+	resp, err := instance.session.Post(instance.baseURL+path, &payload, &target, nil)
+	if err := instance.transformError(path, resp, err); err != nil {
+		return target, err
+	}
+	return target, nil
+}
+
 func (instance *Client) get(path string, target interface{}) error {
-	resp, err := instance.session.Get("https://caretakerd/"+path, nil, target, nil)
+	resp, err := instance.session.Get(instance.baseURL+path, nil, target, nil)
 	if err != nil {
 		return err
 	}
@@ -366,7 +480,7 @@ func (instance *Client) transformError(path string, resp *napping.Response, err
 }
 
 func (instance *Client) getPlain(path string) (string, error) {
-	resp, err := instance.session.Get("https://caretakerd/"+path, nil, nil, nil)
+	resp, err := instance.session.Get(instance.baseURL+path, nil, nil, nil)
 	targetErr := instance.transformError(path, resp, err)
 	if targetErr != nil {
 		return "", targetErr
@@ -375,6 +489,6 @@ func (instance *Client) getPlain(path string) (string, error) {
 }
 
 func (instance *Client) post(path string, payload interface{}) error {
-	resp, err := instance.session.Post("https://caretakerd/"+path, payload, nil, nil)
+	resp, err := instance.session.Post(instance.baseURL+path, payload, nil, nil)
 	return instance.transformError(path, resp, err)
 }
@@ -0,0 +1,199 @@
+package client
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/keyStore"
+	"github.com/echocat/caretakerd/rpc"
+	"github.com/echocat/caretakerd/service"
+	"github.com/echocat/caretakerd/sync"
+	"github.com/echocat/caretakerd/values"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type ClientTest struct{}
+
+func init() {
+	Suite(&ClientTest{})
+}
+
+func freeTCPAddress(c *C) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer func() { _ = listener.Close() }()
+	return listener.Addr().String()
+}
+
+// Starts the RPC of caretakerd over TLS with a service that gets its token from the environment.
+// The service is allowed to read and to issue certificates.
+func (s *ClientTest) startDaemon(c *C) (*caretakerd.Config, *caretakerd.Caretakerd, *rpc.RPC) {
+	conf := caretakerd.NewConfigFor("linux")
+	conf.RPC.Enabled = values.Boolean(true)
+	c.Assert(conf.RPC.Listen.SetTCP(freeTCPAddress(c)), IsNil)
+	conf.Control.Access.PemFile = values.String(filepath.Join(c.MkDir(), "control.pem"))
+	conf.RPC.Roles = access.Roles{
+		"issuer":    {Verbs: []access.Verb{access.Issue}},
+		"restarter": {Verbs: []access.Verb{access.Restart}},
+	}
+
+	master := service.NewConfig()
+	master.Type = service.Master
+	master.Command = []values.String{"sleep", "1"}
+	worker := service.NewConfig()
+	worker.Command = []values.String{"sleep", "1"}
+	worker.Access = access.Config{Type: access.GenerateTokenToEnvironment, Permission: access.ReadOnly, Roles: []values.String{"issuer"}}
+	conf.Services = service.Configs{"master": master, "worker": worker}
+
+	instance, err := caretakerd.NewCaretakerd(&conf, sync.NewGroup())
+	c.Assert(err, IsNil)
+	r, err := rpc.NewRPC(conf.RPC, caretakerd.NewExecution(instance), instance, instance.Logger())
+	c.Assert(err, IsNil)
+	r.Start()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", conf.RPC.Listen.AsAddress()); err == nil {
+			_ = conn.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return &conf, instance, r
+}
+
+// Configures the client like a service that got its token and the certificates of caretakerd from the
+// environment - without a pem. The returned function has to be called to clean up the environment.
+func (s *ClientTest) tokenClientConfigOf(c *C, conf *caretakerd.Config, instance *caretakerd.Caretakerd) (caretakerd.Config, func()) {
+	clientConf := *conf
+	clientConf.Control.Access.PemFile = values.String(filepath.Join(c.MkDir(), "missing.pem"))
+	c.Assert(os.Setenv("CTD_TOKEN", instance.Services().Get("worker").Access().Token()), IsNil)
+	c.Assert(os.Setenv("CTD_CA", string(instance.KeyStore().CABundle())), IsNil)
+	return clientConf, func() {
+		_ = os.Unsetenv("CTD_TOKEN")
+		_ = os.Unsetenv("CTD_CA")
+	}
+}
+
+func (s *ClientTest) TestTokenOverTLS(c *C) {
+	conf, instance, r := s.startDaemon(c)
+	defer r.Stop()
+	defer instance.Close()
+	clientConf, cleanup := s.tokenClientConfigOf(c, conf, instance)
+	defer cleanup()
+
+	cli, err := NewClient(&clientConf)
+	c.Assert(err, IsNil)
+	services, err := cli.GetServices()
+	c.Assert(err, IsNil)
+	c.Assert(services["worker"].Config.Access.Type, Equals, access.GenerateTokenToEnvironment)
+
+	// The token is only allowed to read.
+	c.Assert(cli.StopService("worker"), FitsTypeOf, AccessDeniedError{})
+
+	// Another certificate than the one of caretakerd is not trusted.
+	c.Assert(os.Setenv("CTD_CA", string(s.otherCABundle(c))), IsNil)
+	cli, err = NewClient(&clientConf)
+	c.Assert(err, IsNil)
+	_, err = cli.GetServices()
+	c.Assert(err, ErrorMatches, ".*certificate signed by unknown authority.*")
+
+	// Without any certificates to verify caretakerd the client is not able to connect.
+	c.Assert(os.Unsetenv("CTD_CA"), IsNil)
+	_, err = NewClient(&clientConf)
+	c.Assert(err, ErrorMatches, "There are no certificates to verify caretakerd.*")
+}
+
+func (s *ClientTest) TestIssueDoesNotExceedOwnAccess(c *C) {
+	conf, instance, r := s.startDaemon(c)
+	defer r.Stop()
+	defer instance.Close()
+	clientConf, cleanup := s.tokenClientConfigOf(c, conf, instance)
+	defer cleanup()
+
+	cli, err := NewClient(&clientConf)
+	c.Assert(err, IsNil)
+	issued, err := cli.Issue("alice", access.ReadOnly, []string{"issuer"})
+	c.Assert(err, IsNil)
+	c.Assert(issued.Pem, Matches, "(?s).*-----BEGIN CERTIFICATE-----.*")
+
+	_, err = cli.Issue("bob", access.ReadWrite, nil)
+	c.Assert(err, FitsTypeOf, AccessDeniedError{})
+	_, err = cli.Issue("carol", access.Forbidden, []string{"restarter"})
+	c.Assert(err, FitsTypeOf, AccessDeniedError{})
+}
+
+func (s *ClientTest) TestRevokedCertificateIsRejected(c *C) {
+	conf, instance, r := s.startDaemon(c)
+	defer r.Stop()
+	defer instance.Close()
+
+	control, err := NewClient(conf)
+	c.Assert(err, IsNil)
+	issued, err := control.Issue("alice", access.ReadOnly, nil)
+	c.Assert(err, IsNil)
+	aliceConf := *conf
+	aliceConf.Control.Access.PemFile = values.String(filepath.Join(c.MkDir(), "alice.pem"))
+	c.Assert(os.WriteFile(aliceConf.Control.Access.PemFile.String(), []byte(issued.Pem), 0600), IsNil)
+	alice, err := NewClient(&aliceConf)
+	c.Assert(err, IsNil)
+	_, err = alice.GetServices()
+	c.Assert(err, IsNil)
+
+	c.Assert(control.Revoke(issued.Serial), IsNil)
+	_, err = alice.GetServices()
+	c.Assert(err, ErrorMatches, ".*403 - No get permission.*")
+	_, err = control.GetServices()
+	c.Assert(err, IsNil)
+}
+
+func (s *ClientTest) otherCABundle(c *C) []byte {
+	conf := keyStore.NewConfig()
+	conf.Type = keyStore.Generated
+	ks, err := keyStore.NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	return ks.CABundle()
+}
+
+type staticConfigProvider caretakerd.Config
+
+func (instance staticConfigProvider) ProvideConfig(bool) (*caretakerd.Config, error) {
+	result := caretakerd.Config(instance)
+	return &result, nil
+}
+
+func (s *ClientTest) TestNewClientWithTimeout(c *C) {
+	conf, instance, r := s.startDaemon(c)
+	defer r.Stop()
+	defer instance.Close()
+
+	// Accepts connections but never responds - like a hanging daemon.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+	clientConf := *conf
+	c.Assert(clientConf.RPC.Listen.SetTCP(listener.Addr().String()), IsNil)
+
+	cli, err := NewFactory(staticConfigProvider(clientConf)).NewClientWithTimeout(200 * time.Millisecond)
+	c.Assert(err, IsNil)
+	started := time.Now()
+	_, err = cli.GetServices()
+	c.Assert(err, ErrorMatches, ".*Client.Timeout exceeded.*")
+	c.Assert(time.Since(started) < 5*time.Second, Equals, true)
+
+	cli, err = NewFactory(staticConfigProvider(*conf)).NewClientWithTimeout(5 * time.Second)
+	c.Assert(err, IsNil)
+	_, err = cli.GetServices()
+	c.Assert(err, IsNil)
+}
@@ -1,6 +1,7 @@
 package caretakerd
 
 import (
+	"encoding/json"
 	"github.com/echocat/caretakerd/control"
 	"github.com/echocat/caretakerd/keyStore"
 	"github.com/echocat/caretakerd/logger"
@@ -35,6 +36,31 @@ type Config struct {
 	// For details see {@ref github.com/echocat/caretakerd/logger.Config}.
 	Logger logger.Config `json:"logger" yaml:"logger,omitempty"`
 
+	// @default []
+	//
+	// Config files to include. Every entry could be either a path to a file or a glob pattern
+	// (like ``services/*.yaml``). Relative paths are resolved relative to the directory of the file
+	// that contains the include.
+	//
+	// Included files could only contain {@ref #Services services} and further includes. Every service
+	// could only be defined once over all files.
+	//
+	// Example:
+	// ```yaml
+	// include: ["services/*.yaml", "/etc/caretakerd/extra.yaml"]
+	// ```
+	//
+	// > **Hint:** Additionally the ``--config-dir`` flag could be used to include every ``*.yaml`` and
+	// > ``*.yml`` file of a directory.
+	Include []values.String `json:"include" yaml:"include,omitempty"`
+
+	// @default []
+	//
+	// Environment variables to pass to every service process.
+	//
+	// For the precedence of variables see {@ref github.com/echocat/caretakerd/service.Config#Environment}.
+	Environment service.Environments `json:"environment" yaml:"environment,omitempty"`
+
 	// Services configuration to run with caretakerd.
 	//
 	// > **Important**: This is a map and requires exact one service
@@ -45,6 +71,9 @@ type Config struct {
 
 	// Contains the source where this config comes from.
 	Source values.String `json:"-" yaml:"-"`
+
+	// Contains the absolute paths of every file that was already loaded into this config.
+	includedFiles map[string]bool `json:"-" yaml:"-"`
 }
 
 // NewConfigFor create a new config instance.
@@ -69,20 +98,57 @@ func (instance Config) Validate() error {
 	if err == nil {
 		err = instance.Services.Validate()
 	}
+	if err == nil {
+		err = instance.validateRoleBindings()
+	}
 	return err
 }
 
+func (instance Config) validateRoleBindings() error {
+	roles := instance.RPC.Roles
+	if err := roles.ValidateBindingsOf(instance.Control.Access, "control"); err != nil {
+		return err
+	}
+	for name, conf := range instance.Services {
+		if err := roles.ValidateBindingsOf(conf.Access, "service '"+name+"'"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns "true" if the keyStore is used by the RPC interface or to generate the certificates of services.
+func (instance Config) isKeyStoreRequired() bool {
+	if instance.RPC.Enabled == values.Boolean(true) {
+		return true
+	}
+	for _, conf := range instance.Services {
+		if conf.Access.Type.IsGenerating() {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateMaster return an error instance on every validation problem of the
 // master service config instance.
 func (instance Config) ValidateMaster() error {
 	return instance.Services.ValidateMaster()
 }
 
+// Lint returns warnings for settings of this config that are valid but will probably
+// not behave like expected.
+func (instance Config) Lint() []string {
+	return instance.Services.Lint()
+}
+
 func (instance *Config) init(platform string) {
 	(*instance).KeyStore = keyStore.NewConfig()
 	(*instance).RPC = rpc.NewConfigFor(platform)
 	(*instance).Control = control.NewConfigFor(platform)
 	(*instance).Logger = logger.NewConfig()
+	(*instance).Include = []values.String{}
+	(*instance).Environment = service.Environments{}
 	(*instance).Services = service.NewConfigs()
 }
 
@@ -93,3 +159,11 @@ func (instance *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type noMethods Config
 	return unmarshal((*noMethods)(instance))
 }
+
+// UnmarshalJSON is used by json unmarshalling. Do not call direct.
+func (instance *Config) UnmarshalJSON(b []byte) error {
+	instance.init(runtime.GOOS)
+
+	type noMethods Config
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
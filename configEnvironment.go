@@ -6,6 +6,7 @@ import (
 	"github.com/echocat/caretakerd/service"
 	"github.com/echocat/caretakerd/values"
 	"os"
+	"reflect"
 	"strings"
 )
 
@@ -13,60 +14,56 @@ const (
 	envPrefix = "CTD."
 )
 
-var globalEnvKeyToHandler = map[string]func(*Config, string) error{
-	// logger.config
-	"LOG_LEVEL":           handleGlobalLogLevelEnv,
-	"LOG_STDOUT_LEVEL":    handleGlobalLogStdoutLevelEnv,
-	"LOG_STDERR_LEVEL":    handleGlobalLogStderrLevelEnv,
-	"LOG_FILE":            handleGlobalLogFilenameEnv,
-	"LOG_FILE_NAME":       handleGlobalLogFilenameEnv,
-	"LOG_MAX_SIZE":        handleGlobalLogMaxSizeInMbEnv,
-	"LOG_MAX_SIZE_IN_MB":  handleGlobalLogMaxSizeInMbEnv,
-	"LOG_MAX_BACKUPS":     handleGlobalLogMaxBackupsEnv,
-	"LOG_MAX_AGE":         handleGlobalLogMaxAgeInDaysEnv,
-	"LOG_MAX_AGE_IN_DAYS": handleGlobalLogMaxAgeInDaysEnv,
-}
-
-var serviceEnvKeyToFunction = map[string]func(*service.Config, string) error{
-	// service.config
-	"CMD":                      handleServiceCommandEnv,
-	"COMMAND":                  handleServiceCommandEnv,
-	"TYPE":                     handleServiceTypeEnv,
-	"START_DELAY":              handleServiceStartDelayInSecondsEnv,
-	"START_DELAY_IN_SECONDS":   handleServiceStartDelayInSecondsEnv,
-	"RESTART_DELAY":            handleServiceRestartDelayInSecondsEnv,
-	"RESTART_DELAY_IN_SECONDS": handleServiceRestartDelayInSecondsEnv,
-	"EXIT_CODE":                handleServiceSuccessExitCodesEnv,
-	"EXIT_CODES":               handleServiceSuccessExitCodesEnv,
-	"SUCCESS_EXIT_CODE":        handleServiceSuccessExitCodesEnv,
-	"SUCCESS_EXIT_CODES":       handleServiceSuccessExitCodesEnv,
-	"STOP_WAIT":                handleServiceStopWaitInSecondsEnv,
-	"STOP_WAIT_IN_SECONDS":     handleServiceStopWaitInSecondsEnv,
-	"USER":                     handleServiceUserEnv,
-	"DIR":                      handleServiceDirectoryEnv,
-	"DIRECORY":                 handleServiceDirectoryEnv,
-	"RESTART":                  handleServiceAutoRestartEnv,
-	"AUTO_RESTART":             handleServiceAutoRestartEnv,
-	"INHERIT_ENV":              handleServiceInheritEnvironmentEnv,
-	"INHERIT_ENVIRONMENT":      handleServiceInheritEnvironmentEnv,
-	// logger.config
-	"LOG_LEVEL":           handleServiceLogLevelEnv,
-	"LOG_STDOUT_LEVEL":    handleServiceLogStdoutLevelEnv,
-	"LOG_STDERR_LEVEL":    handleServiceLogStderrLevelEnv,
-	"LOG_FILE":            handleServiceLogFilenameEnv,
-	"LOG_FILE_NAME":       handleServiceLogFilenameEnv,
-	"LOG_MAX_SIZE":        handleServiceLogMaxSizeInMbEnv,
-	"LOG_MAX_SIZE_IN_MB":  handleServiceLogMaxSizeInMbEnv,
-	"LOG_MAX_BACKUPS":     handleServiceLogMaxBackupsEnv,
-	"LOG_MAX_AGE":         handleServiceLogMaxAgeInDaysEnv,
-	"LOG_MAX_AGE_IN_DAYS": handleServiceLogMaxAgeInDaysEnv,
-}
-
-var serviceSubEnvKeyToFunction = map[string]func(*service.Config, string, string) error{
-	// service.config
-	"ENV":         handleEnvironmentEnv,
-	"ENVIRONMENT": handleEnvironmentEnv,
-}
+// Aliases of global environment keys that were supported before every key was derived
+// from the configuration structure.
+var globalEnvironmentKeyAliases = map[string]string{
+	"LOG_LEVEL":           "LOGGER_LEVEL",
+	"LOG_STDOUT_LEVEL":    "LOGGER_STDOUT_LEVEL",
+	"LOG_STDERR_LEVEL":    "LOGGER_STDERR_LEVEL",
+	"LOG_FILE":            "LOGGER_FILENAME",
+	"LOG_FILE_NAME":       "LOGGER_FILENAME",
+	"LOG_MAX_SIZE":        "LOGGER_MAX_SIZE_IN_MB",
+	"LOG_MAX_SIZE_IN_MB":  "LOGGER_MAX_SIZE_IN_MB",
+	"LOG_MAX_BACKUPS":     "LOGGER_MAX_BACKUPS",
+	"LOG_MAX_AGE":         "LOGGER_MAX_AGE_IN_DAYS",
+	"LOG_MAX_AGE_IN_DAYS": "LOGGER_MAX_AGE_IN_DAYS",
+	"LOG_PATTERN":         "LOGGER_PATTERN",
+	"ENV":                 "ENVIRONMENT",
+}
+
+// Aliases of service environment keys that were supported before every key was derived
+// from the configuration structure.
+var serviceEnvironmentKeyAliases = map[string]string{
+	"CMD":                 "COMMAND",
+	"START_DELAY":         "START_DELAY_IN_SECONDS",
+	"RESTART_DELAY":       "RESTART_DELAY_IN_SECONDS",
+	"EXIT_CODE":           "SUCCESS_EXIT_CODES",
+	"EXIT_CODES":          "SUCCESS_EXIT_CODES",
+	"SUCCESS_EXIT_CODE":   "SUCCESS_EXIT_CODES",
+	"STOP_WAIT":           "STOP_WAIT_IN_SECONDS",
+	"DIR":                 "DIRECTORY",
+	"DIRECORY":            "DIRECTORY",
+	"RESTART":             "AUTO_RESTART",
+	"INHERIT_ENV":         "INHERIT_ENVIRONMENT",
+	"INHERIT_ENV_INCLUDE": "INHERIT_ENVIRONMENT_INCLUDE",
+	"INHERIT_ENV_EXCLUDE": "INHERIT_ENVIRONMENT_EXCLUDE",
+	"ENV":                 "ENVIRONMENT",
+	"ENV_FILES":           "ENVIRONMENT_FILES",
+	"LOG_LEVEL":           "LOGGER_LEVEL",
+	"LOG_STDOUT_LEVEL":    "LOGGER_STDOUT_LEVEL",
+	"LOG_STDERR_LEVEL":    "LOGGER_STDERR_LEVEL",
+	"LOG_FILE":            "LOGGER_FILENAME",
+	"LOG_FILE_NAME":       "LOGGER_FILENAME",
+	"LOG_MAX_SIZE":        "LOGGER_MAX_SIZE_IN_MB",
+	"LOG_MAX_SIZE_IN_MB":  "LOGGER_MAX_SIZE_IN_MB",
+	"LOG_MAX_BACKUPS":     "LOGGER_MAX_BACKUPS",
+	"LOG_MAX_AGE":         "LOGGER_MAX_AGE_IN_DAYS",
+	"LOG_MAX_AGE_IN_DAYS": "LOGGER_MAX_AGE_IN_DAYS",
+	"LOG_PATTERN":         "LOGGER_PATTERN",
+}
+
+var globalEnvironmentMappings = newEnvironmentMappingsFor(reflect.TypeOf(Config{}), globalEnvironmentKeyAliases, "Include", "Services")
+var serviceEnvironmentMappings = newEnvironmentMappingsFor(reflect.TypeOf(service.Config{}), serviceEnvironmentKeyAliases)
 
 // Appendable indicates an instance where a string can be appended.
 type Appendable interface {
@@ -81,14 +78,34 @@ type Putable interface {
 // EnrichFromEnvironment enriches the current Config instance with configuration from the environment
 // variables.
 func (instance Config) EnrichFromEnvironment() Config {
+	return instance.enrichFrom(os.Environ(), false)
+}
+
+// EnrichFromEnvironmentCreatingServices is like EnrichFromEnvironment but every service referenced
+// by an environment variable (CTD.<service>.<key>) that does not exist will be created.
+func (instance Config) EnrichFromEnvironmentCreatingServices() Config {
+	return instance.enrichFrom(os.Environ(), true)
+}
+
+func (instance Config) enrichFrom(environ []string, createServices bool) Config {
 	result := &instance
-	for _, plainEnviron := range os.Environ() {
+	if createServices {
+		for _, plainEnviron := range environ {
+			key := strings.SplitN(plainEnviron, "=", 2)[0]
+			if serviceName, ok := serviceNameOfEnv(key); ok {
+				if _, exists := result.Services[serviceName]; !exists {
+					result.Services[serviceName] = service.NewConfig()
+				}
+			}
+		}
+	}
+	for _, plainEnviron := range environ {
 		environ := strings.SplitN(plainEnviron, "=", 2)
 		var err error
 		if len(environ) > 1 {
-			err = instance.handleUnknownEnv(plainEnviron, environ[0], environ[1])
+			err = result.handleUnknownEnv(plainEnviron, environ[0], environ[1])
 		} else {
-			err = instance.handleUnknownEnv(plainEnviron, environ[0], "")
+			err = result.handleUnknownEnv(plainEnviron, environ[0], "")
 		}
 		if err != nil {
 			panics.New("Could not handle environment variable '%s'. Got: %s", plainEnviron, err.Error()).CausedBy(err).Throw()
@@ -97,6 +114,18 @@ func (instance Config) EnrichFromEnvironment() Config {
 	return *result
 }
 
+// Returns the name of the service the given environment variable is addressed to.
+func serviceNameOfEnv(key string) (string, bool) {
+	if !strings.HasPrefix(strings.ToUpper(key), envPrefix) {
+		return "", false
+	}
+	parts := strings.SplitN(key[len(envPrefix):], ".", 3)
+	if _, ok := globalEnvironmentMappings.find(parts[0]); ok || len(parts) < 2 || len(parts[0]) == 0 {
+		return "", false
+	}
+	return parts[0], true
+}
+
 func (instance *Config) handleUnknownEnv(full string, key string, value string) error {
 	prefixLength := len(envPrefix)
 	var err error
@@ -109,136 +138,22 @@ func (instance *Config) handleUnknownEnv(full string, key string, value string)
 }
 
 func (instance *Config) handleEnv(full string, key string, value string) error {
-	if handler, ok := globalEnvKeyToHandler[key]; ok {
-		return handler(instance, value)
-	}
-	parts := strings.SplitN(key, ".", 3)
-	var err error
-	if len(parts) == 2 {
-		err = instance.handleServiceEnv(full, parts[0], parts[1], value)
-	} else if len(parts) == 3 {
-		err = instance.handleServiceMapEnv(full, parts[0], parts[1], parts[2], value)
-	} else {
-		err = errors.New("Illegal environment variable found: '%s'. Unexpected number of '.' chracters.", full)
+	parts := strings.SplitN(key, ".", 2)
+	if mapping, ok := globalEnvironmentMappings.find(parts[0]); ok {
+		return mapping.applyEnvironment(reflect.ValueOf(instance).Elem(), parts[1:], value)
 	}
-	return err
-}
-
-func handleGlobalLogLevelEnv(conf *Config, value string) error {
-	return conf.Logger.Level.Set(value)
-}
-
-func handleGlobalLogStdoutLevelEnv(conf *Config, value string) error {
-	return conf.Logger.StdoutLevel.Set(value)
-}
-
-func handleGlobalLogStderrLevelEnv(conf *Config, value string) error {
-	return conf.Logger.StderrLevel.Set(value)
-}
-
-func handleGlobalLogFilenameEnv(conf *Config, value string) error {
-	return conf.Logger.Filename.Set(value)
-}
-
-func handleGlobalLogMaxSizeInMbEnv(conf *Config, value string) error {
-	return conf.Logger.MaxSizeInMb.Set(value)
-}
-
-func handleGlobalLogMaxBackupsEnv(conf *Config, value string) error {
-	return conf.Logger.MaxBackups.Set(value)
-}
-
-func handleGlobalLogMaxAgeInDaysEnv(conf *Config, value string) error {
-	return conf.Logger.MaxAgeInDays.Set(value)
-}
-
-func handleServiceCommandEnv(conf *service.Config, value string) error {
-	conf.Command = append(conf.Command, parseCmd(value)...)
-	return nil
-}
-
-func handleServiceTypeEnv(conf *service.Config, value string) error {
-	return conf.Type.Set(value)
-}
-
-func handleServiceStartDelayInSecondsEnv(conf *service.Config, value string) error {
-	return conf.StartDelayInSeconds.Set(value)
-}
-
-func handleServiceRestartDelayInSecondsEnv(conf *service.Config, value string) error {
-	return conf.RestartDelayInSeconds.Set(value)
-}
-
-func handleServiceSuccessExitCodesEnv(conf *service.Config, value string) error {
-	return conf.SuccessExitCodes.Set(value)
-}
-
-func handleServiceStopWaitInSecondsEnv(conf *service.Config, value string) error {
-	return conf.StopWaitInSeconds.Set(value)
-}
-
-func handleServiceUserEnv(conf *service.Config, value string) error {
-	return conf.User.Set(value)
-}
-
-func handleServiceDirectoryEnv(conf *service.Config, value string) error {
-	return conf.Directory.Set(value)
-}
-
-func handleServiceAutoRestartEnv(conf *service.Config, value string) error {
-	return conf.AutoRestart.Set(value)
-}
-
-func handleServiceLogLevelEnv(conf *service.Config, value string) error {
-	return conf.Logger.Level.Set(value)
-}
-
-func handleServiceLogStdoutLevelEnv(conf *service.Config, value string) error {
-	return conf.Logger.StdoutLevel.Set(value)
-}
-
-func handleServiceLogStderrLevelEnv(conf *service.Config, value string) error {
-	return conf.Logger.StderrLevel.Set(value)
-}
-
-func handleServiceLogFilenameEnv(conf *service.Config, value string) error {
-	return conf.Logger.Filename.Set(value)
-}
-
-func handleServiceLogMaxSizeInMbEnv(conf *service.Config, value string) error {
-	return conf.Logger.MaxSizeInMb.Set(value)
-}
-
-func handleServiceLogMaxBackupsEnv(conf *service.Config, value string) error {
-	return conf.Logger.MaxBackups.Set(value)
-}
-
-func handleServiceLogMaxAgeInDaysEnv(conf *service.Config, value string) error {
-	return conf.Logger.MaxAgeInDays.Set(value)
-}
-
-func handleServiceInheritEnvironmentEnv(conf *service.Config, value string) error {
-	return conf.InheritEnvironment.Set(value)
-}
-
-func (instance *Config) handleServiceEnv(full string, serviceName string, key string, value string) error {
-	targetKey := strings.ToUpper(key)
-	if handler, ok := serviceEnvKeyToFunction[targetKey]; ok {
-		return instance.Services.Configure(serviceName, value, handler)
+	parts = strings.SplitN(key, ".", 3)
+	if len(parts) < 2 {
+		return errors.New("Illegal environment variable found: '%s'. Unknown global configuration type '%s'.", full, key)
 	}
-	return errors.New("Unknown configuration type '%s' for service '%s'.", key, serviceName)
-}
-
-func handleEnvironmentEnv(conf *service.Config, key string, value string) error {
-	return conf.Environment.Put(key, value)
-}
-
-func (instance *Config) handleServiceMapEnv(full string, serviceName string, key string, subKey string, value string) error {
-	targetKey := strings.ToUpper(key)
-	if handler, ok := serviceSubEnvKeyToFunction[targetKey]; ok {
-		return instance.Services.ConfigureSub(serviceName, subKey, value, handler)
+	serviceName := parts[0]
+	mapping, ok := serviceEnvironmentMappings.find(strings.ToUpper(parts[1]))
+	if !ok {
+		return errors.New("Unknown configuration type '%s' for service '%s'.", parts[1], serviceName)
 	}
-	return errors.New("Unknown configuration type '%s' for service '%s'.", key, serviceName)
+	return instance.Services.Configure(serviceName, value, func(conf *service.Config, value string) error {
+		return mapping.applyEnvironment(reflect.ValueOf(conf).Elem(), parts[2:], value)
+	})
 }
 
 func parseCmd(in string) []values.String {
@@ -0,0 +1,344 @@
+package caretakerd
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/panics"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type environmentMappingKind int
+
+const (
+	valueEnvironmentMapping environmentMappingKind = 0
+	listEnvironmentMapping  environmentMappingKind = 1
+	mapEnvironmentMapping   environmentMappingKind = 2
+)
+
+var setterType = reflect.TypeOf((*interface {
+	Set(value string) error
+})(nil)).Elem()
+
+var putableType = reflect.TypeOf((*Putable)(nil)).Elem()
+
+// EnvironmentMapping describes how an environment variable is mapped to a property of the configuration.
+// Every mapping is derived from the yaml names of the configuration structure. The yaml name
+// "stopSignal" becomes STOP_SIGNAL and nested properties are joined by "_", for example "access.type"
+// becomes ACCESS_TYPE.
+type EnvironmentMapping struct {
+	// Key of the environment variable without any prefix.
+	Key string
+	// Aliases contains alternative keys of the environment variable.
+	Aliases []string
+	// References contains the references (like "github.com/echocat/caretakerd/service.Config#Access")
+	// of every property on the path to the mapped property.
+	References []string
+
+	index []int
+	kind  environmentMappingKind
+}
+
+// IsList returns true if the mapped property is a list. Lists could either be extended
+// using the Key or set element by element using "<Key>.<index>".
+func (instance EnvironmentMapping) IsList() bool {
+	return instance.kind == listEnvironmentMapping
+}
+
+// IsMap returns true if the mapped property is a map. Entries of maps are set
+// using "<Key>.<name>".
+func (instance EnvironmentMapping) IsMap() bool {
+	return instance.kind == mapEnvironmentMapping
+}
+
+// EnvironmentMappings represents a list of EnvironmentMapping.
+type EnvironmentMappings []EnvironmentMapping
+
+// GlobalEnvironmentMappings returns every mapping of CTD.<key> environment variables
+// to properties of Config.
+func GlobalEnvironmentMappings() EnvironmentMappings {
+	return globalEnvironmentMappings
+}
+
+// ServiceEnvironmentMappings returns every mapping of CTD.<service>.<key> environment variables
+// to properties of service.Config.
+func ServiceEnvironmentMappings() EnvironmentMappings {
+	return serviceEnvironmentMappings
+}
+
+func (instance EnvironmentMappings) find(key string) (EnvironmentMapping, bool) {
+	for _, candidate := range instance {
+		if candidate.Key == key {
+			return candidate, true
+		}
+		for _, alias := range candidate.Aliases {
+			if alias == key {
+				return candidate, true
+			}
+		}
+	}
+	return EnvironmentMapping{}, false
+}
+
+// Finds the mapping for the given path of yaml names like ["access", "type"]. Remaining path
+// elements (like the name of a map entry) are returned as sub key.
+func (instance EnvironmentMappings) findByPath(path []string) (EnvironmentMapping, []string, bool) {
+	key := ""
+	for i, element := range path {
+		if i > 0 {
+			key += "_"
+		}
+		key += environmentKeyOf(element)
+		if mapping, ok := instance.find(key); ok {
+			if i+1 < len(path) {
+				return mapping, []string{strings.Join(path[i+1:], ".")}, true
+			}
+			return mapping, []string{}, true
+		}
+	}
+	return EnvironmentMapping{}, nil, false
+}
+
+func newEnvironmentMappingsFor(t reflect.Type, aliases map[string]string, excludedFields ...string) EnvironmentMappings {
+	result := appendEnvironmentMappingsOf(EnvironmentMappings{}, t, "", []int{}, []string{}, excludedFields)
+	known := map[string]bool{}
+	for _, mapping := range result {
+		if known[mapping.Key] {
+			panics.New("Environment key '%s' of %v is not unique.", mapping.Key, t).Throw()
+		}
+		known[mapping.Key] = true
+	}
+	for alias, key := range aliases {
+		if known[alias] {
+			panics.New("Environment key alias '%s' of %v is already used as key.", alias, t).Throw()
+		}
+		found := false
+		for i, mapping := range result {
+			if mapping.Key == key {
+				result[i].Aliases = append(result[i].Aliases, alias)
+				found = true
+			}
+		}
+		if !found {
+			panics.New("Environment key alias '%s' of %v points to unknown key '%s'.", alias, t, key).Throw()
+		}
+	}
+	for _, mapping := range result {
+		sort.Strings(mapping.Aliases)
+	}
+	return result
+}
+
+func appendEnvironmentMappingsOf(to EnvironmentMappings, t reflect.Type, keyPrefix string, index []int, references []string, excludedFields []string) EnvironmentMappings {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlNameOf(field)
+		if len(field.PkgPath) > 0 || name == "-" || containsString(excludedFields, field.Name) {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldReferences := append(append([]string{}, references...), t.PkgPath()+"."+t.Name()+"#"+field.Name)
+		key := keyPrefix + environmentKeyOf(name)
+		mapping := EnvironmentMapping{
+			Key:        key,
+			Aliases:    []string{},
+			References: fieldReferences,
+			index:      fieldIndex,
+		}
+		switch {
+		case field.Type.Kind() == reflect.Map:
+			mapping.kind = mapEnvironmentMapping
+		case isSettable(field.Type):
+			mapping.kind = valueEnvironmentMapping
+		case field.Type.Kind() == reflect.Slice:
+			mapping.kind = listEnvironmentMapping
+		case field.Type.Kind() == reflect.Struct:
+			to = appendEnvironmentMappingsOf(to, field.Type, key+"_", fieldIndex, fieldReferences, []string{})
+			continue
+		default:
+			panics.New("Could not map %v.%s of type %v to an environment variable.", t, field.Name, field.Type).Throw()
+		}
+		to = append(to, mapping)
+	}
+	return to
+}
+
+// Applies the given value of an environment variable. In contrast to apply the elements of lists
+// are appended to the existing ones.
+func (instance EnvironmentMapping) applyEnvironment(root reflect.Value, subKeys []string, value string) error {
+	if instance.kind == listEnvironmentMapping && len(subKeys) == 0 {
+		target := root.FieldByIndex(instance.index)
+		list, err := newListFrom(target.Type(), value)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.AppendSlice(target, list))
+		return nil
+	}
+	return instance.apply(root, subKeys, value)
+}
+
+func (instance EnvironmentMapping) apply(root reflect.Value, subKeys []string, value string) error {
+	target := root.FieldByIndex(instance.index)
+	switch instance.kind {
+	case listEnvironmentMapping:
+		if len(subKeys) == 0 {
+			list, err := newListFrom(target.Type(), value)
+			if err != nil {
+				return err
+			}
+			target.Set(list)
+			return nil
+		}
+		return setListElement(target, subKeys[0], value)
+	case mapEnvironmentMapping:
+		if len(subKeys) == 0 && isSettable(target.Type()) {
+			return setValue(target, value)
+		} else if len(subKeys) == 0 || len(subKeys[0]) == 0 {
+			return errors.New("Configuration type '%s' requires a key in format %s.<name>.", instance.Key, instance.Key)
+		}
+		return putMapEntry(target, subKeys[0], value)
+	}
+	if len(subKeys) > 0 {
+		return errors.New("Configuration type '%s' does not support sub keys but got '%s'.", instance.Key, subKeys[0])
+	}
+	return setValue(target, value)
+}
+
+func setListElement(target reflect.Value, plainIndex string, value string) error {
+	index, err := strconv.Atoi(plainIndex)
+	if err != nil || index < 0 || index > target.Len() {
+		return errors.New("Illegal list index '%s'. Expected a number between 0 and %d.", plainIndex, target.Len())
+	}
+	element := reflect.New(target.Type().Elem()).Elem()
+	if element.Kind() == reflect.Slice && !isSettable(element.Type()) {
+		list, err := newListFrom(element.Type(), value)
+		if err != nil {
+			return err
+		}
+		element.Set(list)
+	} else if err := setValue(element, value); err != nil {
+		return err
+	}
+	if index == target.Len() {
+		target.Set(reflect.Append(target, element))
+	} else {
+		target.Index(index).Set(element)
+	}
+	return nil
+}
+
+func newListFrom(listType reflect.Type, value string) (reflect.Value, error) {
+	result := reflect.MakeSlice(listType, 0, 0)
+	elementType := listType.Elem()
+	if elementType.Kind() == reflect.Slice && !isSettable(elementType) {
+		element, err := newListFrom(elementType, value)
+		if err != nil {
+			return result, err
+		}
+		return reflect.Append(result, element), nil
+	}
+	for _, plain := range parseCmd(value) {
+		element := reflect.New(elementType).Elem()
+		if err := setValue(element, plain.String()); err != nil {
+			return result, err
+		}
+		result = reflect.Append(result, element)
+	}
+	return result, nil
+}
+
+func putMapEntry(target reflect.Value, key string, value string) error {
+	if target.IsNil() {
+		target.Set(reflect.MakeMap(target.Type()))
+	}
+	if target.Addr().Type().Implements(putableType) {
+		return target.Addr().Interface().(Putable).Put(key, value)
+	}
+	element := reflect.New(target.Type().Elem()).Elem()
+	if err := setValue(element, value); err != nil {
+		return err
+	}
+	target.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), element)
+	return nil
+}
+
+func setValue(target reflect.Value, value string) error {
+	if target.Addr().Type().Implements(setterType) {
+		return target.Addr().Interface().(interface {
+			Set(value string) error
+		}).Set(value)
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+		return nil
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("Illegal boolean value: %s", value)
+		}
+		target.SetBool(parsed)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return errors.New("Illegal integer value: %s", value)
+		}
+		target.SetInt(parsed)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return errors.New("Illegal integer value: %s", value)
+		}
+		target.SetUint(parsed)
+		return nil
+	}
+	return errors.New("Could not set value of type %v.", target.Type())
+}
+
+func isSettable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(setterType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func yamlNameOf(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0]
+	if len(name) == 0 {
+		return field.Name
+	}
+	return name
+}
+
+// Transforms a yaml name like "maxSizeInMb" into an environment key like MAX_SIZE_IN_MB.
+func environmentKeyOf(name string) string {
+	result := new(strings.Builder)
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(runes[i-1]) {
+			result.WriteByte('_')
+		}
+		result.WriteRune(unicode.ToUpper(r))
+	}
+	return result.String()
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, candidate := range haystack {
+		if candidate == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package caretakerd
 
 import (
+	"github.com/echocat/caretakerd/service"
 	. "github.com/echocat/caretakerd/values"
 	. "gopkg.in/check.v1"
 )
@@ -19,3 +20,111 @@ func (s *ConfigEnvironmentTest) TestParseCmd(c *C) {
 	c.Assert(parseCmd("\\\\"), DeepEquals, []String{"\\"})
 	c.Assert(parseCmd("v1=\"a \" \"v2= b\""), DeepEquals, []String{"v1=a ", "v2= b"})
 }
+
+func (s *ConfigEnvironmentTest) TestHandleGlobalEnvironmentEnv(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.handleUnknownEnv("CTD.ENVIRONMENT.FOO=bar", "CTD.ENVIRONMENT.FOO", "bar"), IsNil)
+	c.Assert(conf.handleUnknownEnv("CTD.ENV.A.B=c", "CTD.ENV.A.B", "c"), IsNil)
+	c.Assert(conf.Environment["FOO"], Equals, "bar")
+	c.Assert(conf.Environment["A.B"], Equals, "c")
+}
+
+func (s *ConfigEnvironmentTest) TestHandleServiceEnvironmentFilesEnv(c *C) {
+	conf := NewConfigFor("linux")
+	conf.Services["foo"] = service.NewConfig()
+	c.Assert(conf.handleUnknownEnv("CTD.foo.ENVIRONMENT_FILES=a.env -b.env", "CTD.foo.ENVIRONMENT_FILES", "a.env -b.env"), IsNil)
+	c.Assert(conf.Services["foo"].EnvironmentFiles, DeepEquals, []String{"a.env", "-b.env"})
+}
+
+func (s *ConfigEnvironmentTest) TestEnvironmentKeyOf(c *C) {
+	c.Assert(environmentKeyOf("type"), Equals, "TYPE")
+	c.Assert(environmentKeyOf("stopSignal"), Equals, "STOP_SIGNAL")
+	c.Assert(environmentKeyOf("maxSizeInMb"), Equals, "MAX_SIZE_IN_MB")
+	c.Assert(environmentKeyOf("keyStore"), Equals, "KEY_STORE")
+}
+
+func (s *ConfigEnvironmentTest) TestHandleServiceEnvOfDerivedKeys(c *C) {
+	conf := NewConfigFor("linux")
+	conf.Services["foo"] = service.NewConfig()
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.CRON_EXPRESSION", "0 0 * * * *"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.stop_signal", "KILL"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.ACCESS_TYPE", "generateToEnvironment"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.LOGGER_MAX_BACKUPS", "3"), IsNil)
+	c.Assert(conf.Services["foo"].CronExpression.String(), Equals, "0 0 * * * *")
+	c.Assert(conf.Services["foo"].StopSignal, Equals, KILL)
+	c.Assert(conf.Services["foo"].Access.Type.String(), Equals, "generateToEnvironment")
+	c.Assert(conf.Services["foo"].Logger.MaxBackups, Equals, NonNegativeInteger(3))
+}
+
+func (s *ConfigEnvironmentTest) TestHandleServiceEnvOfLists(c *C) {
+	conf := NewConfigFor("linux")
+	foo := service.NewConfig()
+	foo.Command = []String{"echo"}
+	conf.Services["foo"] = foo
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.COMMAND", "\"Hello world\""), IsNil)
+	c.Assert(conf.Services["foo"].Command, DeepEquals, []String{"echo", "Hello world"})
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.CMD", "and bye"), IsNil)
+	c.Assert(conf.Services["foo"].Command, DeepEquals, []String{"echo", "Hello world", "and", "bye"})
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.CMD.1", "Bye"), IsNil)
+	c.Assert(conf.Services["foo"].Command, DeepEquals, []String{"echo", "Bye", "and", "bye"})
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.COMMAND.4", "world"), IsNil)
+	c.Assert(conf.Services["foo"].Command, DeepEquals, []String{"echo", "Bye", "and", "bye", "world"})
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.COMMAND.6", "x"), ErrorMatches, "Illegal list index '6'. Expected a number between 0 and 5.")
+
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.PRE_COMMANDS", "echo a"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.PRE_COMMANDS.1", "-echo b"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.PRE_COMMANDS", "echo c"), IsNil)
+	c.Assert(conf.Services["foo"].PreCommands, DeepEquals, [][]String{{"echo", "a"}, {"-echo", "b"}, {"echo", "c"}})
+
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.INHERIT_ENV_INCLUDE", "A B"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.INHERIT_ENVIRONMENT_INCLUDE", "C"), IsNil)
+	c.Assert(conf.Services["foo"].InheritEnvironmentInclude, DeepEquals, []String{"A", "B", "C"})
+}
+
+func (s *ConfigEnvironmentTest) TestHandleGlobalEnvOfDerivedKeys(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.handleUnknownEnv("", "CTD.RPC_ENABLED", "true"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.RPC_LISTEN", "tcp://127.0.0.1:1234"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.KEY_STORE_TYPE", "generated"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.CONTROL_ACCESS_PERMISSION", "readOnly"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.LOG_LEVEL", "debug"), IsNil)
+	c.Assert(conf.RPC.Enabled, Equals, Boolean(true))
+	c.Assert(conf.RPC.Listen.String(), Equals, "tcp://127.0.0.1:1234")
+	c.Assert(conf.KeyStore.Type.String(), Equals, "generated")
+	c.Assert(conf.Control.Access.Permission.String(), Equals, "readOnly")
+	c.Assert(conf.Logger.Level.String(), Equals, "debug")
+}
+
+func (s *ConfigEnvironmentTest) TestHandleGlobalEnvOfRPCListeners(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.handleUnknownEnv("", "CTD.RPC_LISTENERS", "unix:///run/caretakerd.sock tcp://[::1]:1234"), IsNil)
+	c.Assert(conf.handleUnknownEnv("", "CTD.RPC_LISTENERS.2", "fd://3"), IsNil)
+	c.Assert(conf.RPC.Listeners, HasLen, 3)
+	c.Assert(conf.RPC.Listeners[0].Listen.String(), Equals, "unix:///run/caretakerd.sock")
+	c.Assert(conf.RPC.Listeners[1].Listen.String(), Equals, "tcp://[::1]:1234")
+	c.Assert(conf.RPC.Listeners[2].Listen.String(), Equals, "fd://3")
+	c.Assert(conf.RPC.Listeners[2].SocketPermission.String(), Equals, "0600")
+	c.Assert(conf.RPC.AllListeners(), HasLen, 4)
+}
+
+func (s *ConfigEnvironmentTest) TestHandleUnknownKeys(c *C) {
+	conf := NewConfigFor("linux")
+	conf.Services["foo"] = service.NewConfig()
+	c.Assert(conf.handleUnknownEnv("CTD.FOO=bar", "CTD.FOO", "bar"), ErrorMatches, "Illegal environment variable found: 'CTD.FOO=bar'. Unknown global configuration type 'FOO'.")
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.UNKNOWN", "bar"), ErrorMatches, "Unknown configuration type 'UNKNOWN' for service 'foo'.")
+	c.Assert(conf.handleUnknownEnv("", "CTD.bar.COMMAND", "bar"), ErrorMatches, "There does no service with name 'bar' exist.")
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.USER.x", "bar"), ErrorMatches, "Configuration type 'USER' does not support sub keys but got 'x'.")
+	c.Assert(conf.handleUnknownEnv("", "CTD.foo.ENVIRONMENT.", "bar"), ErrorMatches, "Configuration type 'ENVIRONMENT' requires a key in format ENVIRONMENT.<name>.")
+}
+
+func (s *ConfigEnvironmentTest) TestEnvironmentMappingsCoverEveryProperty(c *C) {
+	for _, mapping := range ServiceEnvironmentMappings() {
+		c.Assert(mapping.References, Not(HasLen), 0)
+	}
+	_, ok := ServiceEnvironmentMappings().find("SECCOMP_PROFILE")
+	c.Assert(ok, Equals, true)
+	_, ok = GlobalEnvironmentMappings().find("SERVICES")
+	c.Assert(ok, Equals, false)
+	_, ok = GlobalEnvironmentMappings().find("INCLUDE")
+	c.Assert(ok, Equals, false)
+}
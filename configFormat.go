@@ -0,0 +1,211 @@
+package caretakerd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/echocat/caretakerd/errors"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConfigFormat represents a format configuration files could be written in.
+type ConfigFormat int
+
+const (
+	// @id yaml
+	//
+	// YAML format. Files with extension ``.yaml`` or ``.yml``.
+	YAML ConfigFormat = 0
+	// @id json
+	//
+	// JSON format. Files with extension ``.json``.
+	JSON ConfigFormat = 1
+	// @id toml
+	//
+	// TOML format. Files with extension ``.toml``.
+	TOML ConfigFormat = 2
+)
+
+// AllConfigFormats contains all possible variants of ConfigFormat.
+var AllConfigFormats = []ConfigFormat{
+	YAML,
+	JSON,
+	TOML,
+}
+
+var tomlKeyValuePattern = regexp.MustCompile(`^[A-Za-z0-9_\-."']+\s*=`)
+
+func (instance ConfigFormat) String() string {
+	result, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CheckedString is like String but also returns an optional error if there are any
+// validation errors.
+func (instance ConfigFormat) CheckedString() (string, error) {
+	switch instance {
+	case YAML:
+		return "yaml", nil
+	case JSON:
+		return "json", nil
+	case TOML:
+		return "toml", nil
+	}
+	return "", errors.New("Illegal config format: %d", instance)
+}
+
+// Set sets the given string to current object from a string.
+// Returns an error object if there are any problems while transforming the string.
+func (instance *ConfigFormat) Set(value string) error {
+	if valueAsInt, err := strconv.Atoi(value); err == nil {
+		for _, candidate := range AllConfigFormats {
+			if int(candidate) == valueAsInt {
+				*instance = candidate
+				return nil
+			}
+		}
+		return fmt.Errorf("illegal config format: %v", value)
+	}
+	lowerValue := strings.ToLower(value)
+	for _, candidate := range AllConfigFormats {
+		if candidate.String() == lowerValue {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal config format: %v", value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance ConfigFormat) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
+
+// ConfigFormatForFileName returns the format of a config file by its extension.
+// Returns false if the extension is not known.
+func ConfigFormatForFileName(fileName string) (ConfigFormat, bool) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return YAML, true
+	case ".json":
+		return JSON, true
+	case ".toml":
+		return TOML, true
+	}
+	return YAML, false
+}
+
+// DetectConfigFormat returns the format of a config file. The format is detected by the
+// extension of the given fileName or - if the extension is not known - by the given content.
+func DetectConfigFormat(fileName string, content []byte) ConfigFormat {
+	if format, ok := ConfigFormatForFileName(fileName); ok {
+		return format
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return JSON
+		}
+		if (strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")) || tomlKeyValuePattern.MatchString(line) {
+			return TOML
+		}
+		return YAML
+	}
+	return YAML
+}
+
+// Decodes the given content into a yaml document node. This allows to handle every
+// format in the same way as YAML.
+func (instance ConfigFormat) decode(content []byte) (*yaml.Node, error) {
+	var node yaml.Node
+	if instance == YAML {
+		if err := yaml.Unmarshal(content, &node); err != nil {
+			return nil, err
+		}
+		return &node, nil
+	}
+	var plain map[string]interface{}
+	switch instance {
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&plain); err != nil {
+			return nil, err
+		}
+		normalizeJSONNumbersOf(plain)
+	case TOML:
+		if err := toml.Unmarshal(content, &plain); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, instance.Validate()
+	}
+	if plain == nil {
+		return &yaml.Node{Kind: yaml.DocumentNode}, nil
+	}
+	if err := node.Encode(plain); err != nil {
+		return nil, err
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}, nil
+}
+
+// Encodes the given value into this format. Every format is derived from the YAML
+// representation of the value to ensure the same structure in every format.
+func (instance ConfigFormat) encode(what interface{}) ([]byte, error) {
+	content, err := yaml.Marshal(what)
+	if err != nil || instance == YAML {
+		return content, err
+	}
+	var plain map[string]interface{}
+	if err := yaml.Unmarshal(content, &plain); err != nil {
+		return nil, err
+	}
+	switch instance {
+	case JSON:
+		return json.MarshalIndent(plain, "", "    ")
+	case TOML:
+		buf := new(bytes.Buffer)
+		if err := toml.NewEncoder(buf).Encode(plain); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, instance.Validate()
+}
+
+// Replaces every json.Number by an int64 or float64 because yaml would handle them as strings.
+func normalizeJSONNumbersOf(what interface{}) interface{} {
+	switch value := what.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = normalizeJSONNumbersOf(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = normalizeJSONNumbersOf(child)
+		}
+	}
+	return what
+}
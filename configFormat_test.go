@@ -0,0 +1,223 @@
+package caretakerd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/service"
+	"github.com/echocat/caretakerd/values"
+)
+
+type ConfigFormatTest struct{}
+
+func init() {
+	Suite(&ConfigFormatTest{})
+}
+
+const testYamlConfig = `
+rpc:
+    enabled: true
+    listen: "tcp://127.0.0.1:1234"
+logger:
+    level: debug
+environment:
+    GLOBAL: "1"
+services:
+    king:
+        type: master
+        command: ["echo", "${MESSAGE}"]
+        cronExpression: "0 0 * * * *"
+        successExitCodes: [0, 12]
+        stopSignal: int
+        stopSignalTarget: mixed
+        stopWaitInSeconds: 10
+        environment:
+            MESSAGE: "Hello world!"
+        autoRestart: always
+        access:
+            type: generateToEnvironment
+            permission: readWrite
+        logger:
+            level: warning
+            pattern: "%m%n"
+    queen:
+        type: onDemand
+        command: ["sleep", "1"]
+`
+
+const testJsonConfig = `{
+    "rpc": {"enabled": true, "listen": "tcp://127.0.0.1:1234"},
+    "logger": {"level": "debug"},
+    "environment": {"GLOBAL": "1"},
+    "services": {
+        "king": {
+            "type": "master",
+            "command": ["echo", "${MESSAGE}"],
+            "cronExpression": "0 0 * * * *",
+            "successExitCodes": [0, 12],
+            "stopSignal": "int",
+            "stopSignalTarget": "mixed",
+            "stopWaitInSeconds": 10,
+            "environment": {"MESSAGE": "Hello world!"},
+            "autoRestart": "always",
+            "access": {"type": "generateToEnvironment", "permission": "readWrite"},
+            "logger": {"level": "warning", "pattern": "%m%n"}
+        },
+        "queen": {
+            "type": "onDemand",
+            "command": ["sleep", "1"]
+        }
+    }
+}`
+
+const testTomlConfig = `
+[rpc]
+enabled = true
+listen = "tcp://127.0.0.1:1234"
+
+[logger]
+level = "debug"
+
+[environment]
+GLOBAL = "1"
+
+[services.king]
+type = "master"
+command = ["echo", "${MESSAGE}"]
+cronExpression = "0 0 * * * *"
+successExitCodes = [0, 12]
+stopSignal = "int"
+stopSignalTarget = "mixed"
+stopWaitInSeconds = 10
+autoRestart = "always"
+environment = { MESSAGE = "Hello world!" }
+
+[services.king.access]
+type = "generateToEnvironment"
+permission = "readWrite"
+
+[services.king.logger]
+level = "warning"
+pattern = "%m%n"
+
+[services.queen]
+type = "onDemand"
+command = ["sleep", "1"]
+`
+
+func (s *ConfigFormatTest) expectedConfig(source values.String) Config {
+	result := NewConfigFor("linux")
+	result.Source = source
+	if source != "" {
+		result.includedFiles = map[string]bool{absolutePathOf(source.String()): true}
+	}
+	result.RPC.Enabled = values.Boolean(true)
+	_ = result.RPC.Listen.Set("tcp://127.0.0.1:1234")
+	result.Logger.Level = logger.Debug
+	result.Environment = service.Environments{"GLOBAL": "1"}
+
+	king := service.NewConfig().WithCommand("echo", "${MESSAGE}")
+	king.Source = source
+	king.Type = service.Master
+	_ = king.CronExpression.Set("0 0 * * * *")
+	king.SuccessExitCodes = values.ExitCodes{0, 12}
+	king.StopSignal = values.INT
+	king.StopSignalTarget = values.Mixed
+	king.StopWaitInSeconds = values.NonNegativeInteger(10)
+	king.Environment = service.Environments{"MESSAGE": "Hello world!"}
+	king.AutoRestart = values.Always
+	king.Access = access.NewGenerateToEnvironmentConfig(access.ReadWrite)
+	king.Logger.Level = logger.Warning
+	king.Logger.Pattern = logger.Pattern("%m%n")
+
+	queen := service.NewConfig().WithCommand("sleep", "1")
+	queen.Source = source
+	queen.Type = service.OnDemand
+
+	result.Services = service.Configs{"king": king, "queen": queen}
+	return result
+}
+
+func (s *ConfigFormatTest) writeFile(c *C, name string, content []byte) values.String {
+	fileName := filepath.Join(c.MkDir(), name)
+	c.Assert(os.WriteFile(fileName, content, 0600), IsNil)
+	return values.String(fileName)
+}
+
+func (s *ConfigFormatTest) TestAllFormatsProduceTheSameConfig(c *C) {
+	for name, content := range map[string]string{
+		"caretakerd.yaml": testYamlConfig,
+		"caretakerd.json": testJsonConfig,
+		"caretakerd.toml": testTomlConfig,
+		"yaml.conf":       testYamlConfig,
+		"json.conf":       testJsonConfig,
+		"toml.conf":       testTomlConfig,
+	} {
+		fileName := s.writeFile(c, name, []byte(content))
+		actual, err := LoadFromFile("linux", fileName)
+		c.Assert(err, IsNil, Commentf("%s", name))
+		c.Assert(actual, DeepEquals, s.expectedConfig(fileName), Commentf("%s", name))
+	}
+}
+
+func (s *ConfigFormatTest) TestRoundTrip(c *C) {
+	for _, format := range AllConfigFormats {
+		content, err := s.expectedConfig("").Marshal(format)
+		c.Assert(err, IsNil, Commentf("%v", format))
+		fileName := s.writeFile(c, "caretakerd."+format.String(), content)
+
+		actual, err := LoadFromFile("linux", fileName)
+		c.Assert(err, IsNil, Commentf("%v:\n%s", format, content))
+		c.Assert(actual, DeepEquals, s.expectedConfig(fileName), Commentf("%v:\n%s", format, content))
+	}
+}
+
+func (s *ConfigFormatTest) TestWriteToFile(c *C) {
+	fileName := values.String(filepath.Join(c.MkDir(), "caretakerd.toml"))
+	c.Assert(s.expectedConfig("").WriteToFile(fileName), IsNil)
+
+	actual, err := LoadFromFile("linux", fileName)
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, s.expectedConfig(fileName))
+}
+
+func (s *ConfigFormatTest) TestJsonMarshalIsSymmetric(c *C) {
+	expected := s.expectedConfig("")
+	content, err := json.Marshal(expected)
+	c.Assert(err, IsNil)
+
+	actual := NewConfigFor("linux")
+	c.Assert(json.Unmarshal(content, &actual), IsNil)
+	c.Assert(actual, DeepEquals, expected)
+}
+
+func (s *ConfigFormatTest) TestMarshalJsonMatchesJsonRepresentation(c *C) {
+	expected := s.expectedConfig("")
+	content, err := expected.Marshal(JSON)
+	c.Assert(err, IsNil)
+	plainExpected, err := json.Marshal(expected.Services["king"])
+	c.Assert(err, IsNil)
+
+	var actual, actualExpected map[string]interface{}
+	c.Assert(json.Unmarshal(content, &actual), IsNil)
+	c.Assert(json.Unmarshal(plainExpected, &actualExpected), IsNil)
+	king := actual["services"].(map[string]interface{})["king"].(map[string]interface{})
+	c.Assert(king["successExitCodes"], DeepEquals, []interface{}{float64(0), float64(12)})
+	c.Assert(king["successExitCodes"], DeepEquals, actualExpected["successExitCodes"])
+}
+
+func (s *ConfigFormatTest) TestDetectConfigFormat(c *C) {
+	c.Assert(DetectConfigFormat("foo.yml", []byte("{}")), Equals, YAML)
+	c.Assert(DetectConfigFormat("foo.JSON", []byte("")), Equals, JSON)
+	c.Assert(DetectConfigFormat("foo.toml", []byte("")), Equals, TOML)
+	c.Assert(DetectConfigFormat("foo", []byte("# comment\n\n  {\"a\": 1}")), Equals, JSON)
+	c.Assert(DetectConfigFormat("foo", []byte("# comment\n[services.a]\n")), Equals, TOML)
+	c.Assert(DetectConfigFormat("foo", []byte("include = [\"a.toml\"]\n")), Equals, TOML)
+	c.Assert(DetectConfigFormat("foo", []byte("services:\n    a: {}\n")), Equals, YAML)
+	c.Assert(DetectConfigFormat("foo", []byte("")), Equals, YAML)
+}
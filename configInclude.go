@@ -0,0 +1,112 @@
+package caretakerd
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Top level properties which are allowed inside of included config files.
+var allowedIncludedProperties = map[string]bool{
+	"include":  true,
+	"services": true,
+}
+
+// IncludeDirectory loads every config file (*.yaml, *.yml, *.json and *.toml) of the given directory
+// in alphabetical order and merges its services into this instance. Files that were already loaded into
+// this instance - like the source or its includes - are skipped.
+func (instance *Config) IncludeDirectory(platform string, directory values.String) error {
+	fileNames := []string{}
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json", "*.toml"} {
+		matches, err := filepath.Glob(filepath.Join(directory.String(), pattern))
+		if err != nil {
+			return errors.New("Could not list config files of directory '%v'.", directory).CausedBy(err)
+		}
+		fileNames = append(fileNames, matches...)
+	}
+	sort.Strings(fileNames)
+	if instance.includedFiles == nil {
+		instance.includedFiles = map[string]bool{}
+	}
+	if !instance.Source.IsTrimmedEmpty() {
+		instance.includedFiles[absolutePathOf(instance.Source.String())] = true
+	}
+	for _, fileName := range fileNames {
+		if err := instance.includeFile(platform, values.String(fileName), instance.includedFiles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (instance *Config) resolveIncludes(platform string, fileName values.String, visited map[string]bool) error {
+	visited[absolutePathOf(fileName.String())] = true
+	directory := filepath.Dir(fileName.String())
+	for _, include := range instance.Include {
+		pattern := include.String()
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(directory, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return errors.New("Illegal include '%v' in '%v'.", include, fileName).CausedBy(err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return errors.New("Included config '%v' of '%v' does not exist.", include, fileName)
+		}
+		for _, match := range matches {
+			if err := instance.includeFile(platform, values.String(match), visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (instance *Config) includeFile(platform string, fileName values.String, visited map[string]bool) error {
+	if visited[absolutePathOf(fileName.String())] {
+		return nil
+	}
+	node, err := loadNodeFrom(fileName, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkIncludedYamlNode(node, fileName); err != nil {
+		return err
+	}
+	fragment := NewConfigFor(platform)
+	if len(node.Content) > 0 {
+		if err := node.Decode(&fragment); err != nil {
+			return errors.New("Could not unmarshal config from '%v'.", fileName).CausedBy(err)
+		}
+	}
+	fragment.Source = fileName
+	fragment.Services.SetSource(fileName)
+	if err := fragment.resolveIncludes(platform, fileName, visited); err != nil {
+		return err
+	}
+	return instance.Services.Merge(fragment.Services)
+}
+
+func checkIncludedYamlNode(node *yaml.Node, fileName values.String) error {
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	content := node.Content[0].Content
+	for i := 0; i < len(content); i += 2 {
+		if !allowedIncludedProperties[content[i].Value] {
+			return errors.New("Included config '%v' contains property '%s'. Only 'services' and 'include' are allowed.", fileName, content[i].Value)
+		}
+	}
+	return nil
+}
+
+func absolutePathOf(fileName string) string {
+	if result, err := filepath.Abs(fileName); err == nil {
+		return result
+	}
+	return fileName
+}
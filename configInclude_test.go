@@ -0,0 +1,151 @@
+package caretakerd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type ConfigIncludeTest struct{}
+
+func init() {
+	Suite(&ConfigIncludeTest{})
+}
+
+func (s *ConfigIncludeTest) writeFile(c *C, fileName string, content string) string {
+	c.Assert(os.MkdirAll(filepath.Dir(fileName), 0700), IsNil)
+	c.Assert(os.WriteFile(fileName, []byte(content), 0600), IsNil)
+	return fileName
+}
+
+func (s *ConfigIncludeTest) TestLoadFromYamlFileWithIncludes(c *C) {
+	dir := c.MkDir()
+	main := s.writeFile(c, filepath.Join(dir, "caretakerd.yaml"), `
+include: ["services/*.yaml", "extra.yaml"]
+services:
+    king:
+        type: master
+        command: ["echo", "king"]
+`)
+	a := s.writeFile(c, filepath.Join(dir, "services", "a.yaml"), `
+services:
+    a:
+        command: ["echo", "a"]
+`)
+	b := s.writeFile(c, filepath.Join(dir, "services", "b.yaml"), `
+include: ["../extra.yaml"]
+services:
+    b:
+        command: ["echo", "b"]
+`)
+	extra := s.writeFile(c, filepath.Join(dir, "extra.yaml"), `
+services:
+    extra:
+        command: ["echo", "extra"]
+`)
+
+	actual, err := LoadFromYamlFile("linux", values.String(main))
+	c.Assert(err, IsNil)
+	c.Assert(actual.Source, Equals, values.String(main))
+	c.Assert(actual.Services, HasLen, 4)
+	c.Assert(actual.Services["king"].Source, Equals, values.String(main))
+	c.Assert(actual.Services["a"].Source, Equals, values.String(a))
+	c.Assert(actual.Services["b"].Source, Equals, values.String(b))
+	c.Assert(actual.Services["extra"].Source, Equals, values.String(extra))
+}
+
+func (s *ConfigIncludeTest) TestLoadFromYamlFileWithDuplicateService(c *C) {
+	dir := c.MkDir()
+	main := s.writeFile(c, filepath.Join(dir, "caretakerd.yaml"), `
+include: ["other.yaml"]
+services:
+    king:
+        type: master
+        command: ["echo", "king"]
+`)
+	s.writeFile(c, filepath.Join(dir, "other.yaml"), `
+services:
+    king:
+        command: ["echo", "other"]
+`)
+
+	_, err := LoadFromYamlFile("linux", values.String(main))
+	c.Assert(err, ErrorMatches, "Service 'king' is defined in '.*caretakerd.yaml' and '.*other.yaml'.")
+}
+
+func (s *ConfigIncludeTest) TestLoadFromYamlFileWithMissingInclude(c *C) {
+	dir := c.MkDir()
+	main := s.writeFile(c, filepath.Join(dir, "caretakerd.yaml"), `
+include: ["missing.yaml", "missing/*.yaml"]
+`)
+
+	_, err := LoadFromYamlFile("linux", values.String(main))
+	c.Assert(err, ErrorMatches, "Included config 'missing.yaml' of '.*caretakerd.yaml' does not exist.")
+}
+
+func (s *ConfigIncludeTest) TestLoadFromYamlFileWithIllegalPropertyInInclude(c *C) {
+	dir := c.MkDir()
+	main := s.writeFile(c, filepath.Join(dir, "caretakerd.yaml"), `
+include: ["other.yaml"]
+`)
+	s.writeFile(c, filepath.Join(dir, "other.yaml"), `
+rpc:
+    enabled: true
+`)
+
+	_, err := LoadFromYamlFile("linux", values.String(main))
+	c.Assert(err, ErrorMatches, "Included config '.*other.yaml' contains property 'rpc'. Only 'services' and 'include' are allowed.")
+}
+
+func (s *ConfigIncludeTest) TestIncludeDirectory(c *C) {
+	dir := c.MkDir()
+	s.writeFile(c, filepath.Join(dir, "b.yml"), `
+services:
+    b:
+        command: ["echo", "b"]
+`)
+	s.writeFile(c, filepath.Join(dir, "a.yaml"), `
+services:
+    a:
+        type: master
+        command: ["echo", "a"]
+`)
+	s.writeFile(c, filepath.Join(dir, "ignored.txt"), `foo`)
+
+	actual := NewConfigFor("linux")
+	c.Assert(actual.IncludeDirectory("linux", values.String(dir)), IsNil)
+	c.Assert(actual.Services, HasLen, 2)
+	c.Assert(actual.Services["a"].Source, Equals, values.String(filepath.Join(dir, "a.yaml")))
+	c.Assert(actual.ValidateMaster(), IsNil)
+}
+
+func (s *ConfigIncludeTest) TestIncludeDirectorySkipsIncludedFiles(c *C) {
+	dir := c.MkDir()
+	main := s.writeFile(c, filepath.Join(dir, "caretakerd.yaml"), `
+include: ["conf.d/worker.yaml"]
+services:
+    main:
+        type: master
+        command: ["echo", "main"]
+`)
+	s.writeFile(c, filepath.Join(dir, "conf.d", "worker.yaml"), `
+services:
+    worker:
+        command: ["echo", "worker"]
+`)
+	s.writeFile(c, filepath.Join(dir, "conf.d", "other.yaml"), `
+services:
+    other:
+        command: ["echo", "other"]
+`)
+
+	actual, err := LoadFromYamlFile("linux", values.String(main))
+	c.Assert(err, IsNil)
+	c.Assert(actual.IncludeDirectory("linux", values.String(filepath.Join(dir, "conf.d"))), IsNil)
+	c.Assert(actual.Services, HasLen, 3)
+	c.Assert(actual.Services["worker"].Source, Equals, values.String(filepath.Join(dir, "conf.d", "worker.yaml")))
+	c.Assert(actual.Validate(), IsNil)
+}
@@ -0,0 +1,60 @@
+package caretakerd
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// Properties of services which are not interpolated while loading the config because they are
+// evaluated before every execution using the environment of the service itself.
+var servicePropertiesEvaluatedOnExecution = map[string]bool{
+	"command":          true,
+	"preCommands":      true,
+	"postCommands":     true,
+	"stopCommand":      true,
+	"readinessCommand": true,
+	"directory":        true,
+}
+
+func interpolateYamlNode(node *yaml.Node, path []string) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolateYamlNode(child, path); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := interpolateYamlNode(node.Content[i+1], append(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if isEvaluatedOnExecution(path) {
+			return nil
+		}
+		value, err := values.Expand(node.Value, os.LookupEnv)
+		if err != nil {
+			if node.Line <= 0 {
+				return errors.New("Could not interpolate '%s'.", strings.Join(path, ".")).CausedBy(err)
+			}
+			return errors.New("Could not interpolate '%s' (line %d).", strings.Join(path, "."), node.Line).CausedBy(err)
+		}
+		if value != node.Value {
+			node.Value = value
+			if node.Style == 0 {
+				// Force a new resolution of the tag because the type of the value could have changed.
+				node.Tag = ""
+			}
+		}
+	}
+	return nil
+}
+
+func isEvaluatedOnExecution(path []string) bool {
+	return len(path) >= 3 && path[0] == "services" && servicePropertiesEvaluatedOnExecution[path[2]]
+}
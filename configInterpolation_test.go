@@ -0,0 +1,99 @@
+package caretakerd
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type ConfigInterpolationTest struct{}
+
+func init() {
+	Suite(&ConfigInterpolationTest{})
+}
+
+func (s *ConfigInterpolationTest) writeConfig(c *C, content string) values.String {
+	fileName := filepath.Join(c.MkDir(), "caretakerd.yaml")
+	c.Assert(os.WriteFile(fileName, []byte(content), 0600), IsNil)
+	return values.String(fileName)
+}
+
+func (s *ConfigInterpolationTest) TestLoadFromYamlFileInterpolates(c *C) {
+	os.Setenv("CTD_TEST_USER", "king")
+	defer os.Unsetenv("CTD_TEST_USER")
+	fileName := s.writeConfig(c, `
+rpc:
+    enabled: ${CTD_TEST_RPC_ENABLED:-true}
+    listen: "tcp://${CTD_TEST_HOST:-127.0.0.1}:1234"
+logger:
+    filename: "$${CTD_TEST_LOG}"
+services:
+    king:
+        type: master
+        command: ["echo", "${MESSAGE:-Hello}"]
+        directory: "${HOME}"
+        user: ${CTD_TEST_USER}
+        stopWaitInSeconds: ${CTD_TEST_STOP_WAIT:-10}
+`)
+
+	actual, err := LoadFromYamlFile("linux", fileName)
+	c.Assert(err, IsNil)
+	c.Assert(actual.RPC.Enabled, Equals, values.Boolean(true))
+	c.Assert(actual.RPC.Listen.String(), Equals, "tcp://127.0.0.1:1234")
+	c.Assert(actual.Logger.Filename, Equals, values.String("${CTD_TEST_LOG}"))
+	c.Assert(actual.Services["king"].Command, DeepEquals, []values.String{"echo", "${MESSAGE:-Hello}"})
+	c.Assert(actual.Services["king"].Directory, Equals, values.String("${HOME}"))
+	c.Assert(actual.Services["king"].User, Equals, values.String("king"))
+	c.Assert(actual.Services["king"].StopWaitInSeconds, Equals, values.NonNegativeInteger(10))
+}
+
+func (s *ConfigInterpolationTest) TestLoadFromYamlFileFailsOnMissingRequiredVariable(c *C) {
+	fileName := s.writeConfig(c, `
+services:
+    king:
+        type: master
+        command: ["echo"]
+        user: "${CTD_TEST_MISSING:?The user is required.}"
+`)
+
+	_, err := LoadFromYamlFile("linux", fileName)
+	c.Assert(err, ErrorMatches, "(?s)Could not interpolate config from .*Could not interpolate 'services.king.user' \\(line 6\\).*Required variable 'CTD_TEST_MISSING' is not set: The user is required..*")
+}
+
+func (s *ConfigInterpolationTest) TestLoadFromYamlFileInterpolatesEnvironmentValues(c *C) {
+	os.Unsetenv("CTD_TEST_UNSET")
+	fileName := s.writeConfig(c, `
+services:
+    king:
+        type: master
+        command: ["echo"]
+        environment:
+            ESCAPED: "very$$ecret"
+            UNSET: "very$CTD_TEST_UNSET"
+`)
+
+	actual, err := LoadFromYamlFile("linux", fileName)
+	c.Assert(err, IsNil)
+	c.Assert(actual.Services["king"].Environment["ESCAPED"], Equals, "very$ecret")
+	c.Assert(actual.Services["king"].Environment["UNSET"], Equals, "very")
+}
+
+func (s *ConfigInterpolationTest) TestLoadFromYamlFileDoesNotInterpolateReadinessCommand(c *C) {
+	os.Unsetenv("CTD_TEST_HEALTH_URL")
+	fileName := s.writeConfig(c, `
+services:
+    king:
+        type: master
+        command: ["my-app"]
+        readinessCommand: ["curl", "-sf", "${CTD_TEST_HEALTH_URL}"]
+        environment:
+            CTD_TEST_HEALTH_URL: "http://localhost/health"
+`)
+
+	actual, err := LoadFromYamlFile("linux", fileName)
+	c.Assert(err, IsNil)
+	c.Assert(actual.Services["king"].ReadinessCommand, DeepEquals, []values.String{"curl", "-sf", "${CTD_TEST_HEALTH_URL}"})
+}
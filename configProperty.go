@@ -0,0 +1,65 @@
+package caretakerd
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/service"
+	"reflect"
+	"strings"
+)
+
+// SetProperty sets the property of this config which is addressed by the given path to the given value.
+// The path consists of the yaml names of the properties like "rpc.enabled" or "services.web.stopSignal".
+// Elements of lists and entries of maps are addressed like with environment variables:
+// "services.web.preCommands.0" or "services.web.environment.FOO". In contrast to environment variables
+// lists are replaced completely.
+// Services that do not exist are created.
+func (instance *Config) SetProperty(path string, value string) error {
+	segments := strings.Split(path, ".")
+	if segments[0] == "services" {
+		if len(segments) < 3 || len(segments[1]) == 0 {
+			return errors.New("Illegal property '%s'. Expected format services.<service>.<property>.", path)
+		}
+		serviceName := segments[1]
+		mapping, subKeys, ok := serviceEnvironmentMappings.findByPath(segments[2:])
+		if !ok {
+			return errors.New("Unknown property '%s'.", path)
+		}
+		if _, exists := instance.Services[serviceName]; !exists {
+			instance.Services[serviceName] = service.NewConfig()
+		}
+		return instance.Services.Configure(serviceName, value, func(conf *service.Config, value string) error {
+			return mapping.apply(reflect.ValueOf(conf).Elem(), subKeys, value)
+		})
+	}
+	mapping, subKeys, ok := globalEnvironmentMappings.findByPath(segments)
+	if !ok {
+		return errors.New("Unknown property '%s'.", path)
+	}
+	return mapping.apply(reflect.ValueOf(instance).Elem(), subKeys, value)
+}
+
+// DefineService creates a service from the given definition in format "<name>=<command>". The command
+// is split like {@ref github.com/echocat/caretakerd/service.Config#Command} environment variables.
+// If the service already exists only its command is replaced. If there is no master defined
+// the service becomes the master.
+func (instance *Config) DefineService(definition string) error {
+	parts := strings.SplitN(definition, "=", 2)
+	if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+		return errors.New("Illegal service definition '%s'. Expected format <name>=<command>.", definition)
+	}
+	name := strings.TrimSpace(parts[0])
+	command := parseCmd(parts[1])
+	if len(command) == 0 {
+		return errors.New("Illegal service definition '%s'. There is no command provided.", definition)
+	}
+	conf, exists := instance.Services[name]
+	if !exists {
+		conf = service.NewConfig()
+	}
+	conf.Command = command
+	if _, hasMaster := instance.Services.GetMasterName(); !hasMaster {
+		conf.Type = service.Master
+	}
+	instance.Services[name] = conf
+	return nil
+}
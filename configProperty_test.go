@@ -0,0 +1,81 @@
+package caretakerd
+
+import (
+	"github.com/echocat/caretakerd/service"
+	. "github.com/echocat/caretakerd/values"
+	. "gopkg.in/check.v1"
+)
+
+type ConfigPropertyTest struct{}
+
+func init() {
+	Suite(&ConfigPropertyTest{})
+}
+
+func (s *ConfigPropertyTest) TestSetProperty(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.SetProperty("rpc.enabled", "true"), IsNil)
+	c.Assert(conf.SetProperty("control.access.permission", "readOnly"), IsNil)
+	c.Assert(conf.SetProperty("environment.FOO", "bar"), IsNil)
+	c.Assert(conf.SetProperty("services.web.stopSignal", "KILL"), IsNil)
+	c.Assert(conf.SetProperty("services.web.command", "nginx -g \"daemon off;\""), IsNil)
+	c.Assert(conf.SetProperty("services.web.preCommands.0", "echo a"), IsNil)
+	c.Assert(conf.SetProperty("services.web.environment.A.B", "c"), IsNil)
+	c.Assert(conf.SetProperty("services.web.logger.maxBackups", "2"), IsNil)
+
+	c.Assert(conf.RPC.Enabled, Equals, Boolean(true))
+	c.Assert(conf.Control.Access.Permission.String(), Equals, "readOnly")
+	c.Assert(conf.Environment["FOO"], Equals, "bar")
+	c.Assert(conf.Services["web"].StopSignal, Equals, KILL)
+	c.Assert(conf.Services["web"].Command, DeepEquals, []String{"nginx", "-g", "daemon off;"})
+	c.Assert(conf.Services["web"].PreCommands, DeepEquals, [][]String{{"echo", "a"}})
+	c.Assert(conf.Services["web"].Environment["A.B"], Equals, "c")
+	c.Assert(conf.Services["web"].Logger.MaxBackups, Equals, NonNegativeInteger(2))
+
+	// In contrast to environment variables lists are replaced completely.
+	c.Assert(conf.SetProperty("services.web.command", "httpd"), IsNil)
+	c.Assert(conf.Services["web"].Command, DeepEquals, []String{"httpd"})
+}
+
+func (s *ConfigPropertyTest) TestSetPropertyFails(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.SetProperty("unknown", "x"), ErrorMatches, "Unknown property 'unknown'.")
+	c.Assert(conf.SetProperty("services.web", "x"), ErrorMatches, "Illegal property 'services.web'. Expected format services.<service>.<property>.")
+	c.Assert(conf.SetProperty("services.web.unknown", "x"), ErrorMatches, "Unknown property 'services.web.unknown'.")
+	c.Assert(conf.Services, HasLen, 0)
+	c.Assert(conf.SetProperty("services.web.stopSignal", "FOO"), NotNil)
+}
+
+func (s *ConfigPropertyTest) TestDefineService(c *C) {
+	conf := NewConfigFor("linux")
+	c.Assert(conf.DefineService("web=nginx -g \"daemon off;\""), IsNil)
+	c.Assert(conf.DefineService("cron=sleep 10"), IsNil)
+	c.Assert(conf.Services["web"].Type, Equals, service.Master)
+	c.Assert(conf.Services["web"].Command, DeepEquals, []String{"nginx", "-g", "daemon off;"})
+	c.Assert(conf.Services["cron"].Type, Equals, service.AutoStart)
+	c.Assert(conf.Services["cron"].Command, DeepEquals, []String{"sleep", "10"})
+
+	c.Assert(conf.DefineService("web"), ErrorMatches, "Illegal service definition 'web'. Expected format <name>=<command>.")
+	c.Assert(conf.DefineService("=foo"), ErrorMatches, "Illegal service definition '=foo'. Expected format <name>=<command>.")
+	c.Assert(conf.DefineService("web="), ErrorMatches, "Illegal service definition 'web='. There is no command provided.")
+}
+
+func (s *ConfigPropertyTest) TestEnrichFromCreatingServices(c *C) {
+	environ := []string{
+		"HOME=/root",
+		"CTD.LOG_LEVEL=debug",
+		"CTD.ENVIRONMENT.FOO=bar",
+		"CTD.web.COMMAND=nginx",
+		"CTD.web.TYPE=master",
+		"CTD.web.ENV.A=b",
+	}
+	conf := NewConfigFor("linux").enrichFrom(environ, true)
+	c.Assert(conf.Services, HasLen, 1)
+	c.Assert(conf.Services["web"].Command, DeepEquals, []String{"nginx"})
+	c.Assert(conf.Services["web"].Type, Equals, service.Master)
+	c.Assert(conf.Services["web"].Environment["A"], Equals, "b")
+	c.Assert(conf.Environment["FOO"], Equals, "bar")
+	c.Assert(conf.ValidateMaster(), IsNil)
+
+	c.Assert(func() { NewConfigFor("linux").enrichFrom(environ, false) }, PanicMatches, "(?s).*There does no service with name 'web' exist.*")
+}
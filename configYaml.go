@@ -28,26 +28,85 @@ func IsConfigNotExists(err error) bool {
 	}
 }
 
+// LoadFromFile loads the caretakerd config from the given file. The format of the file is
+// detected by its extension or its content (see DetectConfigFormat).
+// Every value - except the commands and directories of services which are evaluated
+// before every execution - is interpolated using the environment variables of caretakerd.
+// See values.Expand for the supported syntax.
+//
+// Every file referenced by Include is loaded, too, and its services are merged into the result.
+func LoadFromFile(platform string, fileName values.String) (Config, error) {
+	return loadFromFile(platform, fileName, nil)
+}
+
 // LoadFromYamlFile loads the caretakerd config from the given yaml file.
+// For details see LoadFromFile.
 func LoadFromYamlFile(platform string, fileName values.String) (Config, error) {
+	format := YAML
+	return loadFromFile(platform, fileName, &format)
+}
+
+func loadFromFile(platform string, fileName values.String, format *ConfigFormat) (Config, error) {
 	result := NewConfigFor(platform)
+	node, err := loadNodeFrom(fileName, format)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(node.Content) > 0 {
+		if err := node.Decode(&result); err != nil {
+			return Config{}, errors.New("Could not unmarshal config from '%v'.", fileName).CausedBy(err)
+		}
+	}
+	result.Source = fileName
+	result.Services.SetSource(fileName)
+	result.includedFiles = map[string]bool{}
+	if err := result.resolveIncludes(platform, fileName, result.includedFiles); err != nil {
+		return Config{}, err
+	}
+	return result, nil
+}
+
+func loadNodeFrom(fileName values.String, format *ConfigFormat) (*yaml.Node, error) {
 	content, err := os.ReadFile(fileName.String())
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Config{}, ConfigDoesNotExistError{fileName: fileName.String()}
+			return nil, ConfigDoesNotExistError{fileName: fileName.String()}
 		}
-		return Config{}, errors.New("Could not read config from '%v'.", fileName).CausedBy(err)
+		return nil, errors.New("Could not read config from '%v'.", fileName).CausedBy(err)
 	}
-	if err := yaml.Unmarshal(content, &result); err != nil {
-		return Config{}, errors.New("Could not unmarshal config from '%v'.", fileName).CausedBy(err)
+	if format == nil {
+		detected := DetectConfigFormat(fileName.String(), content)
+		format = &detected
 	}
-	result.Source = fileName
-	return result, nil
+	node, err := format.decode(content)
+	if err != nil {
+		return nil, errors.New("Could not unmarshal config from '%v'.", fileName).CausedBy(err)
+	}
+	if err := interpolateYamlNode(node, []string{}); err != nil {
+		return nil, errors.New("Could not interpolate config from '%v'.", fileName).CausedBy(err)
+	}
+	return node, nil
+}
+
+// Marshal marshals the config of the current instance in the given format.
+func (instance Config) Marshal(format ConfigFormat) ([]byte, error) {
+	return format.encode(instance)
+}
+
+// WriteToFile writes the config of the current instance to the given file. The format is
+// chosen by the extension of the file. If the extension is not known YAML is used.
+func (instance Config) WriteToFile(fileName values.String) error {
+	format, _ := ConfigFormatForFileName(fileName.String())
+	return instance.writeToFile(fileName, format)
 }
 
 // WriteToYamlFile writes the config of the current instance to the given yaml file.
 func (instance Config) WriteToYamlFile(fileName values.String) error {
-	content, err := yaml.Marshal(instance)
+	return instance.writeToFile(fileName, YAML)
+}
+
+func (instance Config) writeToFile(fileName values.String, format ConfigFormat) error {
+	content, err := instance.Marshal(format)
 	if err != nil {
 		return errors.New("Could not write config to '%v'.", fileName).CausedBy(err)
 	}
@@ -0,0 +1,56 @@
+package caretakerd
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/service"
+	usync "github.com/echocat/caretakerd/sync"
+	"github.com/echocat/caretakerd/values"
+)
+
+type ConfigTest struct{}
+
+func init() {
+	Suite(&ConfigTest{})
+}
+
+func (s *ConfigTest) TestValidateRoleBindings(c *C) {
+	conf := NewConfigFor("linux")
+	conf.RPC.Roles = access.Roles{
+		"restartWorkers": {Verbs: []access.Verb{access.Restart}},
+	}
+	worker := service.NewConfig().WithCommand("sleep", "1")
+	worker.Type = service.Master
+	worker.Access = access.NewPeerCredentialsConfig(access.Forbidden, values.String("nobody"))
+	worker.Access.Roles = []values.String{"restartWorkers"}
+	conf.Services["worker"] = worker
+	c.Assert(conf.Validate(), IsNil)
+
+	worker.Access.Roles = []values.String{"unknown"}
+	conf.Services["worker"] = worker
+	c.Assert(conf.Validate(), ErrorMatches, "Role 'unknown' of service 'worker' does not exist.")
+
+	conf.Control.Access.Roles = []values.String{"unknown"}
+	c.Assert(conf.Validate(), ErrorMatches, "Role 'unknown' of control does not exist.")
+}
+
+func (s *ConfigTest) TestKeyStoreIsRequiredForGeneratedCertificatesWithoutRPC(c *C) {
+	conf := NewConfigFor("linux")
+	conf.RPC.Enabled = false
+	master := service.NewConfig().WithCommand("sleep", "1")
+	master.Type = service.Master
+	conf.Services["master"] = master
+	c.Assert(conf.isKeyStoreRequired(), Equals, false)
+
+	master.Access = access.NewGenerateToEnvironmentConfig(access.ReadOnly)
+	conf.Services["master"] = master
+	c.Assert(conf.isKeyStoreRequired(), Equals, true)
+
+	instance, err := NewCaretakerd(&conf, usync.NewGroup())
+	c.Assert(err, IsNil)
+	defer instance.Close()
+	c.Assert(instance.KeyStore().IsEnabled(), Equals, true)
+	c.Assert(instance.Services().Get("master").Access().Certificate(), NotNil)
+	c.Assert(instance.Control().Access().Type(), Equals, access.None)
+}
@@ -1,6 +1,7 @@
 package control
 
 import (
+	"encoding/json"
 	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/defaults"
 	"runtime"
@@ -36,6 +37,14 @@ func (instance *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return unmarshal((*noMethods)(instance))
 }
 
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Config) UnmarshalJSON(b []byte) error {
+	instance.init(runtime.GOOS)
+
+	type noMethods Config
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
+
 // Validate validates the action on this object and returns an error object if there are any.
 func (instance Config) Validate() error {
 	return instance.Access.Validate()
@@ -159,13 +159,14 @@ func (instance *Execution) recreateExecution(target *service.Execution) (*servic
 }
 
 func (instance *Execution) checkAfterExecutionStates(target *service.Execution, exitCode values.ExitCode, err error) (doRestart bool, respectDelay bool) {
-	if _, ok := err.(service.StoppedOrKilledError); ok {
+	if instance.checkRestartRequestedAndClean(target.Service()) {
+		// Hint: This has to be checked first because a restart stops the execution before.
+		doRestart = true
+		respectDelay = false
+	} else if _, ok := err.(service.StoppedOrKilledError); ok {
 		doRestart = false
 	} else if _, ok := err.(service.UnrecoverableError); ok {
 		doRestart = target.Service().Config().CronExpression.IsEnabled() && instance.masterExitCode == nil
-	} else if instance.checkRestartRequestedAndClean(target.Service()) {
-		doRestart = true
-		respectDelay = false
 	} else if target.Service().Config().SuccessExitCodes.Contains(exitCode) {
 		doRestart = (target.Service().Config().CronExpression.IsEnabled() && instance.masterExitCode == nil) || target.Service().Config().AutoRestart.OnSuccess()
 	} else {
@@ -362,6 +363,8 @@ func (instance *Execution) doRUnlock() {
 // GetFor queries the current active service execution for the given service.
 // Returns "false" if no current execution matches.
 func (instance *Execution) GetFor(s *service.Service) (*service.Execution, bool) {
+	instance.doRLock()
+	defer instance.doRUnlock()
 	result, ok := instance.executions[s]
 	return result, ok
 }
@@ -0,0 +1,69 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package caretakerd
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/service"
+	usync "github.com/echocat/caretakerd/sync"
+	"github.com/echocat/caretakerd/values"
+	"time"
+)
+
+type ExecutionTest struct{}
+
+func init() {
+	Suite(&ExecutionTest{})
+}
+
+func (s *ExecutionTest) waitForRunningPID(c *C, execution *Execution, target *service.Service, otherThan values.Integer) values.Integer {
+	for i := 0; i < 500; i++ {
+		information := execution.InformationFor(target)
+		if information.Status == service.Running && information.PID > 0 && information.PID != otherThan {
+			return information.PID
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatalf("Service '%v' was not started.", target)
+	return 0
+}
+
+func (s *ExecutionTest) TestRestartOfRunningService(c *C) {
+	conf := NewConfigFor("linux")
+	conf.RPC.Enabled = false
+	master := service.NewConfig().WithCommand("sleep", "60")
+	master.Type = service.Master
+	conf.Services["master"] = master
+	instance, err := NewCaretakerd(&conf, usync.NewGroup())
+	c.Assert(err, IsNil)
+	defer instance.Close()
+	target := instance.Services().Get("master")
+	c.Assert(target, NotNil)
+
+	execution := NewExecution(instance)
+	done := make(chan error, 1)
+	go func() {
+		_, err := execution.Run()
+		done <- err
+	}()
+	firstPID := s.waitForRunningPID(c, execution, target, 0)
+
+	// A restart stops the execution first. This must not be treated as a regular stop of the service.
+	c.Assert(execution.Restart(target), IsNil)
+	secondPID := s.waitForRunningPID(c, execution, target, firstPID)
+	c.Assert(secondPID, Not(Equals), firstPID)
+	select {
+	case err := <-done:
+		c.Fatalf("Execution ended after restart: %v", err)
+	default:
+	}
+
+	execution.StopAll()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		c.Fatal("Execution did not end after stop.")
+	}
+}
@@ -1,6 +1,7 @@
 package keyStore
 
 import (
+	"crypto/elliptic"
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/values"
 	"strconv"
@@ -8,10 +9,16 @@ import (
 )
 
 var defaults = map[string]interface{}{
-	"Type":    Generated,
-	"PemFile": values.String(""),
-	"Hints":   values.String("algorithm:`rsa` bits:`1024`"),
-	"CaFile":  values.String(""),
+	"Type":                Generated,
+	"PemFile":             values.String(""),
+	"Hints":               values.String("algorithm:`ecdsa` curve:`P-256`"),
+	"CaFile":              values.String(""),
+	"StateDirectory":      values.String(""),
+	"RevocationFile":      values.String(""),
+	"CaValidity":          values.Duration("8760h"),
+	"CertificateValidity": values.Duration("8760h"),
+	"WarnBefore":          values.Duration("720h"),
+	"RenewBefore":         values.Duration("168h"),
 }
 
 // # Description
@@ -27,25 +34,77 @@ type Config struct {
 	//
 	// Defines the pemFile which contains the key and certificate to be used.
 	// This has to be of type PEM and has to contain the certificate and private key.
-	// Currently only private keys of type RSA are supported.
+	// Private keys of type RSA (``RSA PRIVATE KEY``), ECDSA (``EC PRIVATE KEY``) and
+	// RSA, ECDSA or Ed25519 in PKCS #8 format (``PRIVATE KEY``) are supported.
 	//
 	// This property is only evaluated and required if {@ref #Type type} is set to
 	// {@ref .Type#FromFile fromFile}.
 	PemFile values.String `json:"pemFile,omitempty" yaml:"pemFile"`
 
-	// @default "algorithm:`rsa` bits:`1024`"
+	// @default "algorithm:`ecdsa` curve:`P-256`"
 	//
 	// Defines some hints, for example to store in the format ``[<key:`value`>...]``.
 	// Possible hints are:
 	//
-	// * ``algorithm``: Algorithm to be used to create new keys. Currently only ``rsa`` is supported.
-	// * ``bits``: Number of bits to create a new key with.
+	// * ``algorithm``: Algorithm to be used to create new keys. Possible values are ``ecdsa``, ``ed25519`` and ``rsa``.
+	// * ``curve``: Curve to create a new ``ecdsa`` key with. Possible values are ``P-256`` and ``P-384``.
+	// * ``bits``: Number of bits to create a new ``rsa`` key with. Default and minimum is ``2048``.
 	Hints values.String `json:"hints,omitempty" yaml:"hints"`
 
 	// @default ""
 	//
 	// File where trusted certificates are stored in. This has to be in PEM format.
 	CaFile values.String `json:"caFile,omitempty" yaml:"caFile"`
+
+	// @default ""
+	//
+	// If set, the generated certificate of caretakerd is stored in this directory and used again on the next start.
+	// Certificates that were issued with it - like generated {@ref github.com/echocat/caretakerd/access.Config#PemFile pem files} -
+	// stay valid after a restart of caretakerd.
+	// Otherwise a new certificate is generated on every start.
+	//
+	// This property is only evaluated if {@ref #Type type} is set to {@ref .Type#Generated generated}.
+	StateDirectory values.String `json:"stateDirectory,omitempty" yaml:"stateDirectory,omitempty"`
+
+	// @default ""
+	//
+	// File where revoked certificates are stored in. Certificates are revoked using
+	// ``caretakerctl revoke <serial|name>``. Revoked certificates are no longer trusted.
+	//
+	// If empty and {@ref #StateDirectory stateDirectory} is set, the file ``revocations.json`` in this directory
+	// is used. Otherwise revocations are lost after a restart of caretakerd.
+	RevocationFile values.String `json:"revocationFile,omitempty" yaml:"revocationFile,omitempty"`
+
+	// @default "8760h"
+	//
+	// Validity period of the generated certificate of caretakerd.
+	//
+	// This property is only evaluated if {@ref #Type type} is set to {@ref .Type#Generated generated}.
+	CaValidity values.Duration `json:"caValidity" yaml:"caValidity"`
+
+	// @default "8760h"
+	//
+	// Validity period of certificates that are generated for services and caretakerctl.
+	// They never outlive the certificate of caretakerd.
+	CertificateValidity values.Duration `json:"certificateValidity" yaml:"certificateValidity"`
+
+	// @default "720h"
+	//
+	// A warning is logged if the certificate of caretakerd or a generated certificate expires within this period.
+	// ``0`` disables these warnings.
+	WarnBefore values.Duration `json:"warnBefore" yaml:"warnBefore"`
+
+	// @default "168h"
+	//
+	// The certificate of caretakerd (only if {@ref #Type type} is set to {@ref .Type#Generated generated})
+	// and generated certificates are renewed automatically if they expire within this period.
+	// ``0`` disables the automatic renewal.
+	//
+	// If the certificate of caretakerd is renewed, every generated certificate is renewed too.
+	// Generated {@ref github.com/echocat/caretakerd/access.Config#PemFile pem files} are rewritten. Services
+	// that got their certificate from the environment will only receive the new one if they are started again -
+	// see {@ref github.com/echocat/caretakerd/service.Config#RestartOnCertificateRenewal restartOnCertificateRenewal}.
+	RenewBefore values.Duration `json:"renewBefore" yaml:"renewBefore"`
 }
 
 // NewConfig creates a new instance of Config.
@@ -64,15 +123,86 @@ func (instance Config) Validate() error {
 	if err == nil {
 		err = instance.validateStringOnlyAllowedValue(instance.CaFile, "caFile", instance.Type.IsConsumingCAFile)
 	}
+	if err == nil && !instance.Type.IsGenerating() && !instance.StateDirectory.IsEmpty() {
+		err = errors.New("There is no stateDirectory allowed for type %v.", instance.Type)
+	}
+	if err == nil {
+		err = instance.validateDurations()
+	}
 	if err == nil {
-		algorithm := instance.GetHintsArgument("algorithm")
-		if len(algorithm) > 0 && strings.ToLower(algorithm) != "rsa" {
-			err = errors.New("Unsupported algorithm: %s", algorithm)
+		switch instance.algorithm() {
+		case "rsa":
+			_, err = instance.rsaBits()
+		case "ecdsa":
+			_, err = instance.ecdsaCurve()
+		case "ed25519":
+		default:
+			err = errors.New("Unsupported algorithm: %s", instance.GetHintsArgument("algorithm"))
 		}
 	}
 	return err
 }
 
+func (instance Config) validateDurations() error {
+	for _, duration := range []values.Duration{instance.CaValidity, instance.CertificateValidity, instance.WarnBefore, instance.RenewBefore} {
+		if err := duration.Validate(); err != nil {
+			return err
+		}
+	}
+	if instance.CaValidity.Duration() <= 0 {
+		return errors.New("The caValidity has to be greater than 0.")
+	}
+	if instance.CertificateValidity.Duration() <= 0 {
+		return errors.New("The certificateValidity has to be greater than 0.")
+	}
+	renewBefore := instance.RenewBefore.Duration()
+	if renewBefore >= instance.CertificateValidity.Duration() || (instance.Type.IsGenerating() && renewBefore >= instance.CaValidity.Duration()) {
+		// Otherwise every renewed certificate would have to be renewed immediately again.
+		return errors.New("The renewBefore (%v) has to be less than the caValidity (%v) and the certificateValidity (%v).", instance.RenewBefore, instance.CaValidity, instance.CertificateValidity)
+	}
+	return nil
+}
+
+func (instance Config) algorithm() string {
+	algorithm := strings.ToLower(instance.GetHintsArgument("algorithm"))
+	if len(algorithm) == 0 && len(instance.GetHintsArgument("bits")) > 0 {
+		// Only RSA keys have bits. This keeps configurations working that were written when RSA was the only algorithm.
+		return "rsa"
+	} else if len(algorithm) == 0 {
+		return "ecdsa"
+	}
+	return algorithm
+}
+
+// Smallest size of rsa keys that is still considered as secure.
+const minimumRsaBits = 2048
+
+func (instance Config) rsaBits() (int, error) {
+	plainBits := instance.GetHintsArgument("bits")
+	if len(plainBits) == 0 {
+		return 2048, nil
+	}
+	bits, err := strconv.Atoi(plainBits)
+	if err != nil || bits <= 0 {
+		return 0, errors.New("Unsupported algorithm bits: %s", plainBits)
+	}
+	if bits < minimumRsaBits {
+		return 0, errors.New("Insecure algorithm bits: %d. At least %d bits are required for rsa keys.", bits, minimumRsaBits)
+	}
+	return bits, nil
+}
+
+func (instance Config) ecdsaCurve() (elliptic.Curve, error) {
+	plainCurve := instance.GetHintsArgument("curve")
+	switch strings.ToUpper(strings.Replace(plainCurve, "-", "", 1)) {
+	case "", "P256":
+		return elliptic.P256(), nil
+	case "P384":
+		return elliptic.P384(), nil
+	}
+	return nil, errors.New("Unsupported algorithm curve: %s", plainCurve)
+}
+
 func (instance Config) validateRequireStringOrNotValue(value values.String, fieldName string, isAllowedMethod func() bool) error {
 	if isAllowedMethod() {
 		if value.IsEmpty() {
@@ -0,0 +1,68 @@
+package keyStore
+
+import (
+	. "gopkg.in/check.v1"
+
+	"crypto/elliptic"
+	"github.com/echocat/caretakerd/values"
+)
+
+type ConfigTest struct{}
+
+func init() {
+	Suite(&ConfigTest{})
+}
+
+func configWithHints(hints string) Config {
+	result := NewConfig()
+	result.Type = Generated
+	result.Hints = values.String(hints)
+	return result
+}
+
+func (s *ConfigTest) TestAlgorithm(c *C) {
+	c.Assert(configWithHints("").algorithm(), Equals, "ecdsa")
+	c.Assert(configWithHints("curve:`P-384`").algorithm(), Equals, "ecdsa")
+	c.Assert(configWithHints("algorithm:`Ed25519`").algorithm(), Equals, "ed25519")
+	c.Assert(configWithHints("algorithm:`ecdsa` bits:`4096`").algorithm(), Equals, "ecdsa")
+	c.Assert(configWithHints("bits:`4096`").algorithm(), Equals, "rsa")
+}
+
+func (s *ConfigTest) TestRsaBits(c *C) {
+	bits, err := configWithHints("algorithm:`rsa`").rsaBits()
+	c.Assert(err, IsNil)
+	c.Assert(bits, Equals, 2048)
+
+	bits, err = configWithHints("bits:`4096`").rsaBits()
+	c.Assert(err, IsNil)
+	c.Assert(bits, Equals, 4096)
+
+	_, err = configWithHints("bits:`foo`").rsaBits()
+	c.Assert(err, ErrorMatches, "Unsupported algorithm bits: foo")
+	_, err = configWithHints("bits:`-1`").rsaBits()
+	c.Assert(err, ErrorMatches, "Unsupported algorithm bits: -1")
+	_, err = configWithHints("bits:`2047`").rsaBits()
+	c.Assert(err, ErrorMatches, "Insecure algorithm bits: 2047. At least 2048 bits are required for rsa keys.")
+}
+
+func (s *ConfigTest) TestEcdsaCurve(c *C) {
+	curve, err := configWithHints("").ecdsaCurve()
+	c.Assert(err, IsNil)
+	c.Assert(curve, Equals, elliptic.P256())
+
+	curve, err = configWithHints("curve:`p-384`").ecdsaCurve()
+	c.Assert(err, IsNil)
+	c.Assert(curve, Equals, elliptic.P384())
+
+	_, err = configWithHints("curve:`P-521`").ecdsaCurve()
+	c.Assert(err, ErrorMatches, "Unsupported algorithm curve: P-521")
+}
+
+func (s *ConfigTest) TestValidateHints(c *C) {
+	for _, hints := range []string{"", "algorithm:`ecdsa` curve:`P-384`", "algorithm:`ed25519`", "algorithm:`rsa`", "bits:`2048`"} {
+		c.Assert(configWithHints(hints).Validate(), IsNil, Commentf("hints: %s", hints))
+	}
+	c.Assert(configWithHints("bits:`512`").Validate(), ErrorMatches, "Insecure algorithm bits: 512.*")
+	c.Assert(configWithHints("algorithm:`rsa` bits:`1024`").Validate(), ErrorMatches, "Insecure algorithm bits: 1024.*")
+	c.Assert(configWithHints("algorithm:`dsa`").Validate(), ErrorMatches, "Unsupported algorithm: dsa")
+}
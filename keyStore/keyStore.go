@@ -1,6 +1,8 @@
 package keyStore
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -10,8 +12,8 @@ import (
 	"github.com/echocat/caretakerd/panics"
 	"math/big"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,6 +25,14 @@ type KeyStore struct {
 	ca         []*x509.Certificate
 	cert       *x509.Certificate
 	privateKey interface{}
+	lock       *sync.RWMutex
+
+	revocations []Revocation
+	changed     chan struct{}
+
+	// Certificates per common name that were issued in the current second.
+	issued     map[string][]*x509.Certificate
+	issuedLock *sync.Mutex
 }
 
 // NewKeyStore create an new instance of KeyStore.
@@ -33,44 +43,95 @@ func NewKeyStore(enabled bool, conf Config) (*KeyStore, error) {
 	}
 	if !enabled {
 		return &KeyStore{
-			enabled: false,
-			config:  conf,
+			enabled:    false,
+			config:     conf,
+			lock:       new(sync.RWMutex),
+			changed:    make(chan struct{}),
+			issuedLock: new(sync.Mutex),
 		}, nil
 	}
+	var result *KeyStore
 	switch conf.Type {
 	case FromFile:
-		return newFomFile(conf)
+		result, err = newFomFile(conf)
 	case FromEnvironment:
-		return newFromEnvironment(conf)
+		result, err = newFromEnvironment(conf)
 	case Generated:
-		return newGenerated(conf)
+		result, err = newGenerated(conf)
+	default:
+		return nil, errors.New("Unknown keyStore type %v.", conf.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.changed = make(chan struct{})
+	result.issuedLock = new(sync.Mutex)
+	if err := result.loadRevocations(); err != nil {
+		return nil, err
 	}
-	return nil, errors.New("Unknown keyStore type %v.", conf.Type)
+	return result, nil
 }
 
-func generatePrivateKey(conf Config) (privateKey interface{}, privateKeyBytes []byte, publicKey interface{}, err error) {
-	plainAlgorithm := conf.GetHintsArgument("algorithm")
-	if len(plainAlgorithm) > 0 && strings.ToLower(plainAlgorithm) != "rsa" {
-		return nil, []byte{}, nil, errors.New("Unsupported algorithm: %s", plainAlgorithm)
-	}
-	bits := 1024
-	plainBits := conf.GetHintsArgument("bits")
-	if len(plainBits) > 0 {
-		if bits, err = strconv.Atoi(plainBits); err != nil || bits <= 0 {
-			return nil, []byte{}, nil, errors.New("Unsupported algorithm bits: %s", plainBits)
+func generatePrivateKey(conf Config) (privateKey interface{}, privateKeyBlock *pem.Block, publicKey interface{}, err error) {
+	switch conf.algorithm() {
+	case "rsa":
+		bits, err := conf.rsaBits()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		plainPrivateKey, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, nil, nil, errors.New("Could not generate private key.").CausedBy(err)
+		}
+		return plainPrivateKey, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(plainPrivateKey)}, &plainPrivateKey.PublicKey, nil
+	case "ecdsa":
+		curve, err := conf.ecdsaCurve()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		plainPrivateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, nil, nil, errors.New("Could not generate private key.").CausedBy(err)
+		}
+		privateKeyBlock, err := marshalPKCS8PrivateKey(plainPrivateKey)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return plainPrivateKey, privateKeyBlock, &plainPrivateKey.PublicKey, nil
+	case "ed25519":
+		plainPublicKey, plainPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, nil, errors.New("Could not generate private key.").CausedBy(err)
 		}
+		privateKeyBlock, err := marshalPKCS8PrivateKey(plainPrivateKey)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return plainPrivateKey, privateKeyBlock, plainPublicKey, nil
 	}
-	plainPrivateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	return nil, nil, nil, errors.New("Unsupported algorithm: %s", conf.GetHintsArgument("algorithm"))
+}
+
+func marshalPKCS8PrivateKey(privateKey interface{}) (*pem.Block, error) {
+	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
 	if err != nil {
-		return nil, []byte{}, nil, errors.New("Could not generate private key.").CausedBy(err)
+		return nil, errors.New("Could not marshal private key.").CausedBy(err)
 	}
-	privateKeyBytes = x509.MarshalPKCS1PrivateKey(plainPrivateKey)
-	return plainPrivateKey, privateKeyBytes, &plainPrivateKey.PublicKey, nil
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}, nil
 }
 
-func generateCertificate(_ Config, privateKey interface{}, publicKey interface{}) ([]byte, error) {
+// keyUsageFor returns the key usages that are valid for the given public key.
+// Only RSA keys could be used for key encipherment.
+func keyUsageFor(publicKey interface{}, keyUsage x509.KeyUsage) x509.KeyUsage {
+	if _, ok := publicKey.(*rsa.PublicKey); ok {
+		return keyUsage | x509.KeyUsageKeyEncipherment
+	}
+	return keyUsage
+}
+
+func generateCertificate(conf Config, privateKey interface{}, publicKey interface{}) ([]byte, error) {
 	notBefore := time.Now()
-	notAfter := notBefore.Add(8760 * time.Hour)
+	notAfter := notBefore.Add(conf.CaValidity.Duration())
 
 	template := x509.Certificate{
 		SerialNumber: newSerialNumber(),
@@ -80,7 +141,7 @@ func generateCertificate(_ Config, privateKey interface{}, publicKey interface{}
 		IsCA:                  true,
 		NotBefore:             notBefore,
 		NotAfter:              notAfter,
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		KeyUsage:              keyUsageFor(publicKey, x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign|x509.KeyUsageCRLSign),
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
 		BasicConstraintsValid: true,
 	}
@@ -93,7 +154,7 @@ func generateCertificate(_ Config, privateKey interface{}, publicKey interface{}
 }
 
 func generatePem(conf Config) ([]byte, *x509.Certificate, interface{}, error) {
-	privateKey, privateKeyBytes, publicKey, err := generatePrivateKey(conf)
+	privateKey, privateKeyBlock, publicKey, err := generatePrivateKey(conf)
 	if err != nil {
 		return []byte{}, nil, nil, errors.New("Could not generate private key.").CausedBy(err)
 	}
@@ -108,7 +169,7 @@ func generatePem(conf Config) ([]byte, *x509.Certificate, interface{}, error) {
 
 	var pemBytes []byte
 	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDerBytes})...)
-	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privateKeyBytes})...)
+	pemBytes = append(pemBytes, pem.EncodeToMemory(privateKeyBlock)...)
 
 	return pemBytes, cert, privateKey, nil
 }
@@ -152,26 +213,47 @@ func newPemFromBytes(conf Config, pem []byte) (*KeyStore, error) {
 		ca:         ca,
 		cert:       certs[0],
 		privateKey: privateKey,
+		lock:       new(sync.RWMutex),
 	}, nil
 }
 
 func newGenerated(conf Config) (*KeyStore, error) {
+	result := &KeyStore{
+		enabled: true,
+		config:  conf,
+		lock:    new(sync.RWMutex),
+	}
+	if loaded, err := result.loadState(); err != nil {
+		return nil, err
+	} else if loaded {
+		return result, nil
+	}
+	if err := result.generate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (instance *KeyStore) generate() error {
+	conf := instance.config
 	p, cert, privateKey, err := generatePem(conf)
 	if err != nil {
-		return nil, errors.New("Could not generate pem for keyStore config.").CausedBy(err)
+		return errors.New("Could not generate pem for keyStore config.").CausedBy(err)
 	}
 	ca, err := buildWholeCAsBy(conf, p)
 	if err != nil {
-		return nil, errors.New("Could not build CA bundle for keyStore config.").CausedBy(err)
-	}
-	return &KeyStore{
-		enabled:    true,
-		config:     conf,
-		pem:        p,
-		ca:         ca,
-		cert:       cert,
-		privateKey: privateKey,
-	}, nil
+		return errors.New("Could not build CA bundle for keyStore config.").CausedBy(err)
+	}
+	if err := instance.storeState(p); err != nil {
+		return err
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	instance.pem = p
+	instance.ca = ca
+	instance.cert = cert
+	instance.privateKey = privateKey
+	return nil
 }
 
 func buildWholeCAsBy(conf Config, p []byte) ([]*x509.Certificate, error) {
@@ -227,8 +309,19 @@ func loadPrivateKeyFrom(p []byte) (interface{}, error) {
 		block := new(pem.Block)
 		for block != nil && len(rp) > 0 {
 			block, rp = pem.Decode(rp)
-			if block != nil && block.Type == "RSA PRIVATE KEY" {
-				privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if block != nil {
+				var privateKey interface{}
+				var err error
+				switch block.Type {
+				case "RSA PRIVATE KEY":
+					privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+				case "EC PRIVATE KEY":
+					privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+				case "PRIVATE KEY":
+					privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+				default:
+					continue
+				}
 				if err != nil {
 					return nil, errors.New("Could not parse privateKey.").CausedBy(err)
 				}
@@ -264,9 +357,12 @@ func LoadCertificateFromFile(filename string) (*x509.Certificate, error) {
 	return certificates[0], nil
 }
 
-func (instance KeyStore) generateClientCertificate(name string, publicKey interface{}, _ interface{}) ([]byte, error) {
+func (instance *KeyStore) generateClientCertificate(name string, publicKey interface{}, extensions []pkix.Extension) ([]byte, error) {
 	notBefore := time.Now()
-	notAfter := notBefore.Add(8760 * time.Hour)
+	notAfter := notBefore.Add(instance.config.CertificateValidity.Duration())
+	if notAfter.After(instance.cert.NotAfter) {
+		notAfter = instance.cert.NotAfter
+	}
 
 	template := x509.Certificate{
 		SerialNumber: newSerialNumber(),
@@ -277,9 +373,10 @@ func (instance KeyStore) generateClientCertificate(name string, publicKey interf
 		IsCA:                  true,
 		NotBefore:             notBefore,
 		NotAfter:              notAfter,
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		KeyUsage:              keyUsageFor(publicKey, x509.KeyUsageDigitalSignature),
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: false,
+		ExtraExtensions:       extensions,
 	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, instance.cert, publicKey, instance.privateKey)
@@ -291,15 +388,22 @@ func (instance KeyStore) generateClientCertificate(name string, publicKey interf
 
 // GeneratePem generates a new PEM with the config of the current KeyStore instance and returns it.
 // This PEM will be stored in the KeyStore instance.
-func (instance KeyStore) GeneratePem(name string) ([]byte, *x509.Certificate, error) {
+func (instance *KeyStore) GeneratePem(name string) ([]byte, *x509.Certificate, error) {
+	return instance.GeneratePemWithExtensions(name)
+}
+
+// GeneratePemWithExtensions is like GeneratePem but embeds the given extensions into the generated certificate.
+func (instance *KeyStore) GeneratePemWithExtensions(name string, extensions ...pkix.Extension) ([]byte, *x509.Certificate, error) {
 	if !instance.enabled {
 		return []byte{}, nil, errors.New("KeyStore is not enabled.")
 	}
-	privateKey, privateKeyBytes, publicKey, err := generatePrivateKey(instance.Config())
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
+	_, privateKeyBlock, publicKey, err := generatePrivateKey(instance.Config())
 	if err != nil {
 		return []byte{}, nil, errors.New("Could not generate pem for '%v'.", name).CausedBy(err)
 	}
-	certificateDerBytes, err := instance.generateClientCertificate(name, publicKey, privateKey)
+	certificateDerBytes, err := instance.generateClientCertificate(name, publicKey, extensions)
 	if err != nil {
 		return []byte{}, nil, err
 	}
@@ -308,43 +412,65 @@ func (instance KeyStore) GeneratePem(name string) ([]byte, *x509.Certificate, er
 	if err != nil || cert == nil {
 		return []byte{}, nil, errors.New("Wow! Could not parse right now created certificate for '%v'?", name).CausedBy(err)
 	}
+	instance.recordIssued(cert)
 
 	var pemBytes []byte
 	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDerBytes})...)
 	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: instance.cert.Raw})...)
-	pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privateKeyBytes})...)
+	pemBytes = append(pemBytes, pem.EncodeToMemory(privateKeyBlock)...)
 
 	return pemBytes, cert, nil
 }
 
 // PEM returns the contained PEM instance of this KeyStore.
 // If there is no PEM the result is empty.
-func (instance KeyStore) PEM() []byte {
+func (instance *KeyStore) PEM() []byte {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
 	return instance.pem
 }
 
 // CA returns all contained CAs of this KeyStore.
-func (instance KeyStore) CA() []*x509.Certificate {
+func (instance *KeyStore) CA() []*x509.Certificate {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
 	return instance.ca
 }
 
+// CABundle returns all contained CAs of this KeyStore in PEM format. Clients could use it to verify caretakerd.
+func (instance *KeyStore) CABundle() []byte {
+	var result []byte
+	for _, cert := range instance.CA() {
+		result = append(result, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return result
+}
+
 // Type returns the Type of this KeyStore.
-func (instance KeyStore) Type() Type {
+func (instance *KeyStore) Type() Type {
 	return instance.config.Type
 }
 
 // Config returns the Config instance this KeyStore was created with.
-func (instance KeyStore) Config() Config {
+func (instance *KeyStore) Config() Config {
 	return instance.config
 }
 
 // IsCA returns "true" if the contained certificate could be used to create new certificates.
-func (instance KeyStore) IsCA() bool {
-	cert := instance.cert
+func (instance *KeyStore) IsCA() bool {
+	cert := instance.Certificate()
 	return cert != nil && cert.IsCA
 }
 
+// Certificate returns the certificate of caretakerd.
+// If there is no certificate the result is nil.
+func (instance *KeyStore) Certificate() *x509.Certificate {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
+	return instance.cert
+}
+
 // IsEnabled returns "true" if this KeyStore is configured and usable.
-func (instance KeyStore) IsEnabled() bool {
+func (instance *KeyStore) IsEnabled() bool {
 	return instance.enabled
 }
@@ -0,0 +1,103 @@
+package keyStore
+
+import (
+	. "gopkg.in/check.v1"
+
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+)
+
+type KeyStoreTest struct{}
+
+func init() {
+	Suite(&KeyStoreTest{})
+}
+
+func (s *KeyStoreTest) TestGeneratePrivateKey(c *C) {
+	privateKey, block, publicKey, err := generatePrivateKey(configWithHints(""))
+	c.Assert(err, IsNil)
+	c.Assert(block.Type, Equals, "PRIVATE KEY")
+	c.Assert(privateKey.(*ecdsa.PrivateKey).Curve, Equals, elliptic.P256())
+	c.Assert(publicKey, DeepEquals, &privateKey.(*ecdsa.PrivateKey).PublicKey)
+
+	privateKey, block, publicKey, err = generatePrivateKey(configWithHints("algorithm:`ecdsa` curve:`P-384`"))
+	c.Assert(err, IsNil)
+	c.Assert(block.Type, Equals, "PRIVATE KEY")
+	c.Assert(privateKey.(*ecdsa.PrivateKey).Curve, Equals, elliptic.P384())
+
+	privateKey, block, publicKey, err = generatePrivateKey(configWithHints("algorithm:`ed25519`"))
+	c.Assert(err, IsNil)
+	c.Assert(block.Type, Equals, "PRIVATE KEY")
+	c.Assert(publicKey, DeepEquals, privateKey.(ed25519.PrivateKey).Public())
+
+	privateKey, block, publicKey, err = generatePrivateKey(configWithHints("bits:`2048`"))
+	c.Assert(err, IsNil)
+	c.Assert(block.Type, Equals, "RSA PRIVATE KEY")
+	c.Assert(privateKey.(*rsa.PrivateKey).N.BitLen(), Equals, 2048)
+	c.Assert(publicKey, DeepEquals, &privateKey.(*rsa.PrivateKey).PublicKey)
+
+	_, _, _, err = generatePrivateKey(configWithHints("bits:`512`"))
+	c.Assert(err, ErrorMatches, "Insecure algorithm bits: 512.*")
+}
+
+func (s *KeyStoreTest) TestGeneratedPrivateKeyCanBeLoaded(c *C) {
+	for _, hints := range []string{"algorithm:`ecdsa`", "algorithm:`ed25519`", "algorithm:`rsa`"} {
+		privateKey, block, _, err := generatePrivateKey(configWithHints(hints))
+		c.Assert(err, IsNil, Commentf("hints: %s", hints))
+		loaded, err := loadPrivateKeyFrom(pem.EncodeToMemory(block))
+		c.Assert(err, IsNil, Commentf("hints: %s", hints))
+		c.Assert(loaded, DeepEquals, privateKey, Commentf("hints: %s", hints))
+	}
+}
+
+func (s *KeyStoreTest) TestLoadPrivateKeyFromEcPrivateKey(c *C) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	plain, err := x509.MarshalECPrivateKey(privateKey)
+	c.Assert(err, IsNil)
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}})
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: plain})
+
+	loaded, err := loadPrivateKeyFrom(append(certificate, key...))
+	c.Assert(err, IsNil)
+	c.Assert(loaded, DeepEquals, privateKey)
+}
+
+func (s *KeyStoreTest) TestLoadPrivateKeyFromIllegalPem(c *C) {
+	_, err := loadPrivateKeyFrom(nil)
+	c.Assert(err, ErrorMatches, "The PEM does not contain a valid private key.")
+	_, err = loadPrivateKeyFrom(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}}))
+	c.Assert(err, ErrorMatches, "The PEM does not contain a valid private key.")
+	_, err = loadPrivateKeyFrom(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1, 2, 3}}))
+	c.Assert(err, ErrorMatches, "(?s)Could not parse privateKey.*")
+}
+
+func (s *KeyStoreTest) TestKeyUsageFor(c *C) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	ed25519PublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+
+	c.Assert(keyUsageFor(&ecdsaKey.PublicKey, x509.KeyUsageDigitalSignature), Equals, x509.KeyUsageDigitalSignature)
+	c.Assert(keyUsageFor(ed25519PublicKey, x509.KeyUsageDigitalSignature), Equals, x509.KeyUsageDigitalSignature)
+	c.Assert(keyUsageFor(&rsaKey.PublicKey, x509.KeyUsageDigitalSignature), Equals, x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment)
+}
+
+func (s *KeyStoreTest) TestGeneratedCertificateKeyUsage(c *C) {
+	for _, hints := range []string{"algorithm:`ecdsa`", "algorithm:`ed25519`", "algorithm:`rsa`"} {
+		ks, err := NewKeyStore(true, configWithHints(hints))
+		c.Assert(err, IsNil, Commentf("hints: %s", hints))
+		_, cert, err := ks.GeneratePem("foo")
+		c.Assert(err, IsNil, Commentf("hints: %s", hints))
+		c.Assert(cert.KeyUsage&x509.KeyUsageDigitalSignature, Equals, x509.KeyUsageDigitalSignature, Commentf("hints: %s", hints))
+		_, isRsa := cert.PublicKey.(*rsa.PublicKey)
+		c.Assert(cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0, Equals, isRsa, Commentf("hints: %s", hints))
+	}
+}
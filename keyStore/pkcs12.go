@@ -0,0 +1,357 @@
+package keyStore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"github.com/echocat/caretakerd/errors"
+	"unicode/utf16"
+)
+
+// Iterations of the key derivations of the encryption and the MAC of generated PKCS#12 files.
+const pkcs12Iterations = 10000
+
+var (
+	oidData                = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidX509Certificate     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyName        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidPBES2               = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC           = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSHA256              = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	asn1Null               = asn1.RawValue{Tag: asn1.TagNull}
+)
+
+type pkcs12ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type pkcs12CertBag struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+type pkcs12AlgorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs12EncryptedPrivateKeyInfo struct {
+	Algorithm     pkcs12AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pkcs12PBES2Parameters struct {
+	KeyDerivationFunc pkcs12AlgorithmIdentifier
+	EncryptionScheme  pkcs12AlgorithmIdentifier
+}
+
+type pkcs12PBKDF2Parameters struct {
+	Salt           []byte
+	IterationCount int
+	PRF            pkcs12AlgorithmIdentifier
+}
+
+type pkcs12DigestInfo struct {
+	Algorithm pkcs12AlgorithmIdentifier
+	Digest    []byte
+}
+
+type pkcs12MacData struct {
+	Mac        pkcs12DigestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type pkcs12PFX struct {
+	Version  int
+	AuthSafe pkcs12ContentInfo
+	MacData  pkcs12MacData
+}
+
+// EncodePKCS12 encodes the certificates and the private key of the given PEM into a PKCS#12 file.
+// The private key is encrypted using PBES2 (AES-256-CBC with PBKDF2-HMAC-SHA256) with the given password
+// and the whole file is protected with an HMAC-SHA256 using the given password, too.
+func EncodePKCS12(p []byte, friendlyName string, password string) ([]byte, error) {
+	privateKey, err := loadPrivateKeyFrom(p)
+	if err != nil {
+		return nil, err
+	}
+	var certificates [][]byte
+	for rest := p; len(rest) > 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certificates = append(certificates, block.Bytes)
+		}
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("The PEM does not contain a certificate.")
+	}
+	localKeyID := sha1.Sum(certificates[0])
+	keyAttributes, err := pkcs12AttributesFor(friendlyName, localKeyID[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var certificateBags []pkcs12SafeBag
+	for i, certificate := range certificates {
+		bag, err := pkcs12CertificateBagFor(certificate)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			bag.Attributes = keyAttributes
+		}
+		certificateBags = append(certificateBags, bag)
+	}
+	keyBag, err := pkcs12KeyBagFor(privateKey, password)
+	if err != nil {
+		return nil, err
+	}
+	keyBag.Attributes = keyAttributes
+
+	var authenticatedSafe []pkcs12ContentInfo
+	for _, bags := range [][]pkcs12SafeBag{certificateBags, {keyBag}} {
+		contentInfo, err := pkcs12DataContentInfoOf(bags)
+		if err != nil {
+			return nil, err
+		}
+		authenticatedSafe = append(authenticatedSafe, contentInfo)
+	}
+	authenticatedSafeBytes, err := asn1.Marshal(authenticatedSafe)
+	if err != nil {
+		return nil, errors.New("Could not encode PKCS#12 content.").CausedBy(err)
+	}
+	authSafe, err := pkcs12DataContentInfoOfBytes(authenticatedSafeBytes)
+	if err != nil {
+		return nil, err
+	}
+	macData, err := pkcs12MacDataFor(authenticatedSafeBytes, password)
+	if err != nil {
+		return nil, err
+	}
+	result, err := asn1.Marshal(pkcs12PFX{
+		Version:  3,
+		AuthSafe: authSafe,
+		MacData:  macData,
+	})
+	if err != nil {
+		return nil, errors.New("Could not encode PKCS#12.").CausedBy(err)
+	}
+	return result, nil
+}
+
+func pkcs12AttributesFor(friendlyName string, localKeyID []byte) ([]pkcs12Attribute, error) {
+	localKeyIDBytes, err := asn1.Marshal(localKeyID)
+	if err != nil {
+		return nil, errors.New("Could not encode local key id.").CausedBy(err)
+	}
+	result := []pkcs12Attribute{{ID: oidLocalKeyID, Value: asn1Set(localKeyIDBytes)}}
+	if friendlyName != "" {
+		friendlyNameBytes, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagBMPString, Bytes: bmpStringOf(friendlyName, false)})
+		if err != nil {
+			return nil, errors.New("Could not encode friendly name.").CausedBy(err)
+		}
+		result = append(result, pkcs12Attribute{ID: oidFriendlyName, Value: asn1Set(friendlyNameBytes)})
+	}
+	return result, nil
+}
+
+func pkcs12CertificateBagFor(certificate []byte) (pkcs12SafeBag, error) {
+	certificateBytes, err := asn1.Marshal(certificate)
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode certificate.").CausedBy(err)
+	}
+	certBagBytes, err := asn1.Marshal(pkcs12CertBag{
+		ID:    oidX509Certificate,
+		Value: asn1Explicit(certificateBytes),
+	})
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode certificate.").CausedBy(err)
+	}
+	return pkcs12SafeBag{
+		ID:    oidCertBag,
+		Value: asn1Explicit(certBagBytes),
+	}, nil
+}
+
+func pkcs12KeyBagFor(privateKey interface{}, password string) (pkcs12SafeBag, error) {
+	plainPrivateKey, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode private key.").CausedBy(err)
+	}
+	salt, err := randomBytes(16)
+	if err != nil {
+		return pkcs12SafeBag{}, err
+	}
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return pkcs12SafeBag{}, err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, pkcs12Iterations, 32)
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not derive key to encrypt private key.").CausedBy(err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encrypt private key.").CausedBy(err)
+	}
+	padding := aes.BlockSize - len(plainPrivateKey)%aes.BlockSize
+	encrypted := append(plainPrivateKey, make([]byte, padding)...)
+	for i := len(plainPrivateKey); i < len(encrypted); i++ {
+		encrypted[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	pbkdf2Parameters, err := asn1.Marshal(pkcs12PBKDF2Parameters{
+		Salt:           salt,
+		IterationCount: pkcs12Iterations,
+		PRF:            pkcs12AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1Null},
+	})
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode key derivation parameters.").CausedBy(err)
+	}
+	ivBytes, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode encryption parameters.").CausedBy(err)
+	}
+	pbes2Parameters, err := asn1.Marshal(pkcs12PBES2Parameters{
+		KeyDerivationFunc: pkcs12AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: pbkdf2Parameters}},
+		EncryptionScheme:  pkcs12AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivBytes}},
+	})
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode encryption parameters.").CausedBy(err)
+	}
+	encryptedPrivateKeyInfo, err := asn1.Marshal(pkcs12EncryptedPrivateKeyInfo{
+		Algorithm:     pkcs12AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: pbes2Parameters}},
+		EncryptedData: encrypted,
+	})
+	if err != nil {
+		return pkcs12SafeBag{}, errors.New("Could not encode encrypted private key.").CausedBy(err)
+	}
+	return pkcs12SafeBag{
+		ID:    oidPKCS8ShroudedKeyBag,
+		Value: asn1Explicit(encryptedPrivateKeyInfo),
+	}, nil
+}
+
+func pkcs12DataContentInfoOf(bags []pkcs12SafeBag) (pkcs12ContentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return pkcs12ContentInfo{}, errors.New("Could not encode PKCS#12 bags.").CausedBy(err)
+	}
+	return pkcs12DataContentInfoOfBytes(safeContents)
+}
+
+func pkcs12DataContentInfoOfBytes(content []byte) (pkcs12ContentInfo, error) {
+	data, err := asn1.Marshal(content)
+	if err != nil {
+		return pkcs12ContentInfo{}, errors.New("Could not encode PKCS#12 content.").CausedBy(err)
+	}
+	return pkcs12ContentInfo{
+		ContentType: oidData,
+		Content:     asn1Explicit(data),
+	}, nil
+}
+
+func pkcs12MacDataFor(content []byte, password string) (pkcs12MacData, error) {
+	salt, err := randomBytes(16)
+	if err != nil {
+		return pkcs12MacData{}, err
+	}
+	key := pkcs12KeyDerivation(bmpStringOf(password, true), salt, pkcs12Iterations)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return pkcs12MacData{
+		Mac: pkcs12DigestInfo{
+			Algorithm: pkcs12AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1Null},
+			Digest:    mac.Sum(nil),
+		},
+		MacSalt:    salt,
+		Iterations: pkcs12Iterations,
+	}, nil
+}
+
+// Derives the key of the MAC like described in RFC 7292, appendix B.2 using SHA-256.
+func pkcs12KeyDerivation(password []byte, salt []byte, iterations int) []byte {
+	const u = sha256.Size
+	const v = sha256.BlockSize
+	const macKeyID = 3
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = macKeyID
+	}
+	i := append(repeatToMultipleOf(salt, v), repeatToMultipleOf(password, v)...)
+	a := sha256.Sum256(append(d, i...))
+	for r := 1; r < iterations; r++ {
+		a = sha256.Sum256(a[:])
+	}
+	// The key of the MAC has the length of a single hash. Therefore, no further rounds are required.
+	return a[:u]
+}
+
+func repeatToMultipleOf(p []byte, v int) []byte {
+	if len(p) == 0 {
+		return nil
+	}
+	result := make([]byte, v*((len(p)+v-1)/v))
+	for i := range result {
+		result[i] = p[i%len(p)]
+	}
+	return result
+}
+
+// Encodes the given string as BMPString (UTF-16 big endian). Passwords are terminated with two zero bytes.
+func bmpStringOf(s string, terminated bool) []byte {
+	var result []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		result = append(result, byte(c>>8), byte(c))
+	}
+	if terminated {
+		result = append(result, 0, 0)
+	}
+	return result
+}
+
+func asn1Explicit(content []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content}
+}
+
+func asn1Set(content []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: content}
+}
+
+func randomBytes(length int) ([]byte, error) {
+	result := make([]byte, length)
+	if _, err := rand.Read(result); err != nil {
+		return nil, errors.New("Could not generate random bytes.").CausedBy(err)
+	}
+	return result, nil
+}
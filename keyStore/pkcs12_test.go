@@ -0,0 +1,183 @@
+package keyStore
+
+import (
+	. "gopkg.in/check.v1"
+
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+)
+
+type Pkcs12Test struct{}
+
+func init() {
+	Suite(&Pkcs12Test{})
+}
+
+// Decodes the given PKCS#12 file like it is generated by EncodePKCS12. The MAC is verified before anything
+// is decrypted.
+func decodePKCS12(p []byte, password string) (crypto.PrivateKey, []*x509.Certificate, error) {
+	var pfx pkcs12PFX
+	if _, err := asn1.Unmarshal(p, &pfx); err != nil {
+		return nil, nil, err
+	}
+	if pfx.Version != 3 || !pfx.AuthSafe.ContentType.Equal(oidData) || !pfx.MacData.Mac.Algorithm.Algorithm.Equal(oidSHA256) {
+		return nil, nil, errors.New("Unexpected PKCS#12 structure.")
+	}
+	var authenticatedSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authenticatedSafe); err != nil {
+		return nil, nil, err
+	}
+	mac := hmac.New(sha256.New, pkcs12KeyDerivation(bmpStringOf(password, true), pfx.MacData.MacSalt, pfx.MacData.Iterations))
+	mac.Write(authenticatedSafe)
+	if !hmac.Equal(mac.Sum(nil), pfx.MacData.Mac.Digest) {
+		return nil, nil, errors.New("MAC verification failed.")
+	}
+	var contentInfos []pkcs12ContentInfo
+	if _, err := asn1.Unmarshal(authenticatedSafe, &contentInfos); err != nil {
+		return nil, nil, err
+	}
+	var privateKey crypto.PrivateKey
+	var certificates []*x509.Certificate
+	for _, contentInfo := range contentInfos {
+		var safeContents []byte
+		if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &safeContents); err != nil {
+			return nil, nil, err
+		}
+		var bags []pkcs12SafeBag
+		if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+			return nil, nil, err
+		}
+		for _, bag := range bags {
+			switch {
+			case bag.ID.Equal(oidCertBag):
+				certificate, err := decodePKCS12CertificateBag(bag)
+				if err != nil {
+					return nil, nil, err
+				}
+				certificates = append(certificates, certificate)
+			case bag.ID.Equal(oidPKCS8ShroudedKeyBag):
+				key, err := decodePKCS12KeyBag(bag, password)
+				if err != nil {
+					return nil, nil, err
+				}
+				privateKey = key
+			}
+		}
+	}
+	return privateKey, certificates, nil
+}
+
+func decodePKCS12CertificateBag(bag pkcs12SafeBag) (*x509.Certificate, error) {
+	var certBag pkcs12CertBag
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &certBag); err != nil {
+		return nil, err
+	}
+	var certificate []byte
+	if _, err := asn1.Unmarshal(certBag.Value.Bytes, &certificate); err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certificate)
+}
+
+func decodePKCS12KeyBag(bag pkcs12SafeBag, password string) (crypto.PrivateKey, error) {
+	var info pkcs12EncryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &info); err != nil {
+		return nil, err
+	}
+	var pbes2Parameters pkcs12PBES2Parameters
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &pbes2Parameters); err != nil {
+		return nil, err
+	}
+	var pbkdf2Parameters pkcs12PBKDF2Parameters
+	if _, err := asn1.Unmarshal(pbes2Parameters.KeyDerivationFunc.Parameters.FullBytes, &pbkdf2Parameters); err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(pbes2Parameters.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, pbkdf2Parameters.Salt, pbkdf2Parameters.IterationCount, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("Illegal padding.")
+	}
+	return x509.ParsePKCS8PrivateKey(decrypted[:len(decrypted)-padding])
+}
+
+func (s *Pkcs12Test) TestEncodePKCS12(c *C) {
+	for _, hints := range []string{"algorithm:`ecdsa` curve:`P-256`", "algorithm:`ed25519`", "algorithm:`rsa` bits:`2048`"} {
+		conf := NewConfig()
+		conf.Type = Generated
+		conf.Hints = values.String(hints)
+		ks, err := NewKeyStore(true, conf)
+		c.Assert(err, IsNil, Commentf(hints))
+		p, cert, err := ks.GeneratePem("foo")
+		c.Assert(err, IsNil, Commentf(hints))
+		expectedKey, err := loadPrivateKeyFrom(p)
+		c.Assert(err, IsNil, Commentf(hints))
+
+		encoded, err := EncodePKCS12(p, "foo", "pässwörd")
+		c.Assert(err, IsNil, Commentf(hints))
+
+		key, certificates, err := decodePKCS12(encoded, "pässwörd")
+		c.Assert(err, IsNil, Commentf(hints))
+		c.Assert(key.(interface{ Equal(crypto.PrivateKey) bool }).Equal(expectedKey), Equals, true, Commentf(hints))
+		c.Assert(len(certificates) >= 1, Equals, true, Commentf(hints))
+		c.Assert(certificates[0].Equal(cert), Equals, true, Commentf(hints))
+
+		_, _, err = decodePKCS12(encoded, "wrong")
+		c.Assert(err, ErrorMatches, "MAC verification failed.", Commentf(hints))
+	}
+}
+
+func (s *Pkcs12Test) TestEncodePKCS12WithoutCertificate(c *C) {
+	conf := NewConfig()
+	conf.Type = Generated
+	ks, err := NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	p, _, err := ks.GeneratePem("foo")
+	c.Assert(err, IsNil)
+	var keyOnly []byte
+	for block, rest := pem.Decode(p); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			keyOnly = append(keyOnly, pem.EncodeToMemory(block)...)
+		}
+	}
+	_, err = EncodePKCS12(keyOnly, "foo", "secret")
+	c.Assert(err, ErrorMatches, "The PEM does not contain a certificate.")
+}
+
+func (s *Pkcs12Test) TestKeyDerivation(c *C) {
+	// Generated with: openssl kdf -keylen 32 -kdfopt digest:SHA2-256 -kdfopt hexpass:0073006500630072006500740000
+	//   -kdfopt hexsalt:0102030405060708 -kdfopt iter:2048 -kdfopt id:3 PKCS12KDF
+	expected, err := hex.DecodeString("F5482FD03F702689B4E96CBBEA867C6B16E5BDA934929F2ECAF4DA9E1C67BE8F")
+	c.Assert(err, IsNil)
+	actual := pkcs12KeyDerivation(bmpStringOf("secret", true), []byte{1, 2, 3, 4, 5, 6, 7, 8}, 2048)
+	c.Assert(actual, DeepEquals, expected)
+}
+
+func (s *Pkcs12Test) TestBmpStringOf(c *C) {
+	c.Assert(bmpStringOf("ä€", false), DeepEquals, []byte{0x00, 0xe4, 0x20, 0xac})
+	c.Assert(bmpStringOf("a", true), DeepEquals, []byte{0x00, 0x61, 0x00, 0x00})
+	c.Assert(bmpStringOf("", true), DeepEquals, []byte{0x00, 0x00})
+}
@@ -0,0 +1,95 @@
+package keyStore
+
+import (
+	"crypto/x509"
+	"github.com/echocat/caretakerd/errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFilename = "caretakerd.pem"
+
+func (instance *KeyStore) stateFilename() string {
+	return filepath.Join(instance.config.StateDirectory.String(), stateFilename)
+}
+
+// Loads the certificate of caretakerd from the configured stateDirectory.
+// Returns "false" if there is nothing stored or the stored certificate has to be renewed.
+func (instance *KeyStore) loadState() (bool, error) {
+	if instance.config.StateDirectory.IsTrimmedEmpty() {
+		return false, nil
+	}
+	filename := instance.stateFilename()
+	p, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.New("Could not read state of keyStore from '%v'.", filename).CausedBy(err)
+	}
+	stored, err := newPemFromBytes(instance.config, p)
+	if err != nil {
+		return false, errors.New("Could not load state of keyStore from '%v'.", filename).CausedBy(err)
+	}
+	if !stored.cert.IsCA || instance.IsRenewalRequiredFor(stored.cert) {
+		return false, nil
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	instance.pem = stored.pem
+	instance.ca = stored.ca
+	instance.cert = stored.cert
+	instance.privateKey = stored.privateKey
+	return true, nil
+}
+
+func (instance *KeyStore) storeState(p []byte) error {
+	if instance.config.StateDirectory.IsTrimmedEmpty() {
+		return nil
+	}
+	filename := instance.stateFilename()
+	if err := os.MkdirAll(instance.config.StateDirectory.String(), 0700); err != nil {
+		return errors.New("Could not create state directory '%v' of keyStore.", instance.config.StateDirectory).CausedBy(err)
+	}
+	// Write to a temporary file first to never leave a broken state if caretakerd crashes while writing.
+	temporaryFilename := filename + ".tmp"
+	if err := os.WriteFile(temporaryFilename, p, 0600); err != nil {
+		return errors.New("Could not write state of keyStore to '%v'.", temporaryFilename).CausedBy(err)
+	}
+	if err := os.Rename(temporaryFilename, filename); err != nil {
+		return errors.New("Could not write state of keyStore to '%v'.", filename).CausedBy(err)
+	}
+	return nil
+}
+
+// IsRenewable returns "true" if a new certificate for caretakerd could be generated with Renew.
+func (instance *KeyStore) IsRenewable() bool {
+	return instance.enabled && instance.config.Type.IsGenerating()
+}
+
+// Renew generates a new certificate for caretakerd and stores it in the configured stateDirectory.
+// Certificates that were generated with the previous certificate are no longer trusted afterwards.
+func (instance *KeyStore) Renew() error {
+	if !instance.IsRenewable() {
+		return errors.New("The certificate of a keyStore of type %v could not be renewed.", instance.Type())
+	}
+	return instance.generate()
+}
+
+// HasIssued returns "true" if the given certificate was issued with the current certificate of caretakerd.
+func (instance *KeyStore) HasIssued(cert *x509.Certificate) bool {
+	ca := instance.Certificate()
+	return ca != nil && cert != nil && cert.CheckSignatureFrom(ca) == nil
+}
+
+// IsRenewalRequiredFor returns "true" if the given certificate expires within the configured renewBefore period.
+func (instance *KeyStore) IsRenewalRequiredFor(cert *x509.Certificate) bool {
+	renewBefore := instance.config.RenewBefore.Duration()
+	return renewBefore > 0 && time.Now().After(cert.NotAfter.Add(-renewBefore))
+}
+
+// IsExpiryWarningRequiredFor returns "true" if the given certificate expires within the configured warnBefore period.
+func (instance *KeyStore) IsExpiryWarningRequiredFor(cert *x509.Certificate) bool {
+	warnBefore := instance.config.WarnBefore.Duration()
+	return warnBefore > 0 && time.Now().After(cert.NotAfter.Add(-warnBefore))
+}
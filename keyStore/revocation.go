@@ -0,0 +1,193 @@
+package keyStore
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"github.com/echocat/caretakerd/errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const revocationsFilename = "revocations.json"
+
+// Revocation represents a revoked certificate.
+type Revocation struct {
+	// Serial number (hexadecimal) or common name of the revoked certificates.
+	// If this is a common name every certificate of this name that was issued before is revoked.
+	Target string `json:"target"`
+	// Time when the revocation was done.
+	RevokedAt time.Time `json:"revokedAt"`
+	// Serial numbers (hexadecimal) of the certificates of the common name that were issued
+	// in the same second as the revocation but before it.
+	Serials []string `json:"serials,omitempty"`
+}
+
+// Matches returns "true" if the given certificate is revoked by this revocation.
+// Certificates have only a precision of seconds. Certificates of a common name that were issued in the same second
+// as the revocation are only revoked if they are recorded in Serials - otherwise the certificates that are renewed
+// because of the revocation would be revoked, too.
+func (instance Revocation) Matches(cert *x509.Certificate) bool {
+	if serial, ok := parseSerial(instance.Target); ok && serial.Cmp(cert.SerialNumber) == 0 {
+		return true
+	}
+	if cert.Subject.CommonName != instance.Target {
+		return false
+	}
+	if cert.NotBefore.Before(instance.RevokedAt.Truncate(time.Second)) {
+		return true
+	}
+	for _, plain := range instance.Serials {
+		if serial, ok := parseSerial(plain); ok && serial.Cmp(cert.SerialNumber) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses serials like they are printed by openssl - hexadecimal with or without colons.
+func parseSerial(plain string) (*big.Int, bool) {
+	return new(big.Int).SetString(strings.Replace(plain, ":", "", -1), 16)
+}
+
+func (instance *KeyStore) revocationsFilename() string {
+	if !instance.config.RevocationFile.IsTrimmedEmpty() {
+		return instance.config.RevocationFile.String()
+	}
+	if !instance.config.StateDirectory.IsTrimmedEmpty() {
+		return filepath.Join(instance.config.StateDirectory.String(), revocationsFilename)
+	}
+	return ""
+}
+
+func (instance *KeyStore) loadRevocations() error {
+	filename := instance.revocationsFilename()
+	if filename == "" {
+		return nil
+	}
+	p, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.New("Could not read revocations from '%v'.", filename).CausedBy(err)
+	}
+	var revocations []Revocation
+	if err := json.Unmarshal(p, &revocations); err != nil {
+		return errors.New("Could not parse revocations from '%v'.", filename).CausedBy(err)
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	instance.revocations = revocations
+	return nil
+}
+
+func (instance *KeyStore) storeRevocations(revocations []Revocation) error {
+	filename := instance.revocationsFilename()
+	if filename == "" {
+		return nil
+	}
+	p, err := json.MarshalIndent(revocations, "", "  ")
+	if err != nil {
+		return errors.New("Could not serialize revocations.").CausedBy(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return errors.New("Could not create directory of '%v'.", filename).CausedBy(err)
+	}
+	temporaryFilename := filename + ".tmp"
+	if err := os.WriteFile(temporaryFilename, p, 0600); err != nil {
+		return errors.New("Could not write revocations to '%v'.", temporaryFilename).CausedBy(err)
+	}
+	if err := os.Rename(temporaryFilename, filename); err != nil {
+		return errors.New("Could not write revocations to '%v'.", filename).CausedBy(err)
+	}
+	return nil
+}
+
+// Revoke revokes the certificate with the given serial number (hexadecimal) or every
+// certificate with the given common name that was issued before.
+// The revocation is stored in the configured revocationFile.
+func (instance *KeyStore) Revoke(target string) (Revocation, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return Revocation{}, errors.New("There is no serial or common name to revoke.")
+	}
+	if !instance.enabled {
+		return Revocation{}, errors.New("KeyStore is not enabled.")
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	revokedAt := time.Now()
+	revocation := Revocation{
+		Target:    target,
+		RevokedAt: revokedAt,
+		Serials:   instance.issuedSerialsOf(target, revokedAt.Truncate(time.Second)),
+	}
+	revocations := append(append([]Revocation{}, instance.revocations...), revocation)
+	if err := instance.storeRevocations(revocations); err != nil {
+		return Revocation{}, err
+	}
+	instance.revocations = revocations
+	close(instance.changed)
+	instance.changed = make(chan struct{})
+	return revocation, nil
+}
+
+// Remembers the given certificate until a certificate of the same common name is issued in a later second.
+// This is required to revoke certificates that were issued in the same second as a revocation.
+func (instance *KeyStore) recordIssued(cert *x509.Certificate) {
+	instance.issuedLock.Lock()
+	defer instance.issuedLock.Unlock()
+	if instance.issued == nil {
+		instance.issued = map[string][]*x509.Certificate{}
+	}
+	name := cert.Subject.CommonName
+	issued := []*x509.Certificate{}
+	for _, candidate := range instance.issued[name] {
+		if candidate.NotBefore.Equal(cert.NotBefore) {
+			issued = append(issued, candidate)
+		}
+	}
+	instance.issued[name] = append(issued, cert)
+}
+
+func (instance *KeyStore) issuedSerialsOf(name string, second time.Time) []string {
+	instance.issuedLock.Lock()
+	defer instance.issuedLock.Unlock()
+	var result []string
+	for _, cert := range instance.issued[name] {
+		if !cert.NotBefore.Before(second) {
+			result = append(result, cert.SerialNumber.Text(16))
+		}
+	}
+	return result
+}
+
+// Revocations returns every revocation of this KeyStore.
+func (instance *KeyStore) Revocations() []Revocation {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
+	return instance.revocations
+}
+
+// IsRevoked returns "true" if the given certificate was revoked.
+func (instance *KeyStore) IsRevoked(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	for _, revocation := range instance.Revocations() {
+		if revocation.Matches(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed returns a channel that is closed at the next revocation of a certificate.
+// For every following revocation this method has to be called again.
+func (instance *KeyStore) Changed() <-chan struct{} {
+	instance.lock.RLock()
+	defer instance.lock.RUnlock()
+	return instance.changed
+}
@@ -0,0 +1,114 @@
+package keyStore
+
+import (
+	. "gopkg.in/check.v1"
+
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"github.com/echocat/caretakerd/values"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type RevocationTest struct{}
+
+func init() {
+	Suite(&RevocationTest{})
+}
+
+func certificateOf(serial int64, commonName string, notBefore time.Time) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notBefore,
+	}
+}
+
+func (s *RevocationTest) TestMatchesSerial(c *C) {
+	cert := certificateOf(0x5B043AF4, "foo", time.Now())
+	c.Assert(Revocation{Target: "5B043AF4"}.Matches(cert), Equals, true)
+	c.Assert(Revocation{Target: "5b043af4"}.Matches(cert), Equals, true)
+	c.Assert(Revocation{Target: "5B:04:3A:F4"}.Matches(cert), Equals, true)
+	c.Assert(Revocation{Target: "5B043AF5"}.Matches(cert), Equals, false)
+}
+
+func (s *RevocationTest) TestMatchesCommonName(c *C) {
+	revokedAt := time.Date(2026, 10, 19, 12, 0, 0, 500000000, time.UTC)
+	revocation := Revocation{Target: "foo", RevokedAt: revokedAt}
+	c.Assert(revocation.Matches(certificateOf(1, "foo", revokedAt.Add(-time.Second))), Equals, true)
+	c.Assert(revocation.Matches(certificateOf(1, "bar", revokedAt.Add(-time.Second))), Equals, false)
+	// Certificates of the same second could have been renewed because of this revocation.
+	c.Assert(revocation.Matches(certificateOf(1, "foo", revokedAt.Truncate(time.Second))), Equals, false)
+	c.Assert(revocation.Matches(certificateOf(1, "foo", revokedAt.Add(time.Second))), Equals, false)
+	// ...except they were issued before the revocation.
+	revocation.Serials = []string{"2a"}
+	c.Assert(revocation.Matches(certificateOf(0x2A, "foo", revokedAt.Truncate(time.Second))), Equals, true)
+	c.Assert(revocation.Matches(certificateOf(0x2A, "bar", revokedAt.Truncate(time.Second))), Equals, false)
+	c.Assert(revocation.Matches(certificateOf(1, "foo", revokedAt.Truncate(time.Second))), Equals, false)
+}
+
+func (s *RevocationTest) TestRevokeWithinTheSecondOfIssue(c *C) {
+	conf := NewConfig()
+	conf.Type = Generated
+	ks, err := NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	now := time.Now()
+	time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
+
+	_, issuedBefore, err := ks.GeneratePem("foo")
+	c.Assert(err, IsNil)
+	revocation, err := ks.Revoke("foo")
+	c.Assert(err, IsNil)
+	_, issuedAfter, err := ks.GeneratePem("foo")
+	c.Assert(err, IsNil)
+
+	c.Assert(revocation.RevokedAt.Truncate(time.Second).Equal(issuedBefore.NotBefore), Equals, true)
+	c.Assert(revocation.Serials, DeepEquals, []string{issuedBefore.SerialNumber.Text(16)})
+	c.Assert(ks.IsRevoked(issuedBefore), Equals, true)
+	c.Assert(ks.IsRevoked(issuedAfter), Equals, false)
+}
+
+func (s *RevocationTest) TestRevokeIsPersisted(c *C) {
+	conf := NewConfig()
+	conf.Type = Generated
+	conf.StateDirectory = values.String(c.MkDir())
+	ks, err := NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	_, cert, err := ks.GeneratePem("foo")
+	c.Assert(err, IsNil)
+	c.Assert(ks.IsRevoked(cert), Equals, false)
+
+	changed := ks.Changed()
+	revocation, err := ks.Revoke(" foo ")
+	c.Assert(err, IsNil)
+	c.Assert(revocation.Target, Equals, "foo")
+	<-changed
+	_, err = ks.Revoke(cert.SerialNumber.Text(16))
+	c.Assert(err, IsNil)
+	c.Assert(ks.IsRevoked(cert), Equals, true)
+
+	p, err := os.ReadFile(filepath.Join(conf.StateDirectory.String(), revocationsFilename))
+	c.Assert(err, IsNil)
+	var stored []Revocation
+	c.Assert(json.Unmarshal(p, &stored), IsNil)
+	c.Assert(stored, HasLen, 2)
+	c.Assert(stored[0].Target, Equals, "foo")
+
+	reloaded, err := NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	c.Assert(reloaded.Revocations(), HasLen, 2)
+	c.Assert(reloaded.IsRevoked(cert), Equals, true)
+}
+
+func (s *RevocationTest) TestRevokeRequiresTarget(c *C) {
+	conf := NewConfig()
+	conf.Type = Generated
+	ks, err := NewKeyStore(true, conf)
+	c.Assert(err, IsNil)
+	_, err = ks.Revoke(" ")
+	c.Assert(err, ErrorMatches, "There is no serial or common name to revoke.")
+	c.Assert(ks.IsRevoked(nil), Equals, false)
+}
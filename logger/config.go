@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"encoding/json"
 	"github.com/echocat/caretakerd/values"
 )
 
@@ -99,3 +100,11 @@ func (instance *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type noMethods Config
 	return unmarshal((*noMethods)(instance))
 }
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Config) UnmarshalJSON(b []byte) error {
+	instance.init()
+
+	type noMethods Config
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
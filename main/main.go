@@ -5,6 +5,7 @@ import (
 	"github.com/echocat/caretakerd"
 	"github.com/echocat/caretakerd/app"
 	"github.com/echocat/caretakerd/panics"
+	"github.com/echocat/caretakerd/seccomp"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -15,6 +16,7 @@ import (
 var executableNamePattern = regexp.MustCompile("(?:^|" + regexp.QuoteMeta(string(os.PathSeparator)) + ")" + caretakerd.BaseName + "(d|ctl)(?:$|[\\.\\-\\_].*$)")
 
 func main() {
+	seccomp.ExecuteHelperIfRequested()
 	defer panics.DefaultPanicHandler()
 	a := app.NewAppFor(runtime.GOOS, getExecutableType())
 
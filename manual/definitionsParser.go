@@ -368,7 +368,10 @@ func (instance *extractionTask) parsePackageToDefinitions(packageName string, pa
 				}
 
 				if enumDefinition == nil {
-					typeIdentifier := ParseType(eUnderlying.Underlying().String())
+					comment, typeIdentifier := serializedAs(comment)
+					if typeIdentifier == nil {
+						typeIdentifier = ParseType(eUnderlying.Underlying().String())
+					}
 					comment, inlined := extractInlinedFrom(comment)
 					instance.definitions.NewSimpleDefinition(pp.pkg.Path(), name, typeIdentifier, comment, inlined)
 				}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/errors"
+	"html/template"
+	"strings"
+)
+
+func (instance *Renderer) renderEnvironmentMappings(scope string) (template.HTML, error) {
+	markup, err := environmentMappingsMarkdownOf(scope)
+	if err != nil {
+		return "", err
+	}
+	return instance.renderMarkdownWithContext(markup, nil, 0, "")
+}
+
+// Creates a markdown table of every environment mapping of the given scope ("services" or "global").
+// Properties are referenced using {@ref ...}.
+func environmentMappingsMarkdownOf(scope string) (string, error) {
+	var mappings caretakerd.EnvironmentMappings
+	var prefix string
+	switch scope {
+	case "services":
+		mappings, prefix = caretakerd.ServiceEnvironmentMappings(), "CTD.<service>."
+	case "global":
+		mappings, prefix = caretakerd.GlobalEnvironmentMappings(), "CTD."
+	default:
+		return "", errors.New("Unknown environment mapping scope: %v", scope)
+	}
+	markup := "| Environment variable | Aliases | Configuration property |\n| --- | --- | --- |\n"
+	for _, mapping := range mappings {
+		suffix, propertySuffix := "", ""
+		if mapping.IsMap() {
+			suffix, propertySuffix = ".<name>", "``[<name>]``"
+		}
+		variables := []string{"``" + prefix + mapping.Key + suffix + "``"}
+		if mapping.IsList() {
+			variables = append(variables, "``"+prefix+mapping.Key+".<index>``")
+		}
+		aliases := []string{}
+		for _, alias := range mapping.Aliases {
+			aliases = append(aliases, "``"+prefix+alias+suffix+"``")
+		}
+		references := []string{}
+		for _, reference := range mapping.References {
+			references = append(references, "{@ref "+reference+"}")
+		}
+		property := strings.Join(references, ": ") + propertySuffix
+		markup += "| " + strings.Join(variables, ", ") + " | " + strings.Join(aliases, ", ") + " | " + property + " |\n"
+	}
+	return markup, nil
+}
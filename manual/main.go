@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/echocat/caretakerd/app"
 	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/reference"
 	"github.com/echocat/caretakerd/sync"
 	"os"
 	"path/filepath"
@@ -25,12 +27,19 @@ func panicHandler() {
 
 func main() {
 	if len(os.Args) < 4 || len(os.Args[1]) <= 0 || len(os.Args[2]) <= 0 || len(os.Args[3]) <= 0 {
-		_, _ = fmt.Fprintf(os.Stderr, "Usage: %v <version> <platform> <output>\n", os.Args[0])
+		_, _ = fmt.Fprintf(os.Stderr, "Usage: %v <version> <platform> <output> [<output>...]\n"+
+			"The format is selected by the name of <output>:\n"+
+			"  *.html                       Manual as HTML\n"+
+			"  *.md                         Manual as GitHub flavored markdown\n"+
+			"  "+reference.FileName+"     Reference of the configuration to be embedded into the binary\n"+
+			"  *.json                       JSON Schema of the configuration\n"+
+			"  <executable>.1               Man page of caretakerd, caretakerctl or caretaker\n"+
+			"  "+ConfigurationManPageName+".5             Man page of the configuration\n", os.Args[0])
 		os.Exit(1)
 	}
 	version := strings.TrimPrefix(os.Args[1], "v")
 	platform := os.Args[2]
-	plainFile := os.Args[3]
+	plainFiles := os.Args[3:]
 
 	defer panicHandler()
 	project, err := DeterminateProject("github.com/echocat/caretakerd")
@@ -48,27 +57,110 @@ func main() {
 		panic(err)
 	}
 
+	for _, plainFile := range plainFiles {
+		file, err := filepath.Abs(plainFile)
+		if err != nil {
+			panic(err)
+		}
+
+		content := render(file, platform, version, project, pd)
+
+		directory := filepath.Dir(file)
+		if err := os.MkdirAll(directory, 0755); err != nil {
+			panic(err)
+		}
+		if err := os.WriteFile(file, content, 0655); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func render(file string, platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	base := filepath.Base(file)
+	if base == reference.FileName {
+		return renderReference(platform, version, project, pd)
+	}
+	switch filepath.Ext(base) {
+	case ".json":
+		return renderSchema(project, pd)
+	case ".md":
+		return renderMarkdown(platform, version, project, pd)
+	case ".1":
+		name := strings.TrimSuffix(base, ".1")
+		for _, executableType := range app.AllExecutableTypes {
+			if executableType.String() == name {
+				return renderCommandManPage(executableType, platform, version, project, pd)
+			}
+		}
+		panic(fmt.Sprintf("There is no executable called '%s'.", name))
+	case ".5":
+		return renderConfigurationManPage(platform, version, project, pd)
+	}
+	return renderHTML(platform, version, project, pd)
+}
+
+func newRendererFor(platform string, version string, project Project, pd *PickedDefinitions) *Renderer {
 	apps := app.NewAppsFor(platform)
 
 	renderer, err := NewRendererFor(platform, version, project, pd, apps)
 	if err != nil {
 		panic(err)
 	}
+	return renderer
+}
 
-	content, err := renderer.Execute()
+func renderHTML(platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	content, err := newRendererFor(platform, version, project, pd).Execute()
 	if err != nil {
 		panic(err)
 	}
+	return []byte(content)
+}
 
-	file, err := filepath.Abs(plainFile)
+func renderMarkdown(platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	content, err := NewMarkdownRendererFor(newRendererFor(platform, version, project, pd)).Execute()
 	if err != nil {
 		panic(err)
 	}
-	directory := filepath.Dir(file)
-	if err := os.MkdirAll(directory, 0755); err != nil {
+	return []byte(content)
+}
+
+func renderCommandManPage(executableType app.ExecutableType, platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	content, err := NewManRendererFor(newRendererFor(platform, version, project, pd)).ExecuteFor(executableType)
+	if err != nil {
 		panic(err)
 	}
-	if err := os.WriteFile(file, []byte(content), 0655); err != nil {
+	return []byte(content)
+}
+
+func renderConfigurationManPage(platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	content, err := NewManRendererFor(newRendererFor(platform, version, project, pd)).ExecuteConfiguration()
+	if err != nil {
+		panic(err)
+	}
+	return []byte(content)
+}
+
+func renderReference(platform string, version string, project Project, pd *PickedDefinitions) []byte {
+	ref, err := NewReferenceFor(newRendererFor(platform, version, project, pd))
+	if err != nil {
+		panic(err)
+	}
+	content, err := json.MarshalIndent(ref, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(content, '\n')
+}
+
+func renderSchema(project Project, pd *PickedDefinitions) []byte {
+	schema, err := NewSchemaFor(project, pd)
+	if err != nil {
+		panic(err)
+	}
+	content, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
 		panic(err)
 	}
+	return append(content, '\n')
 }
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/app"
+	"github.com/echocat/caretakerd/defaults"
+	"github.com/echocat/caretakerd/errors"
+	"strings"
+)
+
+// ConfigurationManPageName is the name of the man page that describes the configuration file.
+const ConfigurationManPageName = caretakerd.DaemonName + ".yaml"
+
+// ManRenderer renders the manual as man pages in roff format.
+type ManRenderer struct {
+	*Renderer
+}
+
+// NewManRendererFor creates a new ManRenderer based on the given renderer.
+func NewManRendererFor(renderer *Renderer) *ManRenderer {
+	return &ManRenderer{
+		Renderer: renderer,
+	}
+}
+
+// ExecuteFor renders the man page (section 1) of the given executableType.
+func (instance *ManRenderer) ExecuteFor(executableType app.ExecutableType) (string, error) {
+	a, ok := instance.Apps[executableType]
+	if !ok {
+		return "", errors.New("There is no application for %v.", executableType)
+	}
+	model := a.Model()
+	name := executableType.String()
+	to := new(strings.Builder)
+	instance.writeHeader(name, 1, "User Commands", to)
+
+	instance.writeSection("NAME", to)
+	to.WriteString(name + ` \- ` + roffEscape(strings.TrimSuffix(model.Help, ".")) + "\n")
+
+	instance.writeSection("SYNOPSIS", to)
+	to.WriteString(`\fB` + name + `\fR [\fIflags\fR] \fIcommand\fR [\fIcommand flags\fR] [\fIargs\fR ...]` + "\n")
+
+	instance.writeSection("DESCRIPTION", to)
+	to.WriteString(roffEscape(model.Help) + "\n.PP\n" + roffEscape(caretakerd.Description) + "\n")
+
+	instance.writeSection("OPTIONS", to)
+	instance.writeFlags(model.FlagGroupModel, to)
+
+	instance.writeSection("COMMANDS", to)
+	for _, command := range model.FlattenedCommands() {
+		if command.Hidden {
+			continue
+		}
+		to.WriteString(`.SS "` + roffEscapeCode(instance.commandUsageOf(command)) + "\"\n")
+		to.WriteString(roffEscapeLine(roffEscape(command.Help)) + "\n")
+		if hasVisibleFlags(command.FlagGroupModel) {
+			instance.writeFlags(command.FlagGroupModel, to)
+		}
+		if command.ArgGroupModel != nil && len(command.Args) > 0 {
+			for _, arg := range command.Args {
+				if arg.Hidden {
+					continue
+				}
+				to.WriteString(".TP\n" + `\fI` + roffEscapeCode(argPlaceHolderOf(arg)) + `\fR` + "\n")
+				to.WriteString(roffEscapeLine(roffEscape(arg.Help)) + "\n")
+			}
+		}
+	}
+
+	instance.writeSection("ENVIRONMENT", to)
+	for _, flag := range model.Flags {
+		if flag.Hidden || len(flag.Envar) == 0 {
+			continue
+		}
+		to.WriteString(".TP\n" + `\fB` + roffEscapeCode(flag.Envar) + `\fR` + "\n")
+		to.WriteString(`Same as \fB\-\-` + roffEscapeCode(flag.Name) + `\fR.` + "\n")
+	}
+	if executableType != app.Control {
+		to.WriteString(".TP\n" + `\fBCTD.\fR\fIkey\fR, \fBCTD.\fR\fIservice\fR\fB.\fR\fIkey\fR` + "\n")
+		to.WriteString(`Overwrites properties of the configuration. See \fB` + ConfigurationManPageName + `\fR(5) for all possible keys.` + "\n")
+	}
+
+	instance.writeSection("FILES", to)
+	to.WriteString(".TP\n" + `\fI` + roffEscapeCode(defaults.ConfigFilenameFor(instance.Platform).String()) + `\fR` + "\n")
+	to.WriteString(`Default location of the configuration file. See \fB` + ConfigurationManPageName + `\fR(5).` + "\n")
+
+	instance.writeSeeAlso(name, to)
+	return to.String(), nil
+}
+
+// ExecuteConfiguration renders the man page (section 5) of the configuration file.
+func (instance *ManRenderer) ExecuteConfiguration() (string, error) {
+	to := new(strings.Builder)
+	instance.writeHeader(ConfigurationManPageName, 5, "File Formats", to)
+
+	instance.writeSection("NAME", to)
+	to.WriteString(ConfigurationManPageName + ` \- configuration of ` + instance.Name + "\n")
+
+	instance.writeSection("SYNOPSIS", to)
+	to.WriteString(`\fI` + roffEscapeCode(defaults.ConfigFilenameFor(instance.Platform).String()) + `\fR` + "\n")
+
+	instance.writeSection("DESCRIPTION", to)
+	description, err := instance.prepareMarkdown("The configuration of "+instance.Name+" could be written in YAML, JSON or TOML. "+
+		"The format is selected by the extension of the file. Its root is {@ref "+instance.PickedDefinitions.RootID.String()+"}.\n\n"+
+		"Every property could also be set using environment variables (see **ENVIRONMENT**) or the ``--set`` flag of "+instance.Name+"(1).",
+		nil, 0, instance.formatRefAsCode)
+	if err != nil {
+		return "", err
+	}
+	to.WriteString(markdownToRoff(description) + "\n")
+
+	instance.writeSection("STRUCTURE", to)
+	structure := new(strings.Builder)
+	if err := instance.renderPlainDefinitionStructure(0, instance.PickedDefinitions.RootID, structure); err != nil {
+		return "", err
+	}
+	to.WriteString(".nf\n" + instance.roffCodeBlockOf(structure.String()) + ".fi\n")
+
+	if err := instance.writeInclude("INTERPOLATION", "configuration.interpolation", to); err != nil {
+		return "", err
+	}
+	if err := instance.writeInclude("ENVIRONMENT", "configuration.environmentMapping", to); err != nil {
+		return "", err
+	}
+
+	instance.writeSection("DATA TYPES", to)
+	for _, definition := range instance.PickedDefinitions.TopLevelDefinitions {
+		if err := instance.writeDefinition(definition, to); err != nil {
+			return "", err
+		}
+	}
+
+	instance.writeSection("EXAMPLES", to)
+	examples, err := instance.collectExamples()
+	if err != nil {
+		return "", err
+	}
+	for _, example := range examples {
+		to.WriteString(".SS " + roffEscape(example.Title) + "\n")
+		to.WriteString(".nf\n" + instance.roffCodeBlockOf(strings.TrimSpace(example.CodeContent)+"\n") + ".fi\n")
+	}
+
+	instance.writeSeeAlso(ConfigurationManPageName, to)
+	return to.String(), nil
+}
+
+func (instance *ManRenderer) writeHeader(name string, section int, title string, to *strings.Builder) {
+	_, _ = fmt.Fprintf(to, ".TH \"%s\" \"%d\" \"\" \"%s %s\" \"%s\"\n", strings.ToUpper(name), section, instance.Name, instance.Version, title)
+}
+
+func (instance *ManRenderer) writeSection(name string, to *strings.Builder) {
+	to.WriteString(".SH \"" + name + "\"\n")
+}
+
+func (instance *ManRenderer) writeSeeAlso(exclude string, to *strings.Builder) {
+	instance.writeSection("SEE ALSO", to)
+	var references []string
+	for _, executableType := range []app.ExecutableType{app.Daemon, app.Control} {
+		if executableType.String() != exclude {
+			references = append(references, `\fB`+executableType.String()+`\fR(1)`)
+		}
+	}
+	if exclude != ConfigurationManPageName {
+		references = append(references, `\fB`+ConfigurationManPageName+`\fR(5)`)
+	}
+	to.WriteString(strings.Join(references, ", ") + "\n.PP\n" + instance.URL + "\n")
+}
+
+func (instance *ManRenderer) writeFlags(flags *kingpin.FlagGroupModel, to *strings.Builder) {
+	if flags == nil {
+		return
+	}
+	for _, flag := range flags.Flags {
+		if flag.Hidden {
+			continue
+		}
+		to.WriteString(".TP\n")
+		if flag.Short != 0 {
+			to.WriteString(`\fB\-` + roffEscapeCode(string(flag.Short)) + `\fR, `)
+		}
+		to.WriteString(`\fB\-\-` + roffEscapeCode(flag.Name) + `\fR`)
+		if !flag.IsBoolFlag() {
+			to.WriteString(`=\fI` + roffEscapeCode(flag.FormatPlaceHolder()) + `\fR`)
+		}
+		to.WriteString("\n" + roffEscapeLine(roffEscape(flag.Help)) + "\n")
+		if len(flag.Envar) > 0 {
+			to.WriteString(".br\n" + `Environment variable: \fB` + roffEscapeCode(flag.Envar) + `\fR` + "\n")
+		}
+	}
+}
+
+func (instance *ManRenderer) writeInclude(section string, name string, to *strings.Builder) error {
+	markup, err := instance.includeMarkdownAsText(name, 0, "", false, instance.formatRefAsCode)
+	if err != nil {
+		return err
+	}
+	// Every include starts with its title which is replaced by the section.
+	markup = strings.TrimSpace(markup)
+	if index := strings.Index(markup, "\n"); index >= 0 {
+		markup = markup[index+1:]
+	}
+	instance.writeSection(section, to)
+	to.WriteString(markdownToRoff(markup) + "\n")
+	return nil
+}
+
+func (instance *ManRenderer) writeDefinition(definition Definition, to *strings.Builder) error {
+	kind := definition.TypeName()
+	if simple, ok := definition.(*SimpleDefinition); ok {
+		valueType, err := instance.plainValueTypeOf(simple.ValueType())
+		if err != nil {
+			return err
+		}
+		kind = valueType
+	}
+	to.WriteString(".SS \"" + roffEscapeCode(instance.getDisplayIDOf(definition)) + " (" + roffEscapeCode(kind) + ")\"\n")
+	if err := instance.writeDescriptionOf(definition, to); err != nil {
+		return err
+	}
+	if object, ok := definition.(*ObjectDefinition); ok {
+		to.WriteString(".PP\n\\fBProperties\\fR\n")
+		for _, child := range object.Children() {
+			property := child.(*PropertyDefinition)
+			if property.Key() == "-" {
+				continue
+			}
+			valueType, err := instance.plainValueTypeOf(property.ValueType())
+			if err != nil {
+				return err
+			}
+			to.WriteString(".PP\n" + `\fB` + roffEscapeCode(property.Key()) + `\fR (` + roffEscapeCode(valueType) + ")")
+			if def := property.DefaultValue(); def != nil {
+				to.WriteString(` = \fB` + roffEscapeCode(*def) + `\fR`)
+			}
+			to.WriteString("\n.RS 4\n")
+			if err := instance.writeDescriptionOf(property, to); err != nil {
+				return err
+			}
+			to.WriteString(".RE\n")
+		}
+	}
+	if enum, ok := definition.(*EnumDefinition); ok {
+		to.WriteString(".PP\n\\fBElements\\fR\n")
+		for _, child := range enum.Children() {
+			element, ok := child.(*ElementDefinition)
+			if !ok {
+				continue
+			}
+			to.WriteString(".PP\n" + `\fB` + roffEscapeCode(element.Key()) + `\fR` + "\n.RS 4\n")
+			if err := instance.writeDescriptionOf(element, to); err != nil {
+				return err
+			}
+			to.WriteString(".RE\n")
+		}
+	}
+	return nil
+}
+
+func (instance *ManRenderer) writeDescriptionOf(definition Definition, to *strings.Builder) error {
+	description := schemaHeaderPattern.ReplaceAllString(strings.TrimSpace(definition.Description()), "")
+	markup, err := instance.prepareMarkdown(description, definition, 2, instance.formatRefAsCode)
+	if err != nil {
+		return err
+	}
+	if content := markdownToRoff(markup); len(content) > 0 {
+		to.WriteString(content + "\n")
+	}
+	return nil
+}
+
+func (instance *ManRenderer) commandUsageOf(command *kingpin.CmdModel) string {
+	usage := command.FullCommand
+	if command.FlagGroupModel != nil {
+		if summary := command.FlagSummary(); len(summary) > 0 {
+			usage += " " + summary
+		}
+	}
+	if command.ArgGroupModel != nil && len(command.Args) > 0 {
+		usage += " " + command.ArgSummary()
+	}
+	return usage
+}
+
+func (instance *ManRenderer) roffCodeBlockOf(content string) string {
+	result := new(strings.Builder)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if len(line) > 0 {
+			result.WriteString(roffEscapeLine(roffEscapeCode(line)))
+		}
+	}
+	return result.String()
+}
+
+func hasVisibleFlags(flags *kingpin.FlagGroupModel) bool {
+	if flags == nil {
+		return false
+	}
+	for _, flag := range flags.Flags {
+		if !flag.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+func argPlaceHolderOf(arg *kingpin.ArgModel) string {
+	if len(arg.PlaceHolder) > 0 {
+		return arg.PlaceHolder
+	}
+	return "<" + arg.Name + ">"
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"github.com/echocat/caretakerd/app"
+	"os"
+	"strings"
+)
+
+// MarkdownRenderer renders the manual as one GitHub flavored markdown document.
+type MarkdownRenderer struct {
+	*Renderer
+}
+
+// NewMarkdownRendererFor creates a new MarkdownRenderer based on the given renderer.
+func NewMarkdownRendererFor(renderer *Renderer) *MarkdownRenderer {
+	return &MarkdownRenderer{
+		Renderer: renderer,
+	}
+}
+
+// Execute executes the rendering.
+func (instance *MarkdownRenderer) Execute() (string, error) {
+	to := new(strings.Builder)
+	to.WriteString("# " + instance.Name + "\n\n" + instance.Description + "\n\n")
+	to.WriteString("Version: ``" + instance.Version + "``. The latest version of this manual could be found at <" + instance.URL + ">.\n\n")
+
+	for _, name := range []string{"features", "gettingStarted", "downloads"} {
+		if err := instance.writeInclude(name, 2, "", to); err != nil {
+			return "", err
+		}
+	}
+	if err := instance.writeCommands(to); err != nil {
+		return "", err
+	}
+	if err := instance.writeConfiguration(to); err != nil {
+		return "", err
+	}
+	if err := instance.writeDataTypes(to); err != nil {
+		return "", err
+	}
+	for _, name := range []string{"support", "contributing"} {
+		if err := instance.writeInclude(name, 2, "", to); err != nil {
+			return "", err
+		}
+	}
+	license, err := os.ReadFile(instance.Project.SrcRootPath + "/LICENSE")
+	if err != nil {
+		return "", err
+	}
+	instance.writeHeader(2, "license", "License", to)
+	to.WriteString("```\n" + strings.TrimSpace(string(license)) + "\n```\n")
+	return to.String(), nil
+}
+
+func (instance *MarkdownRenderer) writeCommands(to *strings.Builder) error {
+	instance.writeHeader(2, "commands", "Commands", to)
+	if err := instance.writeInclude("commands.description", 3, "commands", to); err != nil {
+		return err
+	}
+	for _, executableType := range app.AllExecutableTypes {
+		a, ok := instance.Apps[executableType]
+		if !ok {
+			continue
+		}
+		usage, err := instance.appUsageOf(executableType, a)
+		if err != nil {
+			return err
+		}
+		instance.writeHeader(3, "commands."+executableType.String(), executableType.String(), to)
+		to.WriteString("```\n" + usage + "\n```\n\n")
+	}
+	return nil
+}
+
+func (instance *MarkdownRenderer) writeConfiguration(to *strings.Builder) error {
+	instance.writeHeader(2, "configuration", "Configuration", to)
+
+	instance.writeHeader(3, "configuration.examples", "Examples", to)
+	examples, err := instance.collectExamples()
+	if err != nil {
+		return err
+	}
+	for _, example := range examples {
+		instance.writeHeader(4, example.ID, example.Title, to)
+		to.WriteString("```" + example.CodeType + "\n" + strings.TrimSpace(example.CodeContent) + "\n```\n\n")
+	}
+
+	instance.writeHeader(3, "configuration.structure", "Structure", to)
+	structure := new(strings.Builder)
+	if err := instance.renderPlainDefinitionStructure(0, instance.PickedDefinitions.RootID, structure); err != nil {
+		return err
+	}
+	to.WriteString("```yaml\n" + structure.String() + "```\n\n")
+
+	for _, name := range []string{"configuration.interpolation", "configuration.environmentMapping", "configuration.schema"} {
+		if err := instance.writeInclude(name, 3, "configuration", to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (instance *MarkdownRenderer) writeDataTypes(to *strings.Builder) error {
+	instance.writeHeader(2, "dataTypes", "Data Types", to)
+	for _, definition := range instance.PickedDefinitions.TopLevelDefinitions {
+		id := instance.getDisplayIDOf(definition)
+		to.WriteString("* [``" + id + "``](#configuration.dataType." + id + ")\n")
+	}
+	to.WriteString("\n")
+
+	for _, definition := range instance.PickedDefinitions.TopLevelDefinitions {
+		id, _ := instance.transformElementHTMLID(definition)
+		kind := definition.TypeName()
+		if simple, ok := definition.(*SimpleDefinition); ok {
+			valueType, err := instance.plainValueTypeOf(simple.ValueType())
+			if err != nil {
+				return err
+			}
+			kind = "``" + valueType + "``"
+		}
+		instance.writeHeader(3, id, "``"+instance.getDisplayIDOf(definition)+"`` "+kind, to)
+		if err := instance.writeDescriptionOf(definition, 4, to); err != nil {
+			return err
+		}
+
+		if object, ok := definition.(*ObjectDefinition); ok {
+			to.WriteString("#### Properties\n\n")
+			for _, child := range object.Children() {
+				property := child.(*PropertyDefinition)
+				if property.Key() == "-" {
+					continue
+				}
+				propertyID, _ := instance.transformElementHTMLID(property)
+				valueType, err := instance.plainValueTypeOf(property.ValueType())
+				if err != nil {
+					return err
+				}
+				title := "``" + property.Key() + "`` ``" + valueType + "``"
+				if def := property.DefaultValue(); def != nil {
+					title += " = ``" + *def + "``"
+				}
+				instance.writeHeader(5, propertyID, title, to)
+				if err := instance.writeDescriptionOf(property, 6, to); err != nil {
+					return err
+				}
+			}
+		}
+
+		if enum, ok := definition.(*EnumDefinition); ok {
+			to.WriteString("#### Elements\n\n")
+			for _, child := range enum.Children() {
+				element, ok := child.(*ElementDefinition)
+				if !ok {
+					continue
+				}
+				elementID, _ := instance.transformElementHTMLID(element)
+				instance.writeHeader(5, elementID, "``"+element.Key()+"``", to)
+				if err := instance.writeDescriptionOf(element, 6, to); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Writes a header with an explicit anchor because GitHub creates its own IDs out of the header's text.
+// This way every link of the HTML manual also works in the markdown document.
+func (instance *MarkdownRenderer) writeHeader(level int, id string, title string, to *strings.Builder) {
+	to.WriteString("<a id=\"" + id + "\"></a>\n\n" + strings.Repeat("#", level) + " " + title + "\n\n")
+}
+
+func (instance *MarkdownRenderer) writeInclude(name string, headerTypeStart int, headerIDPrefix string, to *strings.Builder) error {
+	markup, err := instance.includeMarkdownAsText(name, headerTypeStart, headerIDPrefix, true, instance.formatRefAsLink)
+	if err != nil {
+		return err
+	}
+	to.WriteString(strings.TrimSpace(markup) + "\n\n")
+	return nil
+}
+
+func (instance *MarkdownRenderer) writeDescriptionOf(definition Definition, headerTypeStart int, to *strings.Builder) error {
+	markup, err := instance.prepareMarkdown(strings.TrimSpace(definition.Description()), definition, headerTypeStart, instance.formatRefAsLink)
+	if err != nil {
+		return err
+	}
+	if len(markup) > 0 {
+		to.WriteString(markup + "\n\n")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/reference"
+	"reflect"
+	"strings"
+)
+
+// NewReferenceFor creates a reference of every definition of the configuration that could be embedded
+// into the binary. Descriptions stay markdown but every {@ref ...} is replaced by its display name.
+func NewReferenceFor(renderer *Renderer) (*reference.Reference, error) {
+	result := &reference.Reference{
+		Root:  renderer.transformIDType(renderer.PickedDefinitions.RootID),
+		Types: map[string]*reference.DataType{},
+	}
+	for _, definition := range renderer.PickedDefinitions.TopLevelDefinitions {
+		dataType, err := newReferenceDataTypeFor(renderer, definition)
+		if err != nil {
+			return nil, errors.New("Could not create reference for '%v'.", definition.ID()).CausedBy(err)
+		}
+		result.Types[dataType.Name] = dataType
+	}
+	return result, nil
+}
+
+func newReferenceDataTypeFor(renderer *Renderer, definition Definition) (*reference.DataType, error) {
+	description, err := referenceDescriptionOf(renderer, definition)
+	if err != nil {
+		return nil, err
+	}
+	result := &reference.DataType{
+		Name:        renderer.getDisplayIDOf(definition),
+		Description: description,
+	}
+	switch d := definition.(type) {
+	case *ObjectDefinition:
+		result.Kind = reference.Object
+		result.Properties = []reference.Property{}
+		for _, child := range d.Children() {
+			property := child.(*PropertyDefinition)
+			if property.Key() == "-" {
+				continue
+			}
+			valueType, err := renderer.plainValueTypeOf(property.ValueType())
+			if err != nil {
+				return nil, err
+			}
+			description, err := referenceDescriptionOf(renderer, property)
+			if err != nil {
+				return nil, err
+			}
+			result.Properties = append(result.Properties, reference.Property{
+				Key:         property.Key(),
+				ValueType:   valueType,
+				Default:     property.DefaultValue(),
+				Description: description,
+			})
+		}
+	case *EnumDefinition:
+		result.Kind = reference.Enum
+		result.Elements = []reference.Element{}
+		for _, child := range d.Children() {
+			element, ok := child.(*ElementDefinition)
+			if !ok {
+				continue
+			}
+			description, err := referenceDescriptionOf(renderer, element)
+			if err != nil {
+				return nil, err
+			}
+			result.Elements = append(result.Elements, reference.Element{
+				Key:         element.Key(),
+				Description: description,
+			})
+		}
+	case *SimpleDefinition:
+		result.Kind = reference.Simple
+		valueType, err := renderer.plainValueTypeOf(d.ValueType())
+		if err != nil {
+			return nil, err
+		}
+		result.ValueType = valueType
+	default:
+		return nil, errors.New("Unsupported definition type %v.", reflect.TypeOf(definition))
+	}
+	return result, nil
+}
+
+func referenceDescriptionOf(renderer *Renderer, definition Definition) (string, error) {
+	description := schemaHeaderPattern.ReplaceAllString(strings.TrimSpace(definition.Description()), "")
+	markup, err := renderer.prepareMarkdown(description, definition, 1, renderer.formatRefAsCode)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(markup), nil
+}
@@ -216,25 +216,28 @@ func newFunctionsFor(renderer *Renderer) template.FuncMap {
 			}
 			return string(content), err
 		},
-		"includeAppUsageOf": func(executableType app.ExecutableType, a *kingpin.Application) (string, error) {
-			buf := new(bytes.Buffer)
-			a.Name = executableType.String()
-			a.UsageWriter(buf)
-			context, err := a.ParseContext([]string{})
-			if err != nil {
-				return "", err
-			}
-			if err := a.UsageForContextWithTemplate(context, 2, kingpin.LongHelpTemplate); err != nil {
-				return "", err
-			}
-			content := strings.TrimSpace(buf.String())
-			content = renderer.replaceUsageEnvVarDisplaysIfNeeded(content)
-			return content, nil
-		},
+		"includeAppUsageOf":         renderer.appUsageOf,
 		"collectExamples":           renderer.collectExamples,
 		"transformElementHtmlId":    renderer.transformElementHTMLID,
 		"renderDefinitionStructure": renderer.renderDefinitionStructure,
+		"renderEnvironmentMappings": renderer.renderEnvironmentMappings,
+	}
+}
+
+func (instance *Renderer) appUsageOf(executableType app.ExecutableType, a *kingpin.Application) (string, error) {
+	buf := new(bytes.Buffer)
+	a.Name = executableType.String()
+	a.UsageWriter(buf)
+	context, err := a.ParseContext([]string{})
+	if err != nil {
+		return "", err
+	}
+	if err := a.UsageForContextWithTemplate(context, 2, kingpin.LongHelpTemplate); err != nil {
+		return "", err
 	}
+	content := strings.TrimSpace(buf.String())
+	content = instance.replaceUsageEnvVarDisplaysIfNeeded(content)
+	return content, nil
 }
 
 func (instance *Renderer) replaceUsageEnvVarDisplaysIfNeeded(content string) string {
@@ -406,30 +409,7 @@ func (instance *Renderer) renderMarkdown(of Describable, headerTypeStart int, he
 }
 
 func (instance *Renderer) renderMarkdownWithContext(markup string, context Describable, headerTypeStart int, headerIDPrefix string) (template.HTML, error) {
-	var err error
-
-	markup = lineBreakCorrectPattern.ReplaceAllString(markup, "\n")
-	markup = headerPrefixPattern.ReplaceAllString(markup, "$1"+strings.Repeat("#", headerTypeStart))
-	markup = refPropertyPattern.ReplaceAllStringFunc(markup, func(inline string) string {
-		match := refPropertyPattern.FindStringSubmatch(inline)
-		ref := match[1]
-		idType := instance.resolveRef(ref, context)
-		element, pErr := instance.PickedDefinitions.GetSourceElementBy(idType)
-		if pErr != nil {
-			err = pErr
-			return inline
-		}
-		if element != nil {
-			targetType := instance.getDisplayIDOf(element)
-			display := strings.TrimSpace(match[2])
-			if len(display) <= 0 {
-				display = targetType
-			}
-			return "[``" + display + "``](#configuration.dataType." + targetType + ")"
-		}
-		err = errors.New("Unknonwn reference: %v", ref)
-		return markup
-	})
+	markup, err := instance.prepareMarkdown(markup, context, headerTypeStart, instance.formatRefAsLink)
 	if err != nil {
 		return "", err
 	}
@@ -461,6 +441,58 @@ func (instance *Renderer) renderMarkdownWithContext(markup string, context Descr
 	return template.HTML(strings.TrimSpace(string(rhtml))), nil
 }
 
+// Normalizes the given markup, moves its headers to the given headerTypeStart and replaces every
+// {@ref ...} with the result of formatRef.
+func (instance *Renderer) prepareMarkdown(markup string, context Describable, headerTypeStart int, formatRef func(display string, targetType string) string) (string, error) {
+	var err error
+
+	markup = lineBreakCorrectPattern.ReplaceAllString(markup, "\n")
+	markup = instance.moveHeaders(markup, headerTypeStart)
+	markup = refPropertyPattern.ReplaceAllStringFunc(markup, func(inline string) string {
+		match := refPropertyPattern.FindStringSubmatch(inline)
+		ref := match[1]
+		idType := instance.resolveRef(ref, context)
+		element, pErr := instance.PickedDefinitions.GetSourceElementBy(idType)
+		if pErr != nil {
+			err = pErr
+			return inline
+		}
+		if element != nil {
+			targetType := instance.getDisplayIDOf(element)
+			display := strings.TrimSpace(match[2])
+			if len(display) <= 0 {
+				display = targetType
+			}
+			return formatRef(display, targetType)
+		}
+		err = errors.New("Unknonwn reference: %v", ref)
+		return markup
+	})
+	if err != nil {
+		return "", err
+	}
+	return markup, nil
+}
+
+// Moves every header of the given markup to the given headerTypeStart. Content of fenced code
+// blocks (like comments of shell scripts) is left untouched.
+func (instance *Renderer) moveHeaders(markup string, headerTypeStart int) string {
+	lines := strings.Split(markup, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		} else if !inFence {
+			lines[i] = headerPrefixPattern.ReplaceAllString(line, "$1"+strings.Repeat("#", headerTypeStart))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (instance *Renderer) formatRefAsLink(display string, targetType string) string {
+	return "[``" + display + "``](#configuration.dataType." + targetType + ")"
+}
+
 func (instance *Renderer) resolveRef(ref string, context Describable) IDType {
 	if context != nil && strings.HasPrefix(ref, "#") {
 		name := ref[1:]
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	roffHeaderPattern         = regexp.MustCompile(`^(#+)\s*(.*?)\s*(?:{#[^}]*})?\s*$`)
+	roffListItemPattern       = regexp.MustCompile(`^(\s*)([*+-]|[0-9]+\.)\s+(.*)$`)
+	roffTableSeparatorPattern = regexp.MustCompile(`^[\s|:-]+$`)
+	roffCodePattern           = regexp.MustCompile("``(.+?)``|`([^`]+)`")
+	roffCodePlaceholder       = regexp.MustCompile("\x00([0-9]+)\x00")
+	roffBoldPattern           = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	roffItalicPattern         = regexp.MustCompile(`(^|[\s(])\*([^*\s][^*]*?)\*`)
+	roffLinkPattern           = regexp.MustCompile(`\[([^\]]+)]\(([^)]+)\)`)
+	roffHTMLTagPattern        = regexp.MustCompile(`(?i)<br\s*/?>|</?div[^>]*>`)
+)
+
+// Converts the given markdown into roff. Only the subset of markdown that is used by
+// the descriptions of caretakerd is supported: headers, paragraphs, (nested) lists, tables,
+// block quotes, fenced code blocks and inline code, emphasis and links.
+func markdownToRoff(markup string) string {
+	converter := &roffConverter{}
+	lines := strings.Split(lineBreakCorrectPattern.ReplaceAllString(markup, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if converter.inFence {
+			if strings.HasPrefix(trimmed, "```") {
+				converter.write(".fi", ".RE")
+				converter.inFence = false
+				converter.paragraphRequired = true
+			} else {
+				converter.write(roffEscapeLine(roffEscapeCode(line)))
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			line = trimmed
+		}
+		if strings.HasPrefix(trimmed, "```") {
+			converter.closeLists()
+			converter.write(".PP", ".RS 4", ".nf")
+			converter.inFence = true
+		} else if len(trimmed) == 0 {
+			converter.paragraphRequired = true
+		} else if match := roffHeaderPattern.FindStringSubmatch(trimmed); match != nil {
+			converter.closeLists()
+			if len(match[1]) <= 1 {
+				converter.write(".SS " + roffInline(match[2]))
+				converter.paragraphRequired = false
+			} else {
+				converter.write(".PP", `\fB`+roffInline(match[2])+`\fR`)
+				converter.paragraphRequired = true
+			}
+		} else if strings.HasPrefix(trimmed, "|") {
+			end := i
+			for end+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end+1]), "|") {
+				end++
+			}
+			converter.closeLists()
+			converter.writeTable(lines[i : end+1])
+			converter.paragraphRequired = true
+			i = end
+		} else if match := roffListItemPattern.FindStringSubmatch(line); match != nil {
+			converter.writeListItem(len(match[1]), match[2], match[3])
+		} else if len(converter.listIndents) > 0 && (!converter.paragraphRequired || len(line) > len(strings.TrimLeft(line, " \t"))) {
+			if converter.paragraphRequired {
+				converter.write(".IP")
+				converter.paragraphRequired = false
+			}
+			converter.write(roffEscapeLine(roffInline(trimmed)))
+		} else {
+			converter.closeLists()
+			if converter.paragraphRequired {
+				converter.write(".PP")
+				converter.paragraphRequired = false
+			}
+			converter.write(roffEscapeLine(roffInline(trimmed)))
+		}
+	}
+	if converter.inFence {
+		converter.write(".fi", ".RE")
+	}
+	converter.closeLists()
+	return strings.TrimSpace(converter.result.String())
+}
+
+type roffConverter struct {
+	result            strings.Builder
+	inFence           bool
+	paragraphRequired bool
+	listIndents       []int
+}
+
+func (instance *roffConverter) write(lines ...string) {
+	for _, line := range lines {
+		instance.result.WriteString(line)
+		instance.result.WriteByte('\n')
+	}
+}
+
+func (instance *roffConverter) writeListItem(indent int, marker string, text string) {
+	for len(instance.listIndents) > 0 && indent < instance.listIndents[len(instance.listIndents)-1] {
+		instance.popList()
+	}
+	if len(instance.listIndents) == 0 || indent > instance.listIndents[len(instance.listIndents)-1] {
+		if len(instance.listIndents) > 0 {
+			instance.write(".RS 2")
+		}
+		instance.listIndents = append(instance.listIndents, indent)
+	}
+	if _, err := strconv.Atoi(strings.TrimSuffix(marker, ".")); err == nil {
+		instance.write(".IP " + marker + " 4")
+	} else {
+		instance.write(`.IP \(bu 2`)
+	}
+	instance.write(roffEscapeLine(roffInline(text)))
+	instance.paragraphRequired = false
+}
+
+func (instance *roffConverter) popList() {
+	instance.listIndents = instance.listIndents[:len(instance.listIndents)-1]
+	if len(instance.listIndents) > 0 {
+		instance.write(".RE")
+	}
+}
+
+func (instance *roffConverter) closeLists() {
+	if len(instance.listIndents) == 0 {
+		return
+	}
+	for len(instance.listIndents) > 0 {
+		instance.popList()
+	}
+	instance.paragraphRequired = true
+}
+
+// Writes every row of the table as tagged paragraph. The first column becomes the tag and
+// the other columns its content - prefixed by the column header if there is more than one.
+func (instance *roffConverter) writeTable(lines []string) {
+	var header []string
+	for _, line := range lines {
+		if roffTableSeparatorPattern.MatchString(line) {
+			continue
+		}
+		cells := roffTableCellsOf(line)
+		if header == nil {
+			header = cells
+			continue
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		instance.write(".TP", roffEscapeLine(roffInline(cells[0])))
+		first := true
+		for j := 1; j < len(cells); j++ {
+			if len(cells[j]) == 0 {
+				continue
+			}
+			if !first {
+				instance.write(".br")
+			}
+			first = false
+			if len(header) > 2 && j < len(header) && len(header[j]) > 0 {
+				instance.write(roffEscapeLine(roffInline(header[j] + ": " + cells[j])))
+			} else {
+				instance.write(roffEscapeLine(roffInline(cells[j])))
+			}
+		}
+	}
+}
+
+func roffTableCellsOf(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// Converts inline markdown elements (code, emphasis, links) into roff font changes and escapes
+// every character that has a special meaning in roff.
+func roffInline(text string) string {
+	var codes []string
+	text = roffCodePattern.ReplaceAllStringFunc(text, func(code string) string {
+		match := roffCodePattern.FindStringSubmatch(code)
+		content := match[1]
+		if len(content) == 0 {
+			content = match[2]
+		}
+		codes = append(codes, `\fB`+roffEscapeCode(strings.TrimSpace(content))+`\fR`)
+		return "\x00" + strconv.Itoa(len(codes)-1) + "\x00"
+	})
+	text = roffEscape(text)
+	text = roffHTMLTagPattern.ReplaceAllString(text, " ")
+	text = roffLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := roffLinkPattern.FindStringSubmatch(link)
+		if strings.HasPrefix(match[2], "#") {
+			return match[1]
+		}
+		return match[1] + " (" + match[2] + ")"
+	})
+	text = roffBoldPattern.ReplaceAllString(text, `\fB$1\fR`)
+	text = roffItalicPattern.ReplaceAllString(text, `$1\fI$2\fR`)
+	return roffCodePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		index, _ := strconv.Atoi(roffCodePlaceholder.FindStringSubmatch(placeholder)[1])
+		return codes[index]
+	})
+}
+
+// Escapes every backslash of the given text.
+func roffEscape(text string) string {
+	return strings.Replace(text, `\`, `\e`, -1)
+}
+
+// Like roffEscape but also escapes hyphens to prevent that they are rendered as
+// typographic dashes which could not be used in a terminal.
+func roffEscapeCode(text string) string {
+	return strings.Replace(roffEscape(text), "-", `\-`, -1)
+}
+
+// Prevents that a line is interpreted as roff request.
+func roffEscapeLine(line string) string {
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		return `\&` + line
+	}
+	return line
+}
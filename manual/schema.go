@@ -0,0 +1,237 @@
+package main
+
+import (
+	"github.com/echocat/caretakerd"
+	"github.com/echocat/caretakerd/errors"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+var (
+	schemaRefPattern          = regexp.MustCompile(`{@ref +([^}\s]+)\s*([^}]*)}`)
+	schemaHeaderPattern       = regexp.MustCompile(`(?m)^#+\s*Description\s*\n+`)
+	schemaPrimitiveTypeToType = map[string]string{
+		"string":  "string",
+		"bool":    "boolean",
+		"int":     "integer",
+		"int8":    "integer",
+		"int16":   "integer",
+		"int32":   "integer",
+		"int64":   "integer",
+		"uint":    "integer",
+		"uint8":   "integer",
+		"uint16":  "integer",
+		"uint32":  "integer",
+		"uint64":  "integer",
+		"float32": "number",
+		"float64": "number",
+	}
+)
+
+// Schema represents a JSON Schema document or a part of it.
+type Schema map[string]interface{}
+
+type schemaGenerator struct {
+	project           Project
+	pickedDefinitions *PickedDefinitions
+	definitions       map[string]Schema
+}
+
+// NewSchemaFor creates a JSON Schema (draft-07) for the root of the given PickedDefinitions.
+// Every non inlined definition is referenced from the "definitions" section of the schema.
+func NewSchemaFor(project Project, pickedDefinitions *PickedDefinitions) (Schema, error) {
+	generator := &schemaGenerator{
+		project:           project,
+		pickedDefinitions: pickedDefinitions,
+		definitions:       map[string]Schema{},
+	}
+	root, err := generator.schemaForDefinitionWithID(pickedDefinitions.RootID)
+	if err != nil {
+		return nil, err
+	}
+	root["$schema"] = schemaVersion
+	root["$id"] = caretakerd.URL + "/" + caretakerd.DaemonName + ".schema.json"
+	root["title"] = caretakerd.DaemonName + " configuration"
+	root["definitions"] = generator.definitions
+	return root, nil
+}
+
+func (instance *schemaGenerator) schemaForDefinitionWithID(id IDType) (Schema, error) {
+	definition, err := instance.pickedDefinitions.GetSourceElementBy(id)
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		return nil, errors.New("There is no definition for '%v'.", id)
+	}
+	switch d := definition.(type) {
+	case *ObjectDefinition:
+		return instance.schemaForObject(d)
+	case *EnumDefinition:
+		return instance.schemaForEnum(d), nil
+	case *SimpleDefinition:
+		result, err := instance.schemaForType(d.ValueType())
+		if err != nil {
+			return nil, err
+		}
+		instance.describe(result, d)
+		return result, nil
+	}
+	return nil, errors.New("Unsupported definition type %v of '%v'.", reflect.TypeOf(definition), id)
+}
+
+func (instance *schemaGenerator) schemaForObject(definition *ObjectDefinition) (Schema, error) {
+	properties := Schema{}
+	for _, child := range definition.Children() {
+		property := child.(*PropertyDefinition)
+		if property.Key() == "-" {
+			continue
+		}
+		schema, err := instance.schemaForType(property.ValueType())
+		if err != nil {
+			return nil, errors.New("Could not create schema for property '%s' of '%v'.", property.Key(), definition.ID()).CausedBy(err)
+		}
+		if _, isRef := schema["$ref"]; isRef {
+			// Siblings of $ref are ignored by draft-07. So we have to wrap it.
+			schema = Schema{"allOf": []interface{}{schema}}
+		}
+		instance.describe(schema, property)
+		if def := property.DefaultValue(); def != nil {
+			if value, ok := instance.defaultValueFor(*def, property.ValueType()); ok {
+				schema["default"] = value
+			}
+		}
+		properties[property.Key()] = schema
+	}
+	result := Schema{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	instance.describe(result, definition)
+	return result, nil
+}
+
+func (instance *schemaGenerator) schemaForEnum(definition *EnumDefinition) Schema {
+	values := []interface{}{}
+	for _, child := range definition.Children() {
+		if element, ok := child.(*ElementDefinition); ok {
+			values = append(values, element.Key())
+		}
+	}
+	result := Schema{
+		"type": "string",
+		"enum": values,
+	}
+	instance.describe(result, definition)
+	return result
+}
+
+func (instance *schemaGenerator) schemaForType(t Type) (Schema, error) {
+	switch v := t.(type) {
+	case IDType:
+		return instance.schemaForIDType(v)
+	case PointerType:
+		return instance.schemaForType(v.Value)
+	case ArrayType:
+		items, err := instance.schemaForType(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items}, nil
+	case MapType:
+		values, err := instance.schemaForType(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "object", "additionalProperties": values}, nil
+	}
+	return nil, errors.New("Unsupported type %v.", t)
+}
+
+func (instance *schemaGenerator) schemaForIDType(id IDType) (Schema, error) {
+	if id.Primitive {
+		if t, ok := schemaPrimitiveTypeToType[id.Name]; ok {
+			return Schema{"type": t}, nil
+		}
+		return nil, errors.New("Unsupported primitive type %v.", id)
+	}
+	if inlined := instance.pickedDefinitions.FindInlinedFor(id); inlined != nil && inlined.Inlined() {
+		return instance.schemaForType(inlined.ValueType())
+	}
+	name := instance.definitionNameOf(id)
+	if _, ok := instance.definitions[name]; !ok {
+		// Reserve the name before creating the schema to prevent endless recursions.
+		instance.definitions[name] = Schema{}
+		schema, err := instance.schemaForDefinitionWithID(id)
+		if err != nil {
+			return nil, err
+		}
+		instance.definitions[name] = schema
+	}
+	return Schema{"$ref": "#/definitions/" + name}, nil
+}
+
+func (instance *schemaGenerator) definitionNameOf(id IDType) string {
+	if id.Package == instance.project.RootPackage {
+		return id.Name
+	}
+	return strings.TrimPrefix(id.Package, instance.project.RootPackage+"/") + "." + id.Name
+}
+
+func (instance *schemaGenerator) describe(schema Schema, definition Describable) {
+	description := strings.TrimSpace(definition.Description())
+	description = schemaHeaderPattern.ReplaceAllString(description, "")
+	description = schemaRefPattern.ReplaceAllStringFunc(description, func(ref string) string {
+		match := schemaRefPattern.FindStringSubmatch(ref)
+		if display := strings.TrimSpace(match[2]); len(display) > 0 {
+			return "`" + display + "`"
+		}
+		target := match[1]
+		if index := strings.LastIndexAny(target, "#."); index >= 0 {
+			target = target[index+1:]
+		}
+		return "`" + target + "`"
+	})
+	if len(description) > 0 {
+		schema["description"] = strings.TrimSpace(description)
+	}
+}
+
+// Returns the given default value (which is notated in yaml) if it fits to the given type.
+func (instance *schemaGenerator) defaultValueFor(plain string, t Type) (interface{}, bool) {
+	schema, err := instance.schemaForType(t)
+	if err != nil {
+		return nil, false
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = instance.definitions[strings.TrimPrefix(ref, "#/definitions/")]
+	}
+	expectedType, _ := schema["type"].(string)
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(plain), &value); err != nil {
+		return nil, false
+	}
+	switch value.(type) {
+	case string:
+		return value, expectedType == "string"
+	case bool:
+		return value, expectedType == "boolean"
+	case int, int64, uint64:
+		if expectedType == "string" {
+			return plain, true
+		}
+		return value, expectedType == "integer" || expectedType == "number"
+	case float64:
+		return value, expectedType == "number"
+	case []interface{}:
+		return value, expectedType == "array"
+	case map[string]interface{}:
+		return value, expectedType == "object"
+	}
+	return nil, false
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"github.com/echocat/caretakerd/errors"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var (
+	includeDivWrapperPattern = regexp.MustCompile(`(?m)^<div[^>]*>(.*)</div>\s*$`)
+	includeHeaderIDPattern   = regexp.MustCompile(`(?m)^(#+.*?)\s*{#([^}]*)}\s*$`)
+)
+
+// Functions that are available inside of markdown includes if they are rendered to text formats
+// like man pages or GitHub flavored markdown.
+var textIncludeFunctions = template.FuncMap{
+	"renderEnvironmentMappings": environmentMappingsMarkdownOf,
+}
+
+// Reads the markdown include with the given name and executes its template directives.
+// In contrast to the HTML version of includes the result is still markdown. Explicit header IDs
+// are either removed or - if withAnchors is true - replaced by HTML anchors in front of the header.
+func (instance *Renderer) includeMarkdownAsText(name string, headerTypeStart int, headerIDPrefix string, withAnchors bool, formatRef func(display string, targetType string) string) (string, error) {
+	source := instance.Project.SrcRootPath + "/manual/includes/" + name + ".md"
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return "", err
+	}
+	markup := lineBreakCorrectPattern.ReplaceAllString(string(content), "\n")
+	markup = includeDivWrapperPattern.ReplaceAllString(markup, "$1")
+	markup = includeHeaderIDPattern.ReplaceAllStringFunc(markup, func(header string) string {
+		match := includeHeaderIDPattern.FindStringSubmatch(header)
+		if !withAnchors {
+			return match[1]
+		}
+		id := match[2]
+		if len(headerIDPrefix) > 0 {
+			id = headerIDPrefix + "." + id
+		}
+		return "<a id=\"" + id + "\"></a>\n\n" + match[1]
+	})
+	tmpl, err := template.New(source).Funcs(textIncludeFunctions).Parse(markup)
+	if err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, instance); err != nil {
+		return "", err
+	}
+	return instance.prepareMarkdown(buf.String(), nil, headerTypeStart, formatRef)
+}
+
+func (instance *Renderer) formatRefAsCode(display string, _ string) string {
+	return "``" + display + "``"
+}
+
+// Returns the given type as plain text like "[]service.Config".
+func (instance *Renderer) plainValueTypeOf(t Type) (string, error) {
+	switch v := t.(type) {
+	case IDType:
+		if inlined := instance.PickedDefinitions.FindInlinedFor(v); inlined != nil && inlined.Inlined() {
+			return instance.plainValueTypeOf(inlined.ValueType())
+		}
+		return instance.transformIDType(v), nil
+	case ArrayType:
+		value, err := instance.plainValueTypeOf(v.Value)
+		return "[]" + value, err
+	case PointerType:
+		return instance.plainValueTypeOf(v.Value)
+	case MapType:
+		key, err := instance.plainValueTypeOf(v.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := instance.plainValueTypeOf(v.Value)
+		return "[" + key + "]" + value, err
+	}
+	return "", errors.New("Unknown type: %v", t)
+}
+
+// Returns the first sentence of the description of the given definition as plain text.
+func (instance *Renderer) plainExcerptOf(definition Definition) (string, error) {
+	excerpt := definition.Description()
+	if match := excerptFromCommentExtractionPattern.FindStringSubmatch(excerpt); len(match) == 2 {
+		excerpt = match[1]
+	}
+	excerpt = strings.Join(strings.Fields(excerpt), " ")
+	excerpt, err := instance.prepareMarkdown(excerpt, definition, 0, instance.formatRefAsCode)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(excerpt, "`", "", -1), nil
+}
+
+// Renders the structure of the definition with the given id as plain YAML document including
+// a short hint as comment for every property.
+func (instance *Renderer) renderPlainDefinitionStructure(level int, id IDType, to *strings.Builder) error {
+	definition, err := instance.PickedDefinitions.GetSourceElementBy(id)
+	if err != nil {
+		return err
+	}
+	objectDefinition, ok := definition.(*ObjectDefinition)
+	if !ok {
+		return nil
+	}
+	indent := strings.Repeat("    ", level)
+	for _, child := range objectDefinition.Children() {
+		propertyDefinition := child.(*PropertyDefinition)
+		if propertyDefinition.Key() == "-" {
+			continue
+		}
+		valueType, err := instance.plainValueTypeOf(propertyDefinition.ValueType())
+		if err != nil {
+			return err
+		}
+		excerpt, err := instance.plainExcerptOf(propertyDefinition)
+		if err != nil {
+			return err
+		}
+		to.WriteString(indent + "# (" + valueType + ") " + excerpt + "\n")
+		to.WriteString(indent + propertyDefinition.Key() + ":")
+		if def := propertyDefinition.DefaultValue(); def != nil {
+			to.WriteString(" " + *def)
+		}
+		to.WriteString("\n")
+
+		childID := ExtractValueIDType(propertyDefinition.ValueType())
+		inlined := instance.PickedDefinitions.FindInlinedFor(childID)
+		for inlined != nil && inlined.Inlined() {
+			childID = ExtractValueIDType(inlined.ValueType())
+			inlined = instance.PickedDefinitions.FindInlinedFor(childID)
+		}
+		if instance.isMapType(propertyDefinition.ValueType()) {
+			to.WriteString(indent + "    <" + instance.singular(propertyDefinition.Key()) + " name>:\n")
+			err = instance.renderPlainDefinitionStructure(level+2, childID, to)
+		} else {
+			err = instance.renderPlainDefinitionStructure(level+1, childID, to)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package reference provides the documentation of every property of the caretakerd configuration.
+// It is derived from the same definitions as the manual and could be used where the manual is not available.
+package reference
+
+import (
+	// Required to embed the reference.
+	_ "embed"
+	"encoding/json"
+	"github.com/echocat/caretakerd/errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileName is the name of the file that contains the reference.
+const FileName = "caretakerd.reference.json"
+
+// JSON contains the reference of the caretakerd configuration.
+// It is generated from the sources using "go run ./build generate".
+//
+//go:embed caretakerd.reference.json
+var JSON []byte
+
+var (
+	embedded     *Reference
+	embeddedErr  error
+	embeddedOnce = new(sync.Once)
+)
+
+// Kind represents the kind of a DataType.
+type Kind string
+
+const (
+	// Object is a data type with properties.
+	Object Kind = "object"
+	// Enum is a data type with a fixed set of elements.
+	Enum Kind = "enum"
+	// Simple is a data type that is based on another (mostly primitive) type.
+	Simple Kind = "simple"
+)
+
+// Reference contains every data type of the configuration.
+type Reference struct {
+	// Root is the name of the data type of the root of the configuration.
+	Root string `json:"root"`
+	// Types contains every data type by its name.
+	Types map[string]*DataType `json:"types"`
+}
+
+// DataType describes a data type of the configuration.
+type DataType struct {
+	// Name of this data type like "service.Service".
+	Name string `json:"name"`
+	// Kind of this data type.
+	Kind Kind `json:"kind"`
+	// ValueType contains the type this data type is based on if Kind is Simple.
+	ValueType string `json:"valueType,omitempty"`
+	// Description of this data type in markdown.
+	Description string `json:"description,omitempty"`
+	// Properties of this data type if Kind is Object.
+	Properties []Property `json:"properties,omitempty"`
+	// Elements of this data type if Kind is Enum.
+	Elements []Element `json:"elements,omitempty"`
+}
+
+// Property describes a property of an Object.
+type Property struct {
+	// Key of this property like it is used in the configuration.
+	Key string `json:"key"`
+	// ValueType of this property like "string", "[]string" or "[string]service.Service".
+	ValueType string `json:"valueType"`
+	// Default value of this property.
+	Default *string `json:"default,omitempty"`
+	// Description of this property in markdown.
+	Description string `json:"description,omitempty"`
+}
+
+// Element describes a possible value of an Enum.
+type Element struct {
+	// Key of this element like it is used in the configuration.
+	Key string `json:"key"`
+	// Description of this element in markdown.
+	Description string `json:"description,omitempty"`
+}
+
+// Entry is the result of Reference.Find.
+type Entry struct {
+	// Path of this entry like "services.*.stopSignalTarget".
+	Path string
+	// Property that was found. This is nil for the root of the configuration.
+	Property *Property
+	// ValueType of the found property.
+	ValueType string
+	// DataType of the found property. If the property is a list or a map this is the data type of
+	// its elements. This is nil for primitive types like string.
+	DataType *DataType
+}
+
+// Get returns the reference that is embedded in the binary.
+func Get() (*Reference, error) {
+	embeddedOnce.Do(func() {
+		result := &Reference{}
+		if err := json.Unmarshal(JSON, result); err != nil {
+			embeddedErr = errors.New("Could not parse embedded reference.").CausedBy(err)
+			return
+		}
+		embedded = result
+	})
+	return embedded, embeddedErr
+}
+
+// Find returns the entry for the given path. Every element of the path is separated by "." and
+// elements of lists and maps are selected using any name or index like "*".
+// An empty path or "." returns the root of the configuration.
+func (instance *Reference) Find(path string) (*Entry, error) {
+	result := &Entry{
+		Path:      path,
+		ValueType: instance.Root,
+	}
+	if len(path) > 0 && path != "." {
+		for i, segment := range strings.Split(path, ".") {
+			if elementType, ok := elementTypeOf(result.ValueType); ok {
+				result.ValueType = elementType
+				continue
+			}
+			dataType := instance.Types[result.ValueType]
+			if dataType == nil || dataType.Kind != Object {
+				return nil, errors.New("'%s' of type %s does not have any properties.", parentPathOf(path, i), result.ValueType)
+			}
+			property := dataType.Property(segment)
+			if property == nil {
+				return nil, errors.New("Unknown property '%s' at '%s'. Possible properties are: %s", segment, parentPathOf(path, i), strings.Join(dataType.PropertyKeys(), ", "))
+			}
+			result.Property = property
+			result.ValueType = property.ValueType
+		}
+	}
+	result.DataType = instance.Types[innermostTypeOf(result.ValueType)]
+	return result, nil
+}
+
+// Property returns the property with the given key or nil if there is no such property.
+func (instance DataType) Property(key string) *Property {
+	for i, property := range instance.Properties {
+		if property.Key == key {
+			return &instance.Properties[i]
+		}
+	}
+	return nil
+}
+
+// PropertyKeys returns the sorted keys of every property of this data type.
+func (instance DataType) PropertyKeys() []string {
+	result := make([]string, len(instance.Properties))
+	for i, property := range instance.Properties {
+		result[i] = property.Key
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Returns the type of the elements of the given list ("[]<type>") or map ("[<key>]<type>") type.
+func elementTypeOf(valueType string) (string, bool) {
+	if !strings.HasPrefix(valueType, "[") {
+		return "", false
+	}
+	depth := 0
+	for i, c := range valueType {
+		if c == '[' {
+			depth++
+		} else if c == ']' {
+			depth--
+			if depth == 0 {
+				return valueType[i+1:], true
+			}
+		}
+	}
+	return "", false
+}
+
+func innermostTypeOf(valueType string) string {
+	for {
+		elementType, ok := elementTypeOf(valueType)
+		if !ok {
+			return valueType
+		}
+		valueType = elementType
+	}
+}
+
+func parentPathOf(path string, index int) string {
+	segments := strings.Split(path, ".")
+	if index == 0 {
+		return "."
+	}
+	return strings.Join(segments[:index], ".")
+}
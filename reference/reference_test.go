@@ -0,0 +1,69 @@
+package reference
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ReferenceTest struct{}
+
+func init() {
+	Suite(&ReferenceTest{})
+}
+
+func (s *ReferenceTest) TestGet(c *C) {
+	ref, err := Get()
+	c.Assert(err, IsNil)
+	c.Assert(ref.Types[ref.Root], NotNil)
+	c.Assert(ref.Types[ref.Root].Kind, Equals, Object)
+}
+
+func (s *ReferenceTest) TestFindRoot(c *C) {
+	ref, err := Get()
+	c.Assert(err, IsNil)
+	for _, path := range []string{"", "."} {
+		entry, err := ref.Find(path)
+		c.Assert(err, IsNil)
+		c.Assert(entry.Property, IsNil)
+		c.Assert(entry.ValueType, Equals, ref.Root)
+		c.Assert(entry.DataType, Equals, ref.Types[ref.Root])
+	}
+}
+
+func (s *ReferenceTest) TestFindPropertyOfService(c *C) {
+	ref, err := Get()
+	c.Assert(err, IsNil)
+	entry, err := ref.Find("services.*.stopSignalTarget")
+	c.Assert(err, IsNil)
+	c.Assert(entry.Property.Key, Equals, "stopSignalTarget")
+	c.Assert(*entry.Property.Default, Equals, `"processGroup"`)
+	c.Assert(entry.ValueType, Equals, "values.SignalTarget")
+	c.Assert(entry.DataType.Kind, Equals, Enum)
+	var keys []string
+	for _, element := range entry.DataType.Elements {
+		keys = append(keys, element.Key)
+	}
+	c.Assert(keys, DeepEquals, []string{"process", "processGroup", "mixed"})
+}
+
+func (s *ReferenceTest) TestFindMap(c *C) {
+	ref, err := Get()
+	c.Assert(err, IsNil)
+	entry, err := ref.Find("services")
+	c.Assert(err, IsNil)
+	c.Assert(entry.ValueType, Equals, "[string]service.Service")
+	c.Assert(entry.DataType.Name, Equals, "service.Service")
+
+	entry, err = ref.Find("services.king")
+	c.Assert(err, IsNil)
+	c.Assert(entry.ValueType, Equals, "service.Service")
+	c.Assert(entry.Property.Key, Equals, "services")
+}
+
+func (s *ReferenceTest) TestFindUnknown(c *C) {
+	ref, err := Get()
+	c.Assert(err, IsNil)
+	_, err = ref.Find("services.*.foo")
+	c.Assert(err, ErrorMatches, "Unknown property 'foo' at 'services.\\*'. Possible properties are: access, .*")
+	_, err = ref.Find("rpc.enabled.foo")
+	c.Assert(err, ErrorMatches, "'rpc.enabled' of type bool does not have any properties.")
+}
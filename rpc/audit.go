@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/logger"
+	"github.com/emicklei/go-restful/v3"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum number of bytes of a response that are recorded as result.
+const maxAuditResultLength = 512
+
+// Maximum number of bytes of a request body that are read to record its fields as parameters.
+const maxAuditBodyLength = 64 * 1024
+
+// Attribute of a request a handler could set to record its value as result instead of the response.
+// This is required if the response contains secrets.
+const auditResultAttribute = "auditResult"
+
+// AuditRecord describes an RPC call that changed something.
+type AuditRecord struct {
+	Timestamp  time.Time              `json:"timestamp"`
+	Identity   string                 `json:"identity"`
+	Source     string                 `json:"source"`
+	Action     access.Verb            `json:"action"`
+	Target     string                 `json:"target"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Status     int                    `json:"status"`
+	Result     string                 `json:"result"`
+}
+
+func (instance AuditRecord) String() string {
+	result := fmt.Sprintf("%s from %s: %v %s", instance.Identity, instance.Source, instance.Action, instance.Target)
+	if len(instance.Parameters) > 0 {
+		keys := make([]string, 0, len(instance.Parameters))
+		for key := range instance.Parameters {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = fmt.Sprintf("%s=%v", key, instance.Parameters[key])
+		}
+		result += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return result + fmt.Sprintf(" -> %d %s", instance.Status, instance.Result)
+}
+
+// Records are still written by requests that are in progress while the auditor is closed.
+// Because of this write has to ignore records after close.
+type auditor interface {
+	write(record AuditRecord)
+	close()
+}
+
+// Problems while writing records of a FileAudit are logged to the given logger.
+func newAuditor(conf AuditConfig, log *logger.Logger) (auditor, error) {
+	switch conf.Target {
+	case NoAudit:
+		return nil, nil
+	case LoggerAudit:
+		log, err := logger.NewLogger(conf.Logger, "audit", nil)
+		if err != nil {
+			return nil, errors.New("Could not create logger for audit.").CausedBy(err)
+		}
+		return &loggerAuditor{logger: log, lock: new(sync.Mutex)}, nil
+	case FileAudit:
+		f, err := os.OpenFile(conf.Filename.String(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, errors.New("Could not open audit file '%v'.", conf.Filename).CausedBy(err)
+		}
+		return &fileAuditor{file: f, logger: log, lock: new(sync.Mutex)}, nil
+	}
+	return nil, errors.New("Unknown audit target %v.", conf.Target)
+}
+
+type loggerAuditor struct {
+	logger *logger.Logger
+	lock   *sync.Mutex
+}
+
+func (instance *loggerAuditor) write(record AuditRecord) {
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	if instance.logger != nil {
+		instance.logger.Log(logger.Info, "%v", record)
+	}
+}
+
+func (instance *loggerAuditor) close() {
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	if instance.logger != nil {
+		instance.logger.Close()
+		instance.logger = nil
+	}
+}
+
+type fileAuditor struct {
+	file   *os.File
+	logger *logger.Logger
+	lock   *sync.Mutex
+}
+
+func (instance *fileAuditor) write(record AuditRecord) {
+	line := new(bytes.Buffer)
+	encoder := json.NewEncoder(line)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(record); err != nil {
+		instance.logger.LogProblem(err, logger.Error, "Could not encode audit record: %v", record)
+		return
+	}
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	if instance.file == nil {
+		return
+	}
+	// Every record is synced to not lose it if caretakerd crashes afterwards.
+	if _, err := instance.file.Write(line.Bytes()); err != nil {
+		instance.logger.LogProblem(err, logger.Error, "Could not write audit record to '%v': %v", instance.file.Name(), record)
+	} else if err := instance.file.Sync(); err != nil {
+		instance.logger.LogProblem(err, logger.Error, "Could not sync audit record to '%v': %v", instance.file.Name(), record)
+	}
+}
+
+func (instance *fileAuditor) close() {
+	instance.lock.Lock()
+	defer instance.lock.Unlock()
+	if instance.file != nil {
+		_ = instance.file.Close()
+		instance.file = nil
+	}
+}
+
+// Wraps the given handler to write an audit record for every call if an audit target is configured.
+func (instance *RPC) audited(verb access.Verb, handler restful.RouteFunction) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		target := instance.auditor
+		if target == nil {
+			handler(request, response)
+			return
+		}
+		record := AuditRecord{
+			Timestamp:  time.Now(),
+			Identity:   instance.identityOf(request),
+			Source:     request.Request.RemoteAddr,
+			Action:     verb,
+			Target:     request.PathParameter("serviceName"),
+			Parameters: parametersOf(request, response),
+		}
+		recorder := &resultRecorder{ResponseWriter: response.ResponseWriter}
+		response.ResponseWriter = recorder
+		handler(request, response)
+		record.Status = response.StatusCode()
+		record.Result = strings.TrimSpace(recorder.result.String())
+		if result, ok := request.Attribute(auditResultAttribute).(string); ok {
+			record.Result = result
+		}
+		target.write(record)
+	}
+}
+
+// Describes who sent the given request using the credentials it presented.
+func (instance *RPC) identityOf(request *restful.Request) string {
+	hr := request.Request
+	if _, ok := bearerTokenOf(hr); ok {
+		names := instance.accessNamesOf(request)
+		if len(names) == 0 {
+			return "token:<unknown>"
+		}
+		return "token:" + strings.Join(names, ",")
+	}
+	if peer, ok := hr.Context().Value(peerContextKey{}).(*access.Peer); ok {
+		return fmt.Sprintf("peer:uid=%d,gid=%d,pid=%d", peer.UID, peer.GID, peer.PID)
+	}
+	if cs := hr.TLS; cs != nil && len(cs.PeerCertificates) > 0 {
+		return "cert:CN=" + cs.PeerCertificates[0].Subject.CommonName
+	}
+	return "anonymous"
+}
+
+func (instance *RPC) accessNamesOf(request *restful.Request) []string {
+	var result []string
+	for _, acc := range instance.accessesOf(request) {
+		result = append(result, acc.Name())
+	}
+	return result
+}
+
+// Returns the query parameters and the fields of a JSON body of the given request.
+// The body is restored afterwards to be still readable by the handler. Only the first maxAuditBodyLength
+// bytes of the body are read - larger bodies are rejected because the handler could not read them completely.
+func parametersOf(request *restful.Request, response *restful.Response) map[string]interface{} {
+	result := map[string]interface{}{}
+	hr := request.Request
+	for key, values := range hr.URL.Query() {
+		if len(values) == 1 {
+			result[key] = values[0]
+		} else {
+			result[key] = values
+		}
+	}
+	if hr.Body != nil {
+		limited := http.MaxBytesReader(response, hr.Body, maxAuditBodyLength)
+		body, err := io.ReadAll(limited)
+		hr.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), limited), limited}
+		var fields map[string]interface{}
+		if err == nil && len(body) > 0 && json.Unmarshal(body, &fields) == nil {
+			for key, value := range fields {
+				result[key] = value
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Records the beginning of a response to use it as result of an audit record.
+type resultRecorder struct {
+	http.ResponseWriter
+	result bytes.Buffer
+}
+
+func (instance *resultRecorder) Write(b []byte) (int, error) {
+	if remaining := maxAuditResultLength - instance.result.Len(); remaining > 0 {
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		instance.result.Write(b[:remaining])
+	}
+	return instance.ResponseWriter.Write(b)
+}
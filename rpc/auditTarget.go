@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AuditTarget indicates where audit records of RPC actions are written to.
+type AuditTarget int
+
+const (
+	// @id none
+	//
+	// No audit records are written.
+	NoAudit AuditTarget = 0
+	// @id logger
+	//
+	// Audit records are written as human readable messages to the dedicated
+	// {@ref .AuditConfig#Logger logger} of the audit.
+	LoggerAudit AuditTarget = 1
+	// @id file
+	//
+	// Audit records are appended as JSON lines (one JSON object per line) to the
+	// configured {@ref .AuditConfig#Filename filename}.
+	FileAudit AuditTarget = 2
+)
+
+// AllAuditTargets contains all possible variants of AuditTarget.
+var AllAuditTargets = []AuditTarget{
+	NoAudit,
+	LoggerAudit,
+	FileAudit,
+}
+
+func (instance AuditTarget) String() string {
+	s, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// CheckedString is like String but also returns an optional error if there are
+// validation errors.
+func (instance AuditTarget) CheckedString() (string, error) {
+	switch instance {
+	case NoAudit:
+		return "none", nil
+	case LoggerAudit:
+		return "logger", nil
+	case FileAudit:
+		return "file", nil
+	}
+	return "", fmt.Errorf("illegal audit target: %d", instance)
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are problems while transforming the string.
+func (instance *AuditTarget) Set(value string) error {
+	lowerValue := strings.ToLower(value)
+	for _, candidate := range AllAuditTargets {
+		if candidate.String() == lowerValue {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal audit target: %s", value)
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance AuditTarget) MarshalYAML() (interface{}, error) {
+	return instance.CheckedString()
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *AuditTarget) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance AuditTarget) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *AuditTarget) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance AuditTarget) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
@@ -1,7 +1,11 @@
 package rpc
 
 import (
+	"encoding/json"
+	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/defaults"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/logger"
 	"github.com/echocat/caretakerd/values"
 	"runtime"
 )
@@ -29,6 +33,134 @@ type Config struct {
 	//
 	// For details of possible values see {@ref github.com/echocat/caretakerd/values.SocketAddress}.
 	Listen values.SocketAddress `json:"listen" yaml:"listen"`
+
+	// @default "auto"
+	//
+	// How connections to {@ref #Listen listen} are secured and how their clients are identified.
+	//
+	// For details of possible values see {@ref .Security}.
+	Security Security `json:"security" yaml:"security"`
+
+	// @default 0600
+	//
+	// Permission in filesystem of the socket file if {@ref #Listen listen} is an unix socket.
+	// Only users with write permission of the socket file are able to connect.
+	//
+	// > **Hint:** Connections using unix sockets are not encrypted. Use the access type
+	// > {@ref github.com/echocat/caretakerd/access.Type#PeerCredentials peerCredentials} to grant
+	// > permissions to connecting processes.
+	SocketPermission access.FilePermission `json:"socketPermission" yaml:"socketPermission"`
+
+	// @default ""
+	//
+	// If set, this user owns the socket file if {@ref #Listen listen} is an unix socket.
+	// Otherwise it is owned by the user caretakerd is running with.
+	SocketUser values.String `json:"socketUser,omitempty" yaml:"socketUser,omitempty"`
+
+	// @default ""
+	//
+	// If set, this group owns the socket file if {@ref #Listen listen} is an unix socket.
+	// Otherwise it is owned by the group caretakerd is running with.
+	SocketGroup values.String `json:"socketGroup,omitempty" yaml:"socketGroup,omitempty"`
+
+	// @default []
+	//
+	// Additional addresses caretakerd RPC interface is listened to - each with its own
+	// {@ref .ListenerConfig#Security security}. This makes it for example possible to listen
+	// on an unix socket for local processes and on TCP for remote tooling at the same time.
+	//
+	// > **Hint:** {@ref #Listen listen} is still used by caretakerctl to connect to caretakerd.
+	Listeners []ListenerConfig `json:"listeners,omitempty" yaml:"listeners,omitempty"`
+
+	// @default {}
+	//
+	// Roles which grant specific verbs on specific services. They are bound to caretakerctl
+	// or services using the {@ref github.com/echocat/caretakerd/access.Config#Roles roles}
+	// property of their access.
+	//
+	// Example:
+	// ```yaml
+	// rpc:
+	//     roles:
+	//         restartWorkers:
+	//             verbs: ["get", "restart"]
+	//             selector:
+	//                 group: workers
+	// services:
+	//     worker1:
+	//         labels:
+	//             group: workers
+	//         access:
+	//             type: generateToEnvironment
+	//             roles: ["restartWorkers"]
+	// ```
+	//
+	// For details see {@ref github.com/echocat/caretakerd/access.Role}.
+	Roles access.Roles `json:"roles,omitempty" yaml:"roles,omitempty"`
+
+	// Records every RPC call that changes something - like starting or stopping a service -
+	// together with the identity of the client.
+	//
+	// For details see {@ref .AuditConfig}.
+	Audit AuditConfig `json:"audit" yaml:"audit,omitempty"`
+}
+
+// # Description
+//
+// Defines where audit records of RPC calls are written to. Every call that changes something
+// creates a record that contains the time, the identity and address of the client, the action,
+// the target service, the parameters and the result.
+type AuditConfig struct {
+	// @default "none"
+	//
+	// Where the audit records are written to.
+	//
+	// For details see possible values {@ref .AuditTarget}.
+	Target AuditTarget `json:"target" yaml:"target"`
+
+	// @default ""
+	//
+	// File the audit records are appended to as JSON lines if {@ref #Target target} is
+	// {@ref .AuditTarget#FileAudit file}. The file will be created if it does not exist - but not the parent directory.
+	Filename values.String `json:"filename,omitempty" yaml:"filename,omitempty"`
+
+	// Dedicated logger the audit records are written to if {@ref #Target target} is
+	// {@ref .AuditTarget#LoggerAudit logger}.
+	//
+	// For details see {@ref github.com/echocat/caretakerd/logger.Config}.
+	Logger logger.Config `json:"logger" yaml:"logger,omitempty"`
+}
+
+// # Description
+//
+// Defines an additional address caretakerd RPC interface is listened to.
+type ListenerConfig struct {
+	// Address where caretakerd RPC interface is listened to.
+	//
+	// For details of possible values see {@ref github.com/echocat/caretakerd/values.SocketAddress}.
+	Listen values.SocketAddress `json:"listen" yaml:"listen"`
+
+	// @default "auto"
+	//
+	// How connections to {@ref #Listen listen} are secured and how their clients are identified.
+	//
+	// For details of possible values see {@ref .Security}.
+	Security Security `json:"security" yaml:"security"`
+
+	// @default 0600
+	//
+	// Permission in filesystem of the socket file if {@ref #Listen listen} is an unix socket.
+	SocketPermission access.FilePermission `json:"socketPermission" yaml:"socketPermission"`
+
+	// @default ""
+	//
+	// If set, this user owns the socket file if {@ref #Listen listen} is an unix socket.
+	SocketUser values.String `json:"socketUser,omitempty" yaml:"socketUser,omitempty"`
+
+	// @default ""
+	//
+	// If set, this group owns the socket file if {@ref #Listen listen} is an unix socket.
+	SocketGroup values.String `json:"socketGroup,omitempty" yaml:"socketGroup,omitempty"`
 }
 
 // NewConfigFor creates a new instance of Config.
@@ -40,8 +172,13 @@ func NewConfigFor(platform string) Config {
 
 func (instance *Config) init(platform string) {
 	values.SetDefaultsTo(map[string]interface{}{
-		"Enabled": values.Boolean(false),
-		"Listen":  defaults.ListenAddressFor(platform),
+		"Enabled":          values.Boolean(false),
+		"Listen":           defaults.ListenAddressFor(platform),
+		"Security":         Auto,
+		"SocketPermission": access.FilePermission(0600),
+		"Listeners":        []ListenerConfig{},
+		"Roles":            access.Roles{},
+		"Audit":            NewAuditConfig(),
 	}, instance)
 }
 
@@ -53,11 +190,126 @@ func (instance *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return unmarshal((*noMethods)(instance))
 }
 
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Config) UnmarshalJSON(b []byte) error {
+	instance.init(runtime.GOOS)
+
+	type noMethods Config
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
+
 // Validate validates actions on this object and returns an error object there are any.
 func (instance Config) Validate() error {
 	err := instance.Enabled.Validate()
 	if err == nil {
-		err = instance.Listen.Validate()
+		err = instance.Roles.Validate()
+	}
+	if err == nil {
+		err = instance.Audit.Validate()
+	}
+	if err == nil {
+		for _, listener := range instance.AllListeners() {
+			if err = listener.Validate(); err != nil {
+				break
+			}
+		}
 	}
 	return err
 }
+
+// AllListeners returns the primary listener defined by {@ref #Listen listen} followed
+// by all additional {@ref #Listeners listeners}.
+func (instance Config) AllListeners() []ListenerConfig {
+	result := []ListenerConfig{{
+		Listen:           instance.Listen,
+		Security:         instance.Security,
+		SocketPermission: instance.SocketPermission,
+		SocketUser:       instance.SocketUser,
+		SocketGroup:      instance.SocketGroup,
+	}}
+	return append(result, instance.Listeners...)
+}
+
+func (instance *ListenerConfig) init() {
+	values.SetDefaultsTo(map[string]interface{}{
+		"Security":         Auto,
+		"SocketPermission": access.FilePermission(0600),
+	}, instance)
+}
+
+// Set sets the given address to the current object using the default values of every other property.
+// This is used by environment variables like "CTD.RPC_LISTENERS.0=unix:///run/caretakerd.sock".
+func (instance *ListenerConfig) Set(value string) error {
+	instance.init()
+	return instance.Listen.Set(value)
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *ListenerConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	instance.init()
+
+	type noMethods ListenerConfig
+	return unmarshal((*noMethods)(instance))
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *ListenerConfig) UnmarshalJSON(b []byte) error {
+	instance.init()
+
+	type noMethods ListenerConfig
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
+
+// Validate validates actions on this object and returns an error object there are any.
+func (instance ListenerConfig) Validate() error {
+	if err := instance.Listen.Validate(); err != nil {
+		return err
+	}
+	if err := instance.Security.Validate(); err != nil {
+		return err
+	}
+	if instance.Security == PeerCredentials && instance.Listen.Protocol == values.TCP {
+		return errors.New("Security %v is not possible for address %v because it is no unix socket.", instance.Security, instance.Listen)
+	}
+	return nil
+}
+
+// NewAuditConfig creates a new instance of AuditConfig.
+func NewAuditConfig() AuditConfig {
+	result := AuditConfig{}
+	result.init()
+	return result
+}
+
+func (instance *AuditConfig) init() {
+	(*instance).Target = NoAudit
+	(*instance).Filename = values.String("")
+	(*instance).Logger = logger.NewConfig()
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *AuditConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	instance.init()
+
+	type noMethods AuditConfig
+	return unmarshal((*noMethods)(instance))
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *AuditConfig) UnmarshalJSON(b []byte) error {
+	instance.init()
+
+	type noMethods AuditConfig
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
+
+// Validate validates actions on this object and returns an error object there are any.
+func (instance AuditConfig) Validate() error {
+	if err := instance.Target.Validate(); err != nil {
+		return err
+	}
+	if instance.Target == FileAudit && instance.Filename.IsTrimmedEmpty() {
+		return errors.New("There is no filename set for audit target %v.", instance.Target)
+	}
+	return instance.Logger.Validate()
+}
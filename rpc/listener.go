@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// The first file descriptor passed in by the parent process. 0, 1 and 2 are stdin, stdout and stderr.
+const listenFDsStart = 3
+
+// Creates the listener for the configured address. If it is an unix socket, a stale socket file
+// of a previous run is removed and the configured permission and ownership are applied.
+func listen(conf ListenerConfig) (net.Listener, error) {
+	address := conf.Listen
+	if address.Protocol == values.FD {
+		return listenOnFD(address)
+	}
+	if address.Protocol != values.Unix || address.IsAbstract() {
+		return net.Listen(address.AsScheme(), address.AsAddress())
+	}
+	filename := address.AsAddress()
+	if fileInfo, err := os.Lstat(filename); err == nil && fileInfo.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(filename); err != nil {
+			return nil, errors.New("Could not remove stale socket file '%s'.", filename).CausedBy(err)
+		}
+	}
+	result, err := net.Listen(address.AsScheme(), filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySocketFileAttributes(conf, filename); err != nil {
+		_ = result.Close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// Creates the listener for a socket that was passed in by the parent process. The target of the
+// address is either the number of the file descriptor or its name inside of LISTEN_FDNAMES.
+func listenOnFD(address values.SocketAddress) (net.Listener, error) {
+	fd, err := resolveFD(address.Target)
+	if err != nil {
+		return nil, err
+	}
+	file := os.NewFile(uintptr(fd), "fd://"+strconv.Itoa(fd))
+	if file == nil {
+		return nil, errors.New("File descriptor %d is not valid.", fd)
+	}
+	// net.FileListener duplicates the file descriptor.
+	defer file.Close()
+	result, err := net.FileListener(file)
+	if err != nil {
+		return nil, errors.New("Could not listen on file descriptor %d.", fd).CausedBy(err)
+	}
+	return result, nil
+}
+
+// Resolves the given file descriptor number or name using the protocol of systemd socket activation:
+// LISTEN_FDS contains the number of passed file descriptors starting at 3 and LISTEN_FDNAMES their names.
+func resolveFD(target string) (int, error) {
+	if fd, err := strconv.Atoi(target); err == nil {
+		if fd < 0 {
+			return 0, errors.New("Illegal file descriptor %d.", fd)
+		}
+		return fd, nil
+	}
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, errors.New("Could not resolve file descriptor '%s' because LISTEN_PID is not the pid of this process.", target)
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return 0, errors.New("Could not resolve file descriptor '%s' because LISTEN_FDS is not set.", target)
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < count && i < len(names); i++ {
+		if names[i] == target {
+			return listenFDsStart + i, nil
+		}
+	}
+	return 0, errors.New("There is no file descriptor named '%s' in LISTEN_FDNAMES.", target)
+}
+
+func applySocketFileAttributes(conf ListenerConfig, filename string) error {
+	if err := os.Chmod(filename, conf.SocketPermission.AsFileMode()); err != nil {
+		return errors.New("Could not set permission of socket file '%s' to %v.", filename, conf.SocketPermission).CausedBy(err)
+	}
+	if conf.SocketUser.IsTrimmedEmpty() && conf.SocketGroup.IsTrimmedEmpty() {
+		return nil
+	}
+	uid, gid := -1, -1
+	if !conf.SocketUser.IsTrimmedEmpty() {
+		u, err := user.Lookup(conf.SocketUser.String())
+		if err != nil {
+			return errors.New("Could not set ownership of socket file '%s' to user '%v'.", filename, conf.SocketUser).CausedBy(err)
+		}
+		if uid, err = strconv.Atoi(u.Uid); err != nil {
+			return errors.New("Could not set ownership of socket file '%s' to user '%v'.", filename, conf.SocketUser).CausedBy(err)
+		}
+	}
+	if !conf.SocketGroup.IsTrimmedEmpty() {
+		g, err := user.LookupGroup(conf.SocketGroup.String())
+		if err != nil {
+			return errors.New("Could not set ownership of socket file '%s' to group '%v'.", filename, conf.SocketGroup).CausedBy(err)
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return errors.New("Could not set ownership of socket file '%s' to group '%v'.", filename, conf.SocketGroup).CausedBy(err)
+		}
+	}
+	if err := os.Chown(filename, uid, gid); err != nil {
+		return errors.New("Could not set ownership of socket file '%s'.", filename).CausedBy(err)
+	}
+	return nil
+}
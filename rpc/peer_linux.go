@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"github.com/echocat/caretakerd/access"
+	"net"
+	"syscall"
+)
+
+// Returns the credentials of the process on the other side of the given connection
+// if it is an unix socket connection. Otherwise nil is returned.
+func peerOf(conn net.Conn) (*access.Peer, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var ucred *syscall.Ucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	return &access.Peer{
+		PID: ucred.Pid,
+		UID: ucred.Uid,
+		GID: ucred.Gid,
+	}, nil
+}
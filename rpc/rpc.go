@@ -1,8 +1,11 @@
 package rpc
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/control"
 	"github.com/echocat/caretakerd/errors"
@@ -17,6 +20,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Caretakerd represents a caretakerd instance.
@@ -47,21 +52,22 @@ func (i ListenerStoppedError) Error() string {
 	return "stopped"
 }
 
-// StoppableListener is a reimplementation of net.TCPListener which is a graceful stoppable.
+// StoppableListener is a wrapper around a net.Listener which is a graceful stoppable.
 type StoppableListener struct {
-	*net.TCPListener
+	net.Listener
 	stop chan int
 }
 
 // NewStoppableListener creates a new instance of StoppableListener and encapsulate the given listener.
 func NewStoppableListener(l net.Listener) (*StoppableListener, error) {
-	tcpL, ok := l.(*net.TCPListener)
-	if !ok {
-		return nil, errors.New("Cannot wrap listener")
+	switch l.(type) {
+	case *net.TCPListener, *net.UnixListener:
+	default:
+		return nil, errors.New("Cannot wrap listener of type %T.", l)
 	}
 	result := &StoppableListener{
-		TCPListener: tcpL,
-		stop:        make(chan int),
+		Listener: l,
+		stop:     make(chan int),
 	}
 	return result, nil
 }
@@ -69,11 +75,11 @@ func NewStoppableListener(l net.Listener) (*StoppableListener, error) {
 // Accept returns a new connection if a remote client connects to the server.
 // This method is a blocking method.
 func (sl *StoppableListener) Accept() (net.Conn, error) {
-	newConn, err := sl.TCPListener.Accept()
+	newConn, err := sl.Listener.Accept()
 	if isClosedError(err) {
 		return nil, ListenerStoppedError{}
 	}
-	return newConn, nil
+	return newConn, err
 }
 
 func isClosedError(what error) bool {
@@ -92,19 +98,30 @@ type RPC struct {
 	conf       Config
 	execution  Execution
 	caretakerd Caretakerd
-	listener   *StoppableListener
+	listeners  []*StoppableListener
 	logger     *logger.Logger
+	auditor    auditor
+
+	tlsLock      *sync.Mutex
+	tlsConfig    *tls.Config
+	tlsConfigPem []byte
 }
 
 // NewRPC creates a new instance of RPC.
-func NewRPC(conf Config, execution Execution, executable Caretakerd, log *logger.Logger) *RPC {
+func NewRPC(conf Config, execution Execution, executable Caretakerd, log *logger.Logger) (*RPC, error) {
+	audit, err := newAuditor(conf.Audit, log)
+	if err != nil {
+		return nil, errors.New("Could not create audit.").CausedBy(err)
+	}
 	rpc := RPC{
 		conf:       conf,
 		execution:  execution,
 		caretakerd: executable,
 		logger:     log,
+		auditor:    audit,
+		tlsLock:    new(sync.Mutex),
 	}
-	return &rpc
+	return &rpc, nil
 }
 
 // Start starts the RPC instance in the background.
@@ -132,45 +149,108 @@ func (instance *RPC) Run() {
 	ws.Route(ws.GET("/service/{serviceName}/config").To(instance.serviceConfig))
 	ws.Route(ws.GET("/service/{serviceName}/state").To(instance.serviceStatus))
 	ws.Route(ws.GET("/service/{serviceName}/pid").To(instance.servicePid))
+	ws.Route(ws.GET("/service/{serviceName}/wait").To(instance.serviceWait))
 
-	ws.Route(ws.POST("/service/{serviceName}/start").To(instance.serviceStart))
-	ws.Route(ws.POST("/service/{serviceName}/restart").To(instance.serviceRestart))
-	ws.Route(ws.POST("/service/{serviceName}/status").To(instance.serviceStart))
-	ws.Route(ws.POST("/service/{serviceName}/stop").To(instance.serviceStop))
-	ws.Route(ws.POST("/service/{serviceName}/kill").To(instance.serviceKill))
-	ws.Route(ws.POST("/service/{serviceName}/signal").To(instance.serviceSignal))
+	ws.Route(ws.POST("/service/{serviceName}/start").To(instance.audited(access.Start, instance.serviceStart)))
+	ws.Route(ws.POST("/service/{serviceName}/restart").To(instance.audited(access.Restart, instance.serviceRestart)))
+	ws.Route(ws.POST("/service/{serviceName}/stop").To(instance.audited(access.Stop, instance.serviceStop)))
+	ws.Route(ws.POST("/service/{serviceName}/kill").To(instance.audited(access.Kill, instance.serviceKill)))
+	ws.Route(ws.POST("/service/{serviceName}/signal").To(instance.audited(access.Signal, instance.serviceSignal)))
+
+	ws.Route(ws.POST("/keystore/revoke").To(instance.audited(access.Revoke, instance.keyStoreRevoke)))
+	ws.Route(ws.POST("/keystore/issue").To(instance.audited(access.Issue, instance.keyStoreIssue)))
 
 	container.Add(ws)
 
 	server := &http.Server{
-		Handler:  container,
-		ErrorLog: log.New(instance.logger.NewOutputStreamWrapperFor(logger.Debug), "", 0),
-	}
-	instance.logger.Log(logger.Debug, "Rpc will bind to %v...", instance.conf.Listen)
-	listener, err := net.Listen(instance.conf.Listen.AsScheme(), instance.conf.Listen.AsAddress())
-	if err != nil {
-		log.Fatal(err)
+		Handler:     container,
+		ErrorLog:    log.New(instance.logger.NewOutputStreamWrapperFor(logger.Debug), "", 0),
+		ConnContext: instance.connContext,
 	}
-	sl, err2 := NewStoppableListener(listener)
-	if err2 != nil {
-		panics.New("Could not create listener.").CausedBy(err2).Throw()
+	defer func() {
+		if instance.auditor != nil {
+			instance.auditor.close()
+		}
+	}()
+	targets := make([]net.Listener, 0, len(instance.conf.AllListeners()))
+	for _, conf := range instance.conf.AllListeners() {
+		instance.logger.Log(logger.Debug, "Rpc will bind to %v...", conf.Listen)
+		listener, err := listen(conf)
+		if err != nil {
+			instance.Stop()
+			log.Fatal(err)
+		}
+		sl, err2 := NewStoppableListener(listener)
+		if err2 != nil {
+			_ = listener.Close()
+			instance.Stop()
+			panics.New("Could not create listener for %v.", conf.Listen).CausedBy(err2).Throw()
+		}
+		(*instance).listeners = append((*instance).listeners, sl)
+		targets = append(targets, instance.secureIfRequired(conf, sl))
 	}
 	defer func() {
-		(*instance).listener = nil
+		(*instance).listeners = nil
 	}()
-	(*instance).listener = sl
-	if err := server.Serve(instance.secure(sl)); err != nil {
-		if _, ok := err.(ListenerStoppedError); !ok {
-			panics.New("Could not listen.").CausedBy(err2).Throw()
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target net.Listener) {
+			defer panics.DefaultPanicHandler()
+			defer wg.Done()
+			if err := server.Serve(target); err != nil {
+				if _, ok := err.(ListenerStoppedError); !ok {
+					panics.New("Could not listen.").CausedBy(err).Throw()
+				}
+			}
+		}(target)
+	}
+	wg.Wait()
+}
+
+// Wraps the given listener with TLS unless its connections are authorized using the credentials of the peer.
+func (instance *RPC) secureIfRequired(conf ListenerConfig, in *StoppableListener) net.Listener {
+	_, isUnix := in.Listener.(*net.UnixListener)
+	security := conf.Security.ResolveFor(conf.Listen)
+	if security == Auto {
+		security = TLS
+		if isUnix {
+			security = PeerCredentials
 		}
 	}
+	if security == PeerCredentials {
+		if !isUnix {
+			panics.New("Security %v is not possible for %v because it is no unix socket.", security, conf.Listen).Throw()
+		}
+		return in
+	}
+	return instance.secure(in)
 }
 
 func (instance *RPC) secure(in net.Listener) net.Listener {
+	if _, err := instance.currentTLSConfig(); err != nil {
+		panics.New("Could not load pem of caretakerd.").CausedBy(err).Throw()
+	}
+	// The config is resolved for every connection because the certificate of caretakerd could be renewed while running.
+	return tls.NewListener(in, &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return instance.currentTLSConfig()
+		},
+	})
+}
+
+func (instance *RPC) currentTLSConfig() (*tls.Config, error) {
 	sec := instance.caretakerd.KeyStore()
-	keyPair, err := tls.X509KeyPair(sec.PEM(), sec.PEM())
+	p := sec.PEM()
+	instance.tlsLock.Lock()
+	defer instance.tlsLock.Unlock()
+	if instance.tlsConfig != nil && bytes.Equal(instance.tlsConfigPem, p) {
+		return instance.tlsConfig, nil
+	}
+
+	keyPair, err := tls.X509KeyPair(p, p)
 	if err != nil {
-		panics.New("Could not load pem of caretakerd.").CausedBy(err).Throw()
+		return nil, err
 	}
 
 	rootCas := x509.NewCertPool()
@@ -178,122 +258,225 @@ func (instance *RPC) secure(in net.Listener) net.Listener {
 		rootCas.AddCert(cert)
 	}
 
-	out := tls.NewListener(in, &tls.Config{
+	clientAuth := tls.RequireAndVerifyClientCert
+	if instance.isAnyTokenAccessConfigured() {
+		// Clients using bearer tokens have no certificate. Every request is still checked by checkPermission.
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	instance.tlsConfig = &tls.Config{
 		NextProtos:   []string{"http/1.1"},
 		Certificates: []tls.Certificate{keyPair},
 		RootCAs:      rootCas,
 		ClientCAs:    rootCas,
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-	})
-
-	return out
+		ClientAuth:   clientAuth,
+	}
+	instance.tlsConfigPem = p
+	return instance.tlsConfig, nil
 }
 
 // Stop stops the current RPC instance if it is running.
 // This method is a blocking method.
 func (instance *RPC) Stop() {
-	listener := (*instance).listener
-	if listener != nil {
+	for _, listener := range (*instance).listeners {
 		_ = listener.Close()
 	}
 }
 
-func (instance *RPC) checkPermission(request *restful.Request, permissionChecker func(access.Access) bool) bool {
-	if request != nil {
-		hr := request.Request
-		if hr != nil {
-			cs := hr.TLS
-			if cs != nil {
-				for _, cert := range cs.PeerCertificates {
-					ctl := instance.caretakerd.Control()
-					acc := ctl.Access()
-					if acc.IsCertValid(cert) && permissionChecker(*acc) {
-						return true
-					}
-					for _, serv := range *instance.caretakerd.Services() {
-						acc := serv.Access()
-						if acc.IsCertValid(cert) && permissionChecker(*acc) {
-							return true
-						}
-					}
+type peerContextKey struct{}
+
+// Stores the credentials of the peer (if available) in the context of every request of the given connection.
+func (instance *RPC) connContext(ctx context.Context, conn net.Conn) context.Context {
+	peer, err := peerOf(conn)
+	if err != nil {
+		instance.logger.LogProblem(err, logger.Warning, "Could not retrieve credentials of peer %v.", conn.RemoteAddr())
+		return ctx
+	}
+	if peer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, peerContextKey{}, peer)
+}
+
+// Returns every access whose credentials are presented by the given request. If a bearer token is
+// present only accesses using tokens are considered, otherwise the credentials of the peer or the
+// certificates of the TLS connection.
+func (instance *RPC) accessesOf(request *restful.Request) []*access.Access {
+	if request == nil || request.Request == nil {
+		return nil
+	}
+	hr := request.Request
+	var matches func(acc *access.Access) bool
+	if token, ok := bearerTokenOf(hr); ok {
+		matches = func(acc *access.Access) bool {
+			return acc.IsTokenValid(token)
+		}
+	} else if peer, ok := hr.Context().Value(peerContextKey{}).(*access.Peer); ok {
+		matches = func(acc *access.Access) bool {
+			return acc.IsPeerValid(peer)
+		}
+	} else if cs := hr.TLS; cs != nil {
+		ks := instance.caretakerd.KeyStore()
+		matches = func(acc *access.Access) bool {
+			for _, cert := range cs.PeerCertificates {
+				if !ks.IsRevoked(cert) && acc.IsCertValid(cert) {
+					return true
 				}
 			}
+			return false
+		}
+	} else {
+		return nil
+	}
+	var result []*access.Access
+	if acc := instance.caretakerd.Control().Access(); matches(acc) {
+		result = append(result, acc)
+	}
+	for _, serv := range *instance.caretakerd.Services() {
+		if acc := serv.Access(); matches(acc) {
+			result = append(result, acc)
+		}
+	}
+	if acc, ok := instance.issuedAccessOf(request); ok {
+		result = append(result, acc)
+	}
+	return result
+}
+
+// Returns the access of a certificate that was issued using the issue endpoint. Only certificates
+// that were issued by the keyStore of caretakerd and that are not revoked are considered.
+func (instance *RPC) issuedAccessOf(request *restful.Request) (*access.Access, bool) {
+	hr := request.Request
+	if _, ok := bearerTokenOf(hr); ok {
+		return nil, false
+	}
+	if _, ok := hr.Context().Value(peerContextKey{}).(*access.Peer); ok {
+		return nil, false
+	}
+	if hr.TLS == nil || len(hr.TLS.PeerCertificates) == 0 {
+		return nil, false
+	}
+	ks := instance.caretakerd.KeyStore()
+	cert := hr.TLS.PeerCertificates[0]
+	if !ks.HasIssued(cert) || ks.IsRevoked(cert) {
+		return nil, false
+	}
+	return access.NewIssuedAccess(cert)
+}
+
+// Checks if the given request is allowed to do the given verb on the given service. If target is nil
+// the verb has to be allowed on every service.
+func (instance *RPC) checkPermission(request *restful.Request, verb access.Verb, target *service.Service) bool {
+	var t *access.Target
+	if target != nil {
+		t = &access.Target{
+			Name:   target.Name(),
+			Labels: target.Config().Labels,
+		}
+	}
+	for _, acc := range instance.accessesOf(request) {
+		if acc.IsGranted(verb, t, instance.conf.Roles) {
+			return true
 		}
 	}
 	return false
 }
 
-func (instance *RPC) hasReadPermission(request *restful.Request) bool {
-	return instance.checkPermission(request, func(a access.Access) bool {
-		return a.HasReadPermission()
-	})
+// Returns the token of an "Authorization: Bearer <token>" header if present.
+func bearerTokenOf(request *http.Request) (string, bool) {
+	authorization := request.Header.Get("Authorization")
+	if authorization == "" {
+		return "", false
+	}
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
 }
 
-func (instance *RPC) hasWritePermission(request *restful.Request) bool {
-	return instance.checkPermission(request, func(a access.Access) bool {
-		return a.HasWritePermission()
-	})
+// Returns true if the control or any service is authorized using bearer tokens.
+// In this case clients without certificates have to be able to connect.
+func (instance *RPC) isAnyTokenAccessConfigured() bool {
+	if instance.caretakerd.Control().Access().Type().IsUsingTokens() {
+		return true
+	}
+	for _, serv := range *instance.caretakerd.Services() {
+		if serv.Access().Type().IsUsingTokens() {
+			return true
+		}
+	}
+	return false
 }
 
-func (instance *RPC) onReadPermission(request *restful.Request, response *restful.Response, doThis func()) {
-	if instance.hasReadPermission(request) {
+func (instance *RPC) onPermission(request *restful.Request, response *restful.Response, verb access.Verb, doThis func()) {
+	if instance.checkPermission(request, verb, nil) {
 		doThis()
 	} else {
-		_ = response.WriteErrorString(http.StatusForbidden, "No read permission to instance endpoint.")
+		_ = response.WriteErrorString(http.StatusForbidden, "No "+verb.String()+" permission to instance endpoint.")
 	}
 }
 
-func (instance *RPC) onWritePermission(request *restful.Request, response *restful.Response, doThis func()) {
-	if instance.hasWritePermission(request) {
-		doThis()
+// Executes the given function with the service of the request if the verb is allowed on it.
+// The existence of a service is only revealed if the verb is allowed on every service.
+func (instance *RPC) onServicePermission(request *restful.Request, response *restful.Response, verb access.Verb, doThis func(sc *service.Service)) {
+	serviceName := request.PathParameter("serviceName")
+	svc := instance.caretakerd.Services().Get(serviceName)
+	if svc != nil && instance.checkPermission(request, verb, svc) {
+		doThis(svc)
+	} else if svc == nil && instance.checkPermission(request, verb, nil) {
+		_ = response.WriteError(http.StatusNotFound, errors.New("Service '%s' does not exist.", serviceName))
 	} else {
-		_ = response.WriteErrorString(http.StatusForbidden, "No write permission to instance endpoint.")
+		_ = response.WriteErrorString(http.StatusForbidden, "No "+verb.String()+" permission to instance endpoint.")
 	}
 }
 
 func (instance *RPC) config(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
+	instance.onPermission(request, response, access.ConfigVerb, func() {
 		_ = response.WriteEntity(instance.caretakerd.ConfigObject())
 	})
 }
 
 func (instance *RPC) controlConfig(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
+	instance.onPermission(request, response, access.ConfigVerb, func() {
 		_ = response.WriteEntity(instance.caretakerd.Control().ConfigObject())
 	})
 }
 
+// Returns only the services the get verb is allowed on. If it is not allowed on any service the request is rejected.
 func (instance *RPC) services(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
-		information := instance.execution.Information()
-		_ = response.WriteEntity(information)
-	})
+	information := instance.execution.Information()
+	if !instance.checkPermission(request, access.Get, nil) {
+		services := instance.caretakerd.Services()
+		for name := range information {
+			if svc := services.Get(name); svc == nil || !instance.checkPermission(request, access.Get, svc) {
+				delete(information, name)
+			}
+		}
+		if len(information) == 0 {
+			_ = response.WriteErrorString(http.StatusForbidden, "No "+access.Get.String()+" permission to instance endpoint.")
+			return
+		}
+	}
+	_ = response.WriteEntity(information)
 }
 
 func (instance *RPC) service(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
-		serviceName := request.PathParameter("serviceName")
-		services := instance.caretakerd.Services()
-		if svc := services.Get(serviceName); svc != nil {
-			information := instance.execution.InformationFor(svc)
-			_ = response.WriteEntity(information)
-		} else {
-			_ = response.WriteError(http.StatusNotFound, errors.New("Service '%s' does not exist.", serviceName))
-		}
+	instance.onServicePermission(request, response, access.Get, func(svc *service.Service) {
+		information := instance.execution.InformationFor(svc)
+		_ = response.WriteEntity(information)
 	})
 }
 
 func (instance *RPC) serviceConfig(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
-		instance.doWithService(request, response, func(service *service.Service) {
-			_ = response.WriteEntity(service.Config())
-		})
+	instance.onServicePermission(request, response, access.ConfigVerb, func(service *service.Service) {
+		_ = response.WriteEntity(service.Config())
 	})
 }
 
 func (instance *RPC) serviceStatus(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
-		instance.doWithExecution(request, response, func(execution *service.Execution) {
+	instance.onServicePermission(request, response, access.Get, func(sc *service.Service) {
+		instance.doWithExecution(sc, func(execution *service.Execution) {
 			if execution != nil {
 				_, _ = response.Write([]byte(execution.Status().String()))
 			} else {
@@ -304,8 +487,8 @@ func (instance *RPC) serviceStatus(request *restful.Request, response *restful.R
 }
 
 func (instance *RPC) servicePid(request *restful.Request, response *restful.Response) {
-	instance.onReadPermission(request, response, func() {
-		instance.doWithExecution(request, response, func(execution *service.Execution) {
+	instance.onServicePermission(request, response, access.Get, func(sc *service.Service) {
+		instance.doWithExecution(sc, func(execution *service.Execution) {
 			if execution != nil {
 				_, _ = response.Write([]byte(strconv.Itoa(execution.PID())))
 			} else {
@@ -315,53 +498,120 @@ func (instance *RPC) servicePid(request *restful.Request, response *restful.Resp
 	})
 }
 
-func (instance *RPC) serviceRestart(request *restful.Request, response *restful.Response) {
-	instance.onWritePermission(request, response, func() {
-		instance.doWithService(request, response, func(service *service.Service) {
-			err := instance.execution.Restart(service)
-			if err == nil {
-				_, _ = response.Write([]byte("OK"))
-			} else {
-				_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+// DefaultWaitTimeout is used by the wait endpoint if no timeout is provided.
+const DefaultWaitTimeout = 60 * time.Second
+
+// WaitBody is a response structure.
+type WaitBody struct {
+	Met     bool                `json:"met"`
+	Service service.Information `json:"service"`
+}
+
+// Blocks until the service meets the condition of the "for" parameter or the "timeout" parameter elapsed.
+func (instance *RPC) serviceWait(request *restful.Request, response *restful.Response) {
+	instance.onServicePermission(request, response, access.Get, func(sc *service.Service) {
+		condition := service.RunningCondition
+		if plain := request.QueryParameter("for"); len(plain) > 0 {
+			if err := condition.Set(plain); err != nil {
+				_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal condition. "+err.Error())
+				return
 			}
-		})
+		}
+		timeout := DefaultWaitTimeout
+		if plain := request.QueryParameter("timeout"); len(plain) > 0 {
+			var err error
+			if timeout, err = time.ParseDuration(plain); err != nil {
+				_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal timeout. "+err.Error())
+				return
+			}
+		}
+		_ = response.WriteEntity(instance.waitFor(request, sc, condition, timeout))
+	})
+}
+
+func (instance *RPC) waitFor(request *restful.Request, sc *service.Service, condition service.Condition, timeout time.Duration) WaitBody {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		// Get the channel before the information is retrieved. Otherwise, we could miss a change.
+		changed := sc.Changed()
+		information := instance.execution.InformationFor(sc)
+		if condition.IsMetBy(information) {
+			return WaitBody{Met: true, Service: information}
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return WaitBody{Met: false, Service: information}
+		case <-request.Request.Context().Done():
+			return WaitBody{Met: false, Service: information}
+		}
+	}
+}
+
+func (instance *RPC) serviceRestart(request *restful.Request, response *restful.Response) {
+	instance.onServicePermission(request, response, access.Restart, func(service *service.Service) {
+		err := instance.execution.Restart(service)
+		if err == nil {
+			_, _ = response.Write([]byte("OK"))
+		} else {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+		}
 	})
 }
 
 func (instance *RPC) serviceStart(request *restful.Request, response *restful.Response) {
-	instance.onWritePermission(request, response, func() {
-		instance.doWithService(request, response, func(sc *service.Service) {
-			err := instance.execution.Start(sc)
-			if err == nil {
-				_, _ = response.Write([]byte("OK"))
-			} else if sde, ok := err.(service.AlreadyRunningError); ok {
-				_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
-			} else {
-				_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
-			}
-		})
+	instance.onServicePermission(request, response, access.Start, func(sc *service.Service) {
+		err := instance.execution.Start(sc)
+		if err == nil {
+			_, _ = response.Write([]byte("OK"))
+		} else if sde, ok := err.(service.AlreadyRunningError); ok {
+			_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
+		} else {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+		}
 	})
 }
 
 func (instance *RPC) serviceStop(request *restful.Request, response *restful.Response) {
-	instance.onWritePermission(request, response, func() {
-		instance.doWithService(request, response, func(sc *service.Service) {
-			err := instance.execution.Stop(sc)
-			if err == nil {
-				_, _ = response.Write([]byte("OK"))
-			} else if sde, ok := err.(service.AlreadyStoppedError); ok {
-				_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
-			} else {
-				_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
-			}
-		})
+	instance.onServicePermission(request, response, access.Stop, func(sc *service.Service) {
+		err := instance.execution.Stop(sc)
+		if err == nil {
+			_, _ = response.Write([]byte("OK"))
+		} else if sde, ok := err.(service.AlreadyStoppedError); ok {
+			_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
+		} else {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+		}
 	})
 }
 
 func (instance *RPC) serviceKill(request *restful.Request, response *restful.Response) {
-	instance.onWritePermission(request, response, func() {
-		instance.doWithService(request, response, func(sc *service.Service) {
-			err := instance.execution.Kill(sc)
+	instance.onServicePermission(request, response, access.Kill, func(sc *service.Service) {
+		err := instance.execution.Kill(sc)
+		if err == nil {
+			_, _ = response.Write([]byte("OK"))
+		} else if sde, ok := err.(service.AlreadyStoppedError); ok {
+			_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
+		} else {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+		}
+	})
+}
+
+// SignalBody is a response structure.
+type SignalBody struct {
+	Signal values.Signal `json:"signal"`
+}
+
+func (instance *RPC) serviceSignal(request *restful.Request, response *restful.Response) {
+	instance.onServicePermission(request, response, access.Signal, func(sc *service.Service) {
+		sb := SignalBody{}
+		err := request.ReadEntity(&sb)
+		if err != nil {
+			_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal body. "+err.Error())
+		} else {
+			err = instance.execution.Signal(sc, sb.Signal)
 			if err == nil {
 				_, _ = response.Write([]byte("OK"))
 			} else if sde, ok := err.(service.AlreadyStoppedError); ok {
@@ -369,52 +619,101 @@ func (instance *RPC) serviceKill(request *restful.Request, response *restful.Res
 			} else {
 				_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
 			}
-		})
+		}
 	})
 }
 
-// SignalBody is a response structure.
-type SignalBody struct {
-	Signal values.Signal `json:"signal"`
+// RevokeBody is a request structure.
+type RevokeBody struct {
+	Target string `json:"target"`
 }
 
-func (instance *RPC) serviceSignal(request *restful.Request, response *restful.Response) {
-	instance.onWritePermission(request, response, func() {
-		instance.doWithService(request, response, func(sc *service.Service) {
-			sb := SignalBody{}
-			err := request.ReadEntity(&sb)
-			if err != nil {
-				_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal body. "+err.Error())
-			} else {
-				err = instance.execution.Kill(sc)
-				if err == nil {
-					_, _ = response.Write([]byte("OK"))
-				} else if sde, ok := err.(service.AlreadyStoppedError); ok {
-					_ = response.WriteErrorString(http.StatusConflict, "ERROR: "+sde.Error())
-				} else {
-					_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
-				}
-			}
-		})
+func (instance *RPC) keyStoreRevoke(request *restful.Request, response *restful.Response) {
+	instance.onPermission(request, response, access.Revoke, func() {
+		rb := RevokeBody{}
+		err := request.ReadEntity(&rb)
+		if err != nil {
+			_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal body. "+err.Error())
+		} else if strings.TrimSpace(rb.Target) == "" {
+			_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: There is no serial or common name to revoke.")
+		} else if revocation, err := instance.caretakerd.KeyStore().Revoke(rb.Target); err != nil {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
+		} else {
+			instance.logger.Log(logger.Info, "Revoked certificates matching '%v'.", revocation.Target)
+			_, _ = response.Write([]byte("OK"))
+		}
 	})
 }
 
-func (instance *RPC) doWithService(request *restful.Request, response *restful.Response, what func(service *service.Service)) {
-	serviceName := request.PathParameter("serviceName")
-	services := instance.caretakerd.Services()
-	if svc := services.Get(serviceName); svc != nil {
-		what(svc)
-	} else {
-		_ = response.WriteError(http.StatusNotFound, errors.New("Service '%s' does not exist.", serviceName))
-	}
+// IssueBody is a request structure.
+type IssueBody struct {
+	Name       string            `json:"name"`
+	Permission access.Permission `json:"permission"`
+	Roles      []string          `json:"roles,omitempty"`
 }
 
-func (instance *RPC) doWithExecution(request *restful.Request, response *restful.Response, what func(execution *service.Execution)) {
-	instance.doWithService(request, response, func(s *service.Service) {
-		if e, ok := instance.execution.GetFor(s); ok {
-			what(e)
+// IssuedBody is a response structure.
+type IssuedBody struct {
+	Serial string `json:"serial"`
+	Pem    string `json:"pem"`
+}
+
+func (instance *RPC) keyStoreIssue(request *restful.Request, response *restful.Response) {
+	instance.onPermission(request, response, access.Issue, func() {
+		ib := IssueBody{}
+		err := request.ReadEntity(&ib)
+		if err != nil {
+			_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: Illegal body. "+err.Error())
+		} else if err := instance.validateIssueBody(ib); err != nil {
+			_ = response.WriteErrorString(http.StatusBadRequest, "ERROR: "+err.Error())
+		} else if !access.IsAllowedToIssue(instance.accessesOf(request), ib.Permission, ib.Roles) {
+			_ = response.WriteErrorString(http.StatusForbidden, "ERROR: The permission or the roles exceed the own ones.")
+		} else if pem, cert, err := access.IssuePem(instance.caretakerd.KeyStore(), ib.Name, ib.Permission, ib.Roles); err != nil {
+			_ = response.WriteErrorString(http.StatusInternalServerError, "ERROR: "+err.Error())
 		} else {
-			what(nil)
+			serial := fmt.Sprintf("%X", cert.SerialNumber)
+			instance.logger.Log(logger.Info, "Issued certificate %v for '%v' with permission %v and roles %v.", serial, ib.Name, ib.Permission, ib.Roles)
+			// Do not record the private key of the response in the audit.
+			request.SetAttribute(auditResultAttribute, "serial="+serial)
+			_ = response.WriteEntity(IssuedBody{
+				Serial: serial,
+				Pem:    string(pem),
+			})
 		}
 	})
 }
+
+// The name of an issued certificate must not be the name of caretakerctl or a service. Otherwise, it would
+// be trusted by accesses of the type trusted without a pemFile, too.
+func (instance *RPC) validateIssueBody(ib IssueBody) error {
+	name := strings.TrimSpace(ib.Name)
+	if name == "" {
+		return errors.New("There is no name to issue a certificate for.")
+	}
+	if name != ib.Name {
+		return errors.New("The name '%v' must not start or end with whitespaces.", ib.Name)
+	}
+	if name == instance.caretakerd.Control().Access().Name() || instance.caretakerd.Services().Get(name) != nil {
+		return errors.New("The name '%v' is already used by caretakerctl or a service.", name)
+	}
+	if err := ib.Permission.Validate(); err != nil {
+		return err
+	}
+	if ib.Permission == access.Forbidden && len(ib.Roles) == 0 {
+		return errors.New("There is neither a permission nor a role to issue a certificate for '%v' with.", name)
+	}
+	for _, role := range ib.Roles {
+		if _, ok := instance.conf.Roles[role]; !ok {
+			return errors.New("Role '%v' does not exist.", role)
+		}
+	}
+	return nil
+}
+
+func (instance *RPC) doWithExecution(s *service.Service, what func(execution *service.Execution)) {
+	if e, ok := instance.execution.GetFor(s); ok {
+		what(e)
+	} else {
+		what(nil)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package rpc
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/service"
+	"github.com/echocat/caretakerd/sync"
+	"github.com/echocat/caretakerd/values"
+	"github.com/emicklei/go-restful/v3"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type RPCTest struct{}
+
+func init() {
+	Suite(&RPCTest{})
+}
+
+// Execution that drives exactly one running service execution.
+type singleExecution struct {
+	target *service.Execution
+	killed bool
+}
+
+func (instance *singleExecution) GetFor(s *service.Service) (*service.Execution, bool) {
+	return instance.target, s == instance.target.Service()
+}
+
+func (instance *singleExecution) Information() map[string]service.Information {
+	return map[string]service.Information{instance.target.Name(): instance.InformationFor(instance.target.Service())}
+}
+
+func (instance *singleExecution) InformationFor(*service.Service) service.Information {
+	return service.NewInformationForExecution(instance.target)
+}
+
+func (instance *singleExecution) Start(*service.Service) error {
+	return service.AlreadyRunningError{Name: instance.target.Name()}
+}
+
+func (instance *singleExecution) Restart(*service.Service) error {
+	return service.AlreadyRunningError{Name: instance.target.Name()}
+}
+
+func (instance *singleExecution) Stop(*service.Service) error {
+	instance.target.Stop()
+	return nil
+}
+
+func (instance *singleExecution) Kill(*service.Service) error {
+	instance.killed = true
+	return instance.target.Kill()
+}
+
+func (instance *singleExecution) Signal(_ *service.Service, what values.Signal) error {
+	return instance.target.Signal(what)
+}
+
+func (s *RPCTest) TestServiceSignalSendsTheRequestedSignal(c *C) {
+	instance := new(AuditTest).newRPC(c, nil)
+	marker := filepath.Join(c.MkDir(), "hup")
+	worker := service.NewConfig().WithCommand("sh", "-c", values.String("trap 'touch "+marker+"' HUP; while true; do sleep 0.1; done"))
+	services, err := service.NewServices(service.Configs{"worker": worker}, service.Environments{}, sync.NewGroup(), instance.caretakerd.KeyStore())
+	c.Assert(err, IsNil)
+	instance.caretakerd.(*testCaretakerd).services = services
+	target, err := services.Get("worker").NewExecution(instance.caretakerd.KeyStore())
+	c.Assert(err, IsNil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = target.Run()
+	}()
+	execution := &singleExecution{target: target}
+	instance.execution = execution
+	defer func() {
+		target.Stop()
+		<-done
+	}()
+	for i := 0; i < 500 && target.PID() <= 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(target.Status(), Equals, service.Running)
+	// Give the shell the chance to install its trap.
+	time.Sleep(200 * time.Millisecond)
+
+	request := new(AuditTest).newRequest("POST", "/service/worker/signal", `{"signal":"HUP"}`)
+	request.Request.Header.Set("Authorization", "Bearer control-token")
+	request.PathParameters()["serviceName"] = "worker"
+	recorder := httptest.NewRecorder()
+	instance.serviceSignal(request, restful.NewResponse(recorder))
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	c.Assert(strings.TrimSpace(recorder.Body.String()), Equals, "OK")
+
+	for i := 0; i < 500; i++ {
+		if _, err := os.Stat(marker); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err = os.Stat(marker)
+	c.Assert(err, IsNil)
+	c.Assert(execution.killed, Equals, false)
+	c.Assert(target.Status(), Equals, service.Running)
+}
@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/echocat/caretakerd/values"
+	"strings"
+)
+
+// Security indicates how connections to a listener are secured and how their clients are identified.
+type Security int
+
+const (
+	// @id auto
+	//
+	// Uses {@ref #PeerCredentials peerCredentials} for unix sockets and {@ref #TLS tls} for everything else.
+	// File descriptors passed in by the parent process are inspected when caretakerd starts.
+	Auto Security = 0
+	// @id tls
+	//
+	// Connections are encrypted using TLS. Clients are identified by their certificates which have
+	// to be signed by the CA of the {@ref github.com/echocat/caretakerd/keyStore.Config keyStore}.
+	TLS Security = 1
+	// @id peerCredentials
+	//
+	// Connections are not encrypted. Clients are identified by the user and group of the connecting process
+	// which is provided by the operating system. This is only possible for unix sockets.
+	//
+	// > **Hint:** Only the access type {@ref github.com/echocat/caretakerd/access.Type#PeerCredentials peerCredentials}
+	// > is able to grant permissions to these clients.
+	PeerCredentials Security = 2
+)
+
+// AllSecurities contains all possible variants of Security.
+var AllSecurities = []Security{
+	Auto,
+	TLS,
+	PeerCredentials,
+}
+
+func (instance Security) String() string {
+	s, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// CheckedString is like String but also returns an optional error if there are
+// validation errors.
+func (instance Security) CheckedString() (string, error) {
+	switch instance {
+	case Auto:
+		return "auto", nil
+	case TLS:
+		return "tls", nil
+	case PeerCredentials:
+		return "peerCredentials", nil
+	}
+	return "", fmt.Errorf("illegal security: %d", instance)
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are problems while transforming the string.
+func (instance *Security) Set(value string) error {
+	for _, candidate := range AllSecurities {
+		if strings.EqualFold(candidate.String(), value) {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal security: %s", value)
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance Security) MarshalYAML() (interface{}, error) {
+	return instance.CheckedString()
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *Security) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Security) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Security) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Security) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
+
+// ResolveFor returns the Security that is used for the given address. Auto is resolved
+// by the protocol of the address. For file descriptors Auto is returned because the type of the
+// socket is only known after it was opened.
+func (instance Security) ResolveFor(address values.SocketAddress) Security {
+	if instance != Auto {
+		return instance
+	}
+	switch address.Protocol {
+	case values.Unix:
+		return PeerCredentials
+	case values.TCP:
+		return TLS
+	}
+	return Auto
+}
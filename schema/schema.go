@@ -0,0 +1,16 @@
+// Package schema provides the JSON Schema of the caretakerd configuration.
+package schema
+
+import (
+	// Required to embed the schema.
+	_ "embed"
+)
+
+// FileName is the name under which the JSON Schema is published.
+const FileName = "caretakerd.schema.json"
+
+// JSON contains the JSON Schema (draft-07) of the caretakerd configuration.
+// It is generated from the sources using "go run ./build generate".
+//
+//go:embed caretakerd.schema.json
+var JSON []byte
@@ -0,0 +1,27 @@
+package schema
+
+import (
+	"encoding/json"
+	. "gopkg.in/check.v1"
+)
+
+type SchemaTest struct{}
+
+func init() {
+	Suite(&SchemaTest{})
+}
+
+func (s *SchemaTest) TestJSON(c *C) {
+	var schema map[string]interface{}
+	c.Assert(json.Unmarshal(JSON, &schema), IsNil)
+	c.Assert(schema["$schema"], Equals, "http://json-schema.org/draft-07/schema#")
+	c.Assert(schema["type"], Equals, "object")
+
+	properties := schema["properties"].(map[string]interface{})
+	services := properties["services"].(map[string]interface{})
+	c.Assert(services["additionalProperties"], DeepEquals, map[string]interface{}{"$ref": "#/definitions/service.Config"})
+
+	definitions := schema["definitions"].(map[string]interface{})
+	serviceType := definitions["service.Type"].(map[string]interface{})
+	c.Assert(serviceType["enum"], DeepEquals, []interface{}{"onDemand", "autoStart", "master"})
+}
@@ -0,0 +1,136 @@
+package seccomp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/echocat/caretakerd/errors"
+)
+
+// Action defines what happens if a process calls a syscall matched by a rule of a seccomp profile.
+type Action int
+
+const (
+	// @id allow
+	//
+	// The syscall will be executed.
+	Allow Action = 0
+	// @id kill
+	//
+	// The whole process will be killed with signal SYS. This will be reported as seccomp violation.
+	Kill Action = 1
+	// @id errno
+	//
+	// The syscall will not be executed and fails with error <code>EPERM</code>.
+	Errno Action = 2
+	// @id log
+	//
+	// The syscall will be executed but the kernel will log it.
+	Log Action = 3
+)
+
+// AllActions contains all possible variants of Action.
+var AllActions = []Action{
+	Allow,
+	Kill,
+	Errno,
+	Log,
+}
+
+func (instance Action) String() string {
+	result, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CheckedString is like String but also returns an optional error if there are any
+// validation errors.
+func (instance Action) CheckedString() (string, error) {
+	switch instance {
+	case Allow:
+		return "allow", nil
+	case Kill:
+		return "kill", nil
+	case Errno:
+		return "errno", nil
+	case Log:
+		return "log", nil
+	}
+	return "", errors.New("Illegal seccomp action: %d", instance)
+}
+
+// Set sets the given string to current object from a string.
+// Returns an error object if there are any problems while transforming the string.
+func (instance *Action) Set(value string) error {
+	if valueAsInt, err := strconv.Atoi(value); err == nil {
+		for _, candidate := range AllActions {
+			if int(candidate) == valueAsInt {
+				*instance = candidate
+				return nil
+			}
+		}
+		return fmt.Errorf("illegal seccomp action: %v", value)
+	}
+	lowerValue := strings.ToLower(value)
+	for _, candidate := range AllActions {
+		if candidate.String() == lowerValue {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal seccomp action: %v", value)
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance Action) MarshalYAML() (interface{}, error) {
+	return instance.String(), nil
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *Action) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Action) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Action) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Action) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
+
+func (instance Action) filterReturnValue() uint32 {
+	switch instance {
+	case Kill:
+		return retKillProcess
+	case Errno:
+		return retErrno | errnoPermissionDenied
+	case Log:
+		return retLog
+	}
+	return retAllow
+}
@@ -0,0 +1,34 @@
+package seccomp
+
+// Instruction represents one classic BPF instruction as expected by the kernel.
+type Instruction struct {
+	Code uint16
+	Jt   uint8
+	Jf   uint8
+	K    uint32
+}
+
+const (
+	opLoadWordAbsolute     = uint16(0x20) // BPF_LD | BPF_W | BPF_ABS
+	opJumpIfEqual          = uint16(0x15) // BPF_JMP | BPF_JEQ | BPF_K
+	opJumpIfGreaterOrEqual = uint16(0x35) // BPF_JMP | BPF_JGE | BPF_K
+	opReturn               = uint16(0x06) // BPF_RET | BPF_K
+
+	// Offsets inside of struct seccomp_data
+	offsetNr   = uint32(0)
+	offsetArch = uint32(4)
+
+	retKillProcess = uint32(0x80000000)
+	retErrno       = uint32(0x00050000)
+	retLog         = uint32(0x7ffc0000)
+	retAllow       = uint32(0x7fff0000)
+
+	errnoPermissionDenied = uint32(1) // EPERM
+
+	maximumInstructions = 4096
+
+	// AUDIT_ARCH_X86_64 - x32 syscalls are reported with this arch, too.
+	auditArchX8664 = uint32(0xc000003e)
+	// x32 syscalls have this bit set in their number (__X32_SYSCALL_BIT).
+	x32SyscallBit = uint32(0x40000000)
+)
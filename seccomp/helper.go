@@ -0,0 +1,93 @@
+package seccomp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/echocat/caretakerd/errors"
+)
+
+// ProgramEnvironmentVariable is the environment variable that is used to hand over the
+// compiled profile to the helper process. It is removed before the actual command is executed.
+const ProgramEnvironmentVariable = "CTD_SECCOMP_PROGRAM"
+
+// HelperFailureExitCode is the exit code of the helper process if it could not apply
+// the profile or could not execute the actual command.
+const HelperFailureExitCode = 126
+
+const helperName = "caretakerd-seccomp"
+
+// WrapCommand modifies the given command so that the given profile is applied right before the
+// actual executable is executed. This is done by executing the current executable as helper
+// (see ExecuteHelperIfRequested) which installs the profile and replaces itself with the
+// actual executable afterwards.
+func WrapCommand(cmd *exec.Cmd, profile Profile) error {
+	program, err := profile.Compile()
+	if err != nil {
+		return err
+	}
+	encoded, err := encodeProgram(program)
+	if err != nil {
+		return err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return errors.New("Could not determine the current executable to apply seccomp profile.").CausedBy(err)
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	(*cmd).Env = append(env, ProgramEnvironmentVariable+"="+encoded)
+	(*cmd).Args = append([]string{helperName, cmd.Path}, cmd.Args...)
+	(*cmd).Path = self
+	return nil
+}
+
+// ExecuteHelperIfRequested checks if the current process was started by WrapCommand. In
+// this case the profile will be installed and the process replaced by the actual command.
+// This method never returns in this case. It should be called as early as possible.
+func ExecuteHelperIfRequested() {
+	if len(os.Args) < 3 || filepath.Base(os.Args[0]) != helperName {
+		return
+	}
+	encoded := os.Getenv(ProgramEnvironmentVariable)
+	os.Unsetenv(ProgramEnvironmentVariable)
+	program, err := decodeProgram(encoded)
+	if err == nil {
+		err = execWithProgram(program, os.Args[1], os.Args[2:], os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "Could not execute '%s' with seccomp profile: %v\n", os.Args[1], err)
+	os.Exit(HelperFailureExitCode)
+}
+
+func encodeProgram(program []Instruction) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, program); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decodeProgram(encoded string) ([]Instruction, error) {
+	if len(encoded) == 0 {
+		return nil, errors.New("No seccomp program provided.")
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%8 != 0 {
+		return nil, errors.New("Illegal seccomp program length: %d", len(b))
+	}
+	result := make([]Instruction, len(b)/8)
+	if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
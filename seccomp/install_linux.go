@@ -0,0 +1,49 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package seccomp
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/echocat/caretakerd/errors"
+)
+
+const (
+	prSetNoNewPrivs         = 38
+	seccompSetModeFilter    = 1
+	seccompFilterFlagTsync  = 1
+	seccompModeFilterLegacy = 2
+)
+
+func execWithProgram(program []Instruction, path string, args []string, env []string) error {
+	runtime.LockOSThread()
+	if err := install(program); err != nil {
+		return err
+	}
+	return syscall.Exec(path, args, env)
+}
+
+func install(program []Instruction) error {
+	if len(program) == 0 {
+		return errors.New("Empty seccomp program.")
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return errors.New("Could not set no_new_privs.").CausedBy(errno)
+	}
+	fprog := syscall.SockFprog{
+		Len:    uint16(len(program)),
+		Filter: (*syscall.SockFilter)(unsafe.Pointer(&program[0])),
+	}
+	_, _, errno := syscall.RawSyscall(uintptr(syscallNumbers["seccomp"]), seccompSetModeFilter, seccompFilterFlagTsync, uintptr(unsafe.Pointer(&fprog)))
+	if errno == syscall.ENOSYS {
+		_, _, errno = syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_SECCOMP, seccompModeFilterLegacy, uintptr(unsafe.Pointer(&fprog)))
+	}
+	if errno != 0 {
+		return errors.New("Could not install seccomp filter.").CausedBy(errno)
+	}
+	return nil
+}
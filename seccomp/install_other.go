@@ -0,0 +1,12 @@
+//go:build !linux || (!amd64 && !arm64)
+// +build !linux !amd64,!arm64
+
+package seccomp
+
+import (
+	"github.com/echocat/caretakerd/errors"
+)
+
+func execWithProgram(program []Instruction, path string, args []string, env []string) error {
+	return errors.New("Seccomp profiles are not supported on this platform.")
+}
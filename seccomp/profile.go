@@ -0,0 +1,214 @@
+package seccomp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/echocat/caretakerd/errors"
+)
+
+// DefaultDenyDangerousName is the name of the built-in profile DefaultDenyDangerous.
+const DefaultDenyDangerousName = "default-deny-dangerous"
+
+// Profile describes which syscalls a process is allowed to call.
+// Profiles could be loaded from JSON files.
+type Profile struct {
+	// @default allow
+	//
+	// Action that will be applied to every syscall that is neither listed in
+	// {@ref #Allow allow} nor in {@ref #Deny deny}.
+	DefaultAction Action `json:"defaultAction" yaml:"defaultAction"`
+
+	// @default kill
+	//
+	// Action that will be applied to every syscall listed in {@ref #Deny deny}.
+	DenyAction Action `json:"denyAction" yaml:"denyAction"`
+
+	// @default []
+	//
+	// Syscalls (names like <code>read</code> or numbers) which will be always allowed.
+	Allow []string `json:"allow" yaml:"allow"`
+
+	// @default []
+	//
+	// Syscalls (names like <code>ptrace</code> or numbers) which will be handled using
+	// {@ref #DenyAction denyAction}.
+	Deny []string `json:"deny" yaml:"deny"`
+
+	ignoreUnknownSyscalls bool
+}
+
+// DefaultDenyDangerous is a profile that allows everything except syscalls which are
+// usually only required to manipulate the whole system or other processes.
+// Syscalls of this profile which does not exist on the current platform are ignored.
+var DefaultDenyDangerous = Profile{
+	ignoreUnknownSyscalls: true,
+	DefaultAction:         Allow,
+	DenyAction:            Kill,
+	Deny: []string{
+		"acct",
+		"add_key",
+		"bpf",
+		"create_module",
+		"delete_module",
+		"finit_module",
+		"fsconfig",
+		"fsmount",
+		"fsopen",
+		"fspick",
+		"init_module",
+		"iopl",
+		"ioperm",
+		"kexec_file_load",
+		"kexec_load",
+		"keyctl",
+		"lookup_dcookie",
+		"mount",
+		"move_mount",
+		"open_by_handle_at",
+		"open_tree",
+		"perf_event_open",
+		"pivot_root",
+		"process_vm_readv",
+		"process_vm_writev",
+		"ptrace",
+		"quotactl",
+		"reboot",
+		"request_key",
+		"setns",
+		"settimeofday",
+		"swapoff",
+		"swapon",
+		"umount2",
+		"unshare",
+		"uselib",
+		"userfaultfd",
+		"vhangup",
+	},
+}
+
+// NewProfile creates a new instance of Profile.
+func NewProfile() Profile {
+	return Profile{
+		DefaultAction: Allow,
+		DenyAction:    Kill,
+		Allow:         []string{},
+		Deny:          []string{},
+	}
+}
+
+// LoadProfile loads a profile by the given reference. This could be either the name of a
+// built-in profile (see DefaultDenyDangerousName) or the path to a JSON file.
+// If reference is empty nil is returned.
+func LoadProfile(reference string) (*Profile, error) {
+	if len(reference) == 0 {
+		return nil, nil
+	}
+	if reference == DefaultDenyDangerousName {
+		result := DefaultDenyDangerous
+		return &result, nil
+	}
+	content, err := ioutil.ReadFile(reference)
+	if err != nil {
+		return nil, errors.New("Could not read seccomp profile '%v'.", reference).CausedBy(err)
+	}
+	result, err := ParseProfile(content)
+	if err != nil {
+		return nil, errors.New("Could not parse seccomp profile '%v'.", reference).CausedBy(err)
+	}
+	return result, nil
+}
+
+// ParseProfile parses the given JSON document to a profile.
+func ParseProfile(content []byte) (*Profile, error) {
+	result := NewProfile()
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Profile) Validate() error {
+	err := instance.DefaultAction.Validate()
+	if err == nil {
+		err = instance.DenyAction.Validate()
+	}
+	return err
+}
+
+// Compile compiles this profile into a BPF program for the current platform.
+// Returns an error if the current platform does not support seccomp or if
+// the profile contains unknown syscalls.
+func (instance Profile) Compile() ([]Instruction, error) {
+	if !Supported() {
+		return nil, errors.New("Seccomp profiles are not supported on this platform.")
+	}
+	return instance.compileFor(auditArch, syscallNumbers)
+}
+
+func (instance Profile) compileFor(arch uint32, numbers map[string]uint32) ([]Instruction, error) {
+	if err := instance.Validate(); err != nil {
+		return nil, err
+	}
+	result := []Instruction{
+		{Code: opLoadWordAbsolute, K: offsetArch},
+		{Code: opJumpIfEqual, Jt: 1, K: arch},
+		{Code: opReturn, K: retKillProcess},
+		{Code: opLoadWordAbsolute, K: offsetNr},
+	}
+	if arch == auditArchX8664 {
+		// x32 syscalls report the same arch but could reach every syscall using their own numbers.
+		result = append(result,
+			Instruction{Code: opJumpIfGreaterOrEqual, Jf: 1, K: x32SyscallBit},
+			Instruction{Code: opReturn, K: retKillProcess},
+		)
+	}
+	handled := map[uint32]bool{}
+	appendRules := func(names []string, action Action) error {
+		for _, name := range names {
+			nr, err := resolveSyscall(name, numbers)
+			if err != nil && instance.ignoreUnknownSyscalls {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if handled[nr] {
+				continue
+			}
+			handled[nr] = true
+			result = append(result,
+				Instruction{Code: opJumpIfEqual, Jf: 1, K: nr},
+				Instruction{Code: opReturn, K: action.filterReturnValue()},
+			)
+		}
+		return nil
+	}
+	if err := appendRules(instance.Allow, Allow); err != nil {
+		return nil, err
+	}
+	if err := appendRules(instance.Deny, instance.DenyAction); err != nil {
+		return nil, err
+	}
+	result = append(result, Instruction{Code: opReturn, K: instance.DefaultAction.filterReturnValue()})
+	if len(result) > maximumInstructions {
+		return nil, errors.New("Seccomp profile results in %d instructions but only %d are allowed.", len(result), maximumInstructions)
+	}
+	return result, nil
+}
+
+func resolveSyscall(name string, numbers map[string]uint32) (uint32, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(name))
+	if nr, ok := numbers[trimmed]; ok {
+		return nr, nil
+	}
+	if nr, err := strconv.ParseUint(trimmed, 10, 32); err == nil {
+		return uint32(nr), nil
+	}
+	return 0, errors.New("Unknown syscall '%v'.", name)
+}
@@ -0,0 +1,130 @@
+package seccomp
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ProfileTest struct{}
+
+func init() {
+	Suite(&ProfileTest{})
+}
+
+var testSyscallNumbers = map[string]uint32{
+	"read":   0,
+	"write":  1,
+	"ptrace": 101,
+	"mount":  165,
+}
+
+func (s *ProfileTest) TestLoadProfileWithEmptyReference(c *C) {
+	actual, err := LoadProfile("")
+	c.Assert(err, IsNil)
+	c.Assert(actual, IsNil)
+}
+
+func (s *ProfileTest) TestLoadBuiltInProfile(c *C) {
+	actual, err := LoadProfile(DefaultDenyDangerousName)
+	c.Assert(err, IsNil)
+	c.Assert(actual.DefaultAction, Equals, Allow)
+	c.Assert(actual.DenyAction, Equals, Kill)
+	c.Assert(actual.Deny, DeepEquals, DefaultDenyDangerous.Deny)
+}
+
+func (s *ProfileTest) TestLoadProfileFromMissingFile(c *C) {
+	_, err := LoadProfile("/does/not/exist.json")
+	c.Assert(err, ErrorMatches, "(?s)Could not read seccomp profile '/does/not/exist.json'.*")
+}
+
+func (s *ProfileTest) TestParseProfile(c *C) {
+	actual, err := ParseProfile([]byte(`{"defaultAction": "errno", "allow": ["read", "write"]}`))
+	c.Assert(err, IsNil)
+	c.Assert(actual.DefaultAction, Equals, Errno)
+	c.Assert(actual.DenyAction, Equals, Kill)
+	c.Assert(actual.Allow, DeepEquals, []string{"read", "write"})
+	c.Assert(actual.Deny, DeepEquals, []string{})
+}
+
+func (s *ProfileTest) TestParseProfileWithIllegalAction(c *C) {
+	_, err := ParseProfile([]byte(`{"defaultAction": "foo"}`))
+	c.Assert(err, ErrorMatches, "illegal seccomp action: foo")
+}
+
+func (s *ProfileTest) TestCompile(c *C) {
+	profile := Profile{
+		DefaultAction: Errno,
+		DenyAction:    Log,
+		Allow:         []string{"read", "Write"},
+		Deny:          []string{"ptrace", "read", "333"},
+	}
+	actual, err := profile.compileFor(0xc000003e, testSyscallNumbers)
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, []Instruction{
+		{Code: opLoadWordAbsolute, K: offsetArch},
+		{Code: opJumpIfEqual, Jt: 1, K: 0xc000003e},
+		{Code: opReturn, K: retKillProcess},
+		{Code: opLoadWordAbsolute, K: offsetNr},
+		{Code: opJumpIfGreaterOrEqual, Jf: 1, K: 0x40000000},
+		{Code: opReturn, K: retKillProcess},
+		{Code: opJumpIfEqual, Jf: 1, K: 0},
+		{Code: opReturn, K: retAllow},
+		{Code: opJumpIfEqual, Jf: 1, K: 1},
+		{Code: opReturn, K: retAllow},
+		{Code: opJumpIfEqual, Jf: 1, K: 101},
+		{Code: opReturn, K: retLog},
+		{Code: opJumpIfEqual, Jf: 1, K: 333},
+		{Code: opReturn, K: retLog},
+		{Code: opReturn, K: retErrno | errnoPermissionDenied},
+	})
+}
+
+func (s *ProfileTest) TestCompileWithUnknownSyscall(c *C) {
+	profile := NewProfile()
+	profile.Deny = []string{"foo"}
+	_, err := profile.compileFor(0xc000003e, testSyscallNumbers)
+	c.Assert(err, ErrorMatches, "Unknown syscall 'foo'.")
+}
+
+func (s *ProfileTest) TestCompileBuiltInProfileIgnoresUnknownSyscalls(c *C) {
+	actual, err := DefaultDenyDangerous.compileFor(0xc000003e, testSyscallNumbers)
+	c.Assert(err, IsNil)
+	c.Assert(actual, HasLen, 11)
+}
+
+func (s *ProfileTest) TestCompileKillsX32Syscalls(c *C) {
+	profile := Profile{
+		DefaultAction: Allow,
+		DenyAction:    Errno,
+		Deny:          []string{"ptrace"},
+	}
+	actual, err := profile.compileFor(0xc000003e, testSyscallNumbers)
+	c.Assert(err, IsNil)
+	c.Assert(actual[3:6], DeepEquals, []Instruction{
+		{Code: opLoadWordAbsolute, K: offsetNr},
+		{Code: opJumpIfGreaterOrEqual, Jf: 1, K: 0x40000000},
+		{Code: opReturn, K: retKillProcess},
+	})
+
+	// Other architectures do not know x32 syscalls.
+	actual, err = profile.compileFor(0xc00000b7, testSyscallNumbers)
+	c.Assert(err, IsNil)
+	c.Assert(actual[3:], DeepEquals, []Instruction{
+		{Code: opLoadWordAbsolute, K: offsetNr},
+		{Code: opJumpIfEqual, Jf: 1, K: 101},
+		{Code: opReturn, K: retErrno | errnoPermissionDenied},
+		{Code: opReturn, K: retAllow},
+	})
+}
+
+func (s *ProfileTest) TestEncodeAndDecodeProgram(c *C) {
+	program := []Instruction{
+		{Code: opLoadWordAbsolute, K: offsetArch},
+		{Code: opJumpIfEqual, Jt: 1, Jf: 2, K: 0xc00000b7},
+		{Code: opReturn, K: retAllow},
+	}
+	encoded, err := encodeProgram(program)
+	c.Assert(err, IsNil)
+	actual, err := decodeProgram(encoded)
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, program)
+}
@@ -0,0 +1,13 @@
+//go:build !linux || (!amd64 && !arm64)
+// +build !linux !amd64,!arm64
+
+package seccomp
+
+const auditArch uint32 = 0
+
+var syscallNumbers = map[string]uint32{}
+
+// Supported returns true if seccomp profiles could be applied on the current platform.
+func Supported() bool {
+	return false
+}
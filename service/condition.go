@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/echocat/caretakerd/errors"
+)
+
+// Condition represents a state of a service someone could wait for.
+type Condition int
+
+const (
+	// RunningCondition is met if the process of the service is running.
+	RunningCondition = Condition(0)
+	// ReadyCondition is met if the process of the service is running and its readiness command succeeded.
+	ReadyCondition = Condition(1)
+	// DownCondition is met if the service is not running.
+	DownCondition = Condition(2)
+)
+
+// AllConditions contains all possible variants of Condition.
+var AllConditions = []Condition{
+	RunningCondition,
+	ReadyCondition,
+	DownCondition,
+}
+
+func (instance Condition) String() string {
+	s, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// CheckedString is like String but also returns an optional error if there are any
+// validation errors.
+func (instance Condition) CheckedString() (string, error) {
+	switch instance {
+	case RunningCondition:
+		return "running", nil
+	case ReadyCondition:
+		return "ready", nil
+	case DownCondition:
+		return "down", nil
+	}
+	return "", errors.New("Illegal condition: %d", instance)
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are any problems while transforming the string.
+func (instance *Condition) Set(value string) error {
+	lowerValue := strings.ToLower(value)
+	for _, candidate := range AllConditions {
+		if candidate.String() == lowerValue {
+			*instance = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal condition: %v", value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Condition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(instance.String())
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Condition) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Condition) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
+
+// IsMetBy returns "true" if the given information of a service satisfies this condition.
+func (instance Condition) IsMetBy(information Information) bool {
+	switch instance {
+	case RunningCondition:
+		return information.Status == Running
+	case ReadyCondition:
+		return information.Status == Running && information.Ready
+	case DownCondition:
+		return information.Status == Down
+	}
+	return false
+}
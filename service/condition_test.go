@@ -0,0 +1,38 @@
+package service
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ConditionTest struct{}
+
+func init() {
+	Suite(&ConditionTest{})
+}
+
+func (s *ConditionTest) TestSet(c *C) {
+	var condition Condition
+	c.Assert(condition.Set("Ready"), IsNil)
+	c.Assert(condition, Equals, ReadyCondition)
+	c.Assert(condition.Set("down"), IsNil)
+	c.Assert(condition, Equals, DownCondition)
+	c.Assert(condition.Set("stopped"), ErrorMatches, "illegal condition: stopped")
+}
+
+func (s *ConditionTest) TestIsMetBy(c *C) {
+	running := Information{Status: Running}
+	ready := Information{Status: Running, Ready: true}
+	down := Information{Status: Down}
+	starting := Information{Status: New}
+
+	c.Assert(RunningCondition.IsMetBy(running), Equals, true)
+	c.Assert(RunningCondition.IsMetBy(ready), Equals, true)
+	c.Assert(RunningCondition.IsMetBy(starting), Equals, false)
+
+	c.Assert(ReadyCondition.IsMetBy(running), Equals, false)
+	c.Assert(ReadyCondition.IsMetBy(ready), Equals, true)
+
+	c.Assert(DownCondition.IsMetBy(down), Equals, true)
+	c.Assert(DownCondition.IsMetBy(starting), Equals, false)
+	c.Assert(DownCondition.IsMetBy(running), Equals, false)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"github.com/echocat/caretakerd/access"
 	"github.com/echocat/caretakerd/logger"
 	"github.com/echocat/caretakerd/values"
@@ -44,6 +45,9 @@ type Config struct {
 	// Hello world!
 	// ```
 	//
+	// Also defaults (``${MESSAGE:-Hello world!}``), required variables (``${MESSAGE:?Message is missing.}``)
+	// and escaping (``$$``) are supported. For details see [Interpolation](#configuration.interpolation).
+	//
 	// # Special master handling
 	//
 	// If the service is configured as {@ref #Type type} = {@ref github.com/echocat/caretakerd/service.Type#Master master}
@@ -103,6 +107,33 @@ type Config struct {
 	// ```
 	PostCommands [][]values.String `json:"postCommands" yaml:"postCommands,flow"`
 
+	// @default []
+	//
+	// Command to be executed to check if the service is ready after its process was started.
+	//
+	// The command is repeated every second as long as the service is running until it exits with ``0``.
+	// From this moment on the service is reported as ready. If this property is empty the service is
+	// ready as soon as it is running.
+	//
+	// If the command does not end within the {@ref #ReadinessTimeoutInSeconds readinessTimeoutInSeconds} or
+	// the service is not running anymore, the command will be killed.
+	//
+	// This could be used by ``caretakerctl wait <service> --for=status=ready`` to wait until a service
+	// could really be used.
+	//
+	// Example:
+	// ```yaml
+	// command: ["nginx", "-g", "daemon off;"]
+	// readinessCommand: ["curl", "-sf", "http://localhost/health"]
+	// ```
+	ReadinessCommand []values.String `json:"readinessCommand" yaml:"readinessCommand,flow"`
+
+	// @default 10
+	//
+	// Timeout to wait before killing the {@ref #ReadinessCommand readinessCommand}. The command will be repeated
+	// after it was killed. If ``0`` the command will never be killed because of a timeout.
+	ReadinessTimeoutInSeconds values.NonNegativeInteger `json:"readinessTimeoutInSeconds" yaml:"readinessTimeoutInSeconds"`
+
 	// @default ""
 	//
 	// If configured this will trigger the service at this specific times. If not the service will
@@ -169,18 +200,93 @@ type Config struct {
 	// @default []
 	//
 	// Environment variables to pass to the process.
+	//
+	// # Precedence
+	//
+	// The environment of the process is assembled in the following order. If a variable is defined
+	// more than once the later one wins:
+	//
+	// 1. Environment of caretakerd itself (only if {@ref #InheritEnvironment inheritEnvironment} is enabled)
+	// 2. {@ref github.com/echocat/caretakerd.Config#Environment Global environment}
+	// 3. {@ref #EnvironmentFiles environmentFiles} (in the configured order)
+	// 4. This property
+	// 5. ``CTD_PEM``, ``CTD_TOKEN`` and ``CTD_CA`` (managed by caretakerd, see {@ref #Access access}).
+	//    ``CTD_CA`` contains the certificates to verify caretakerd - like required by clients that only have a token.
+	//
+	// > **Important:** The values are interpolated while loading the configuration. A literal ``$`` has to be
+	// > written as ``$$``. For details see [Interpolation](#configuration.interpolation).
 	Environment Environments `json:"environment" yaml:"environment"`
 
+	// @default []
+	//
+	// Files in dotenv format which contain environment variables to pass to the process.
+	// The files are read before every start of the process.
+	//
+	// If a filename is prefixed with ``-`` the file may be missing.
+	//
+	// Example:
+	// ```yaml
+	// environmentFiles: ["/etc/myService/defaults.env", "-/run/secrets/myService.env"]
+	// ```
+	//
+	// Format of the files:
+	// ```bash
+	// # Comments and empty lines are ignored.
+	// export DATABASE_USER=myService
+	// DATABASE_PASSWORD='very$ecret'
+	// GREETING="Hello\nworld!"
+	// ```
+	//
+	// For the precedence of variables see {@ref #Environment environment}.
+	EnvironmentFiles []values.String `json:"environmentFiles" yaml:"environmentFiles"`
+
 	// @default true
 	//
 	// Additionally pass the environment variables started with caretakerd to the service process.
+	//
+	// Which of these variables are passed could be restricted using {@ref #InheritEnvironmentInclude inheritEnvironmentInclude}
+	// and {@ref #InheritEnvironmentExclude inheritEnvironmentExclude}.
 	InheritEnvironment values.Boolean `json:"inheritEnvironment" yaml:"inheritEnvironment"`
 
+	// @default []
+	//
+	// If not empty only the environment variables of caretakerd whose names matches at least one of these
+	// glob patterns (like ``LANG`` or ``JAVA_*``) will be passed to the service process.
+	// This requires {@ref #InheritEnvironment inheritEnvironment} enabled.
+	//
+	// > **Hint:** The configuration variables of caretakerd (``CTD.*``) are never passed to a service process.
+	// > Except if they are explicitly matched by a pattern of this property which starts with ``CTD.``.
+	InheritEnvironmentInclude []values.String `json:"inheritEnvironmentInclude" yaml:"inheritEnvironmentInclude"`
+
+	// @default []
+	//
+	// Environment variables of caretakerd whose names matches at least one of these glob patterns
+	// (like ``AWS_*``) will not be passed to the service process.
+	// This requires {@ref #InheritEnvironment inheritEnvironment} enabled.
+	InheritEnvironmentExclude []values.String `json:"inheritEnvironmentExclude" yaml:"inheritEnvironmentExclude"`
+
 	// @default ""
 	//
 	// Working directory to start the service process in.
 	Directory values.String `json:"directory" yaml:"directory"`
 
+	// @default ""
+	//
+	// Seccomp profile that restricts the syscalls the service process is allowed to call.
+	// The profile is installed right before the process is executed.
+	//
+	// Possible values:
+	//
+	// * ``""``: No restrictions.
+	// * ``default-deny-dangerous``: Built-in profile that kills the process if it calls syscalls
+	//   like ``ptrace``, ``mount``, ``reboot`` or ``init_module``.
+	// * Path to a JSON file containing a {@ref github.com/echocat/caretakerd/seccomp.Profile}.
+	//
+	// If the process is killed because of a violation this is reported in the exit information of the service.
+	//
+	// > **Hint:** This is only supported on Linux (amd64 and arm64).
+	SeccompProfile values.String `json:"seccompProfile" yaml:"seccompProfile"`
+
 	// @default onFailures
 	//
 	// Configure how caretakerd will handle the end of a process.
@@ -196,6 +302,28 @@ type Config struct {
 	// If a process should be restarted (because of {@ref #AutoRestart autoRestart}), caretakerd will wait this seconds before restart is initiated.
 	RestartDelayInSeconds values.NonNegativeInteger `json:"restartDelayInSeconds" yaml:"restartDelayInSeconds"`
 
+	// @default false
+	//
+	// If ``true``, this service is restarted after its generated certificate was renewed.
+	// See {@ref github.com/echocat/caretakerd/keyStore.Config#RenewBefore renewBefore}.
+	//
+	// This is required for services that read their certificate only once at startup - like every service with the
+	// access type {@ref github.com/echocat/caretakerd/access.Type#GenerateToEnvironment generateToEnvironment}.
+	RestartOnCertificateRenewal values.Boolean `json:"restartOnCertificateRenewal" yaml:"restartOnCertificateRenewal"`
+
+	// @default {}
+	//
+	// Labels which describe this service. They could be used by the
+	// {@ref github.com/echocat/caretakerd/access.Role#Selector selector} of roles
+	// to grant verbs on a group of services.
+	//
+	// Example:
+	// ```yaml
+	// labels:
+	//     group: workers
+	// ```
+	Labels values.Labels `json:"labels,omitempty" yaml:"labels,omitempty"`
+
 	// Configures the permission of this service to control caretakerd remotely
 	// and how to obtain the credentials for it.
 	//
@@ -206,6 +334,9 @@ type Config struct {
 	//
 	// For details see {@ref github.com/echocat/caretakerd/logger.Config}.
 	Logger logger.Config `json:"logger" yaml:"logger,omitempty"`
+
+	// Contains the source where this config comes from.
+	Source values.String `json:"-" yaml:"-"`
 }
 
 // NewConfig creates a new instance of Config.
@@ -226,10 +357,13 @@ func (instance *Config) init() {
 	(*instance).Command = []values.String{}
 	(*instance).PreCommands = [][]values.String{}
 	(*instance).PostCommands = [][]values.String{}
+	(*instance).ReadinessCommand = []values.String{}
+	(*instance).ReadinessTimeoutInSeconds = values.NonNegativeInteger(10)
 	(*instance).Type = AutoStart
 	(*instance).CronExpression = NewCronExpression()
 	(*instance).StartDelayInSeconds = values.NonNegativeInteger(0)
 	(*instance).RestartDelayInSeconds = values.NonNegativeInteger(5)
+	(*instance).RestartOnCertificateRenewal = values.Boolean(false)
 	(*instance).SuccessExitCodes = values.ExitCodes{values.ExitCode(0)}
 	(*instance).StopSignal = defaultStopSignal()
 	(*instance).StopSignalTarget = values.ProcessGroup
@@ -237,9 +371,14 @@ func (instance *Config) init() {
 	(*instance).StopWaitInSeconds = values.NonNegativeInteger(30)
 	(*instance).User = values.String("")
 	(*instance).Environment = Environments{}
+	(*instance).EnvironmentFiles = []values.String{}
 	(*instance).Directory = values.String("")
+	(*instance).SeccompProfile = values.String("")
 	(*instance).AutoRestart = values.OnFailures
 	(*instance).InheritEnvironment = values.Boolean(true)
+	(*instance).InheritEnvironmentInclude = []values.String{}
+	(*instance).InheritEnvironmentExclude = []values.String{}
+	(*instance).Labels = values.Labels{}
 	(*instance).Access = access.NewNoneConfig()
 }
 
@@ -250,3 +389,11 @@ func (instance *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type noMethods Config
 	return unmarshal((*noMethods)(instance))
 }
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Config) UnmarshalJSON(b []byte) error {
+	instance.init()
+
+	type noMethods Config
+	return json.Unmarshal(b, (*noMethods)(instance))
+}
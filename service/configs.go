@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
 )
 
 // Configs represents a couple of named service configs.
@@ -24,6 +25,26 @@ func (s *Configs) GetMasterName() (string, bool) {
 	return "", false
 }
 
+// SetSource records the given source at every contained config.
+func (s Configs) SetSource(source values.String) {
+	for name, conf := range s {
+		conf.Source = source
+		s[name] = conf
+	}
+}
+
+// Merge adds every config of the given Configs to this instance.
+// If a service with the same name already exists an error is returned.
+func (s Configs) Merge(other Configs) error {
+	for name, conf := range other {
+		if existing, ok := s[name]; ok {
+			return errors.New("Service '%s' is defined in '%v' and '%v'.", name, existing.Source, conf.Source)
+		}
+		s[name] = conf
+	}
+	return nil
+}
+
 // Configure executes a configuring action for a service with the given name.
 func (s *Configs) Configure(serviceName string, value string, with func(conf *Config, value string) error) error {
 	conf, ok := (*s)[serviceName]
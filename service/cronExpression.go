@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"gopkg.in/robfig/cron.v2"
 	"strings"
 	"time"
@@ -112,6 +113,20 @@ func (instance *CronExpression) UnmarshalYAML(unmarshal func(interface{}) error)
 	return instance.Set(value)
 }
 
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance CronExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(instance.String())
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *CronExpression) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
 // IsEnabled returns "true" if this expression is valid and enabled.
 func (instance CronExpression) IsEnabled() bool {
 	return instance.schedule != nil
@@ -2,9 +2,13 @@ package service
 
 import (
 	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"path"
 	"strings"
 )
 
+const configurationEnvironmentPrefix = "CTD."
+
 // Environments represents a couple of key value pair environment variables.
 // @inline
 type Environments map[string]string
@@ -61,3 +65,39 @@ func (i *Environments) Put(key string, value string) error {
 	(*i)[key] = value
 	return nil
 }
+
+// InheritedEnvironmentFor filters the given environ (in format of os.Environ()) by the inherit environment
+// settings of the given config. The configuration variables of caretakerd (CTD.*) are only returned if
+// they are explicitly included.
+func InheritedEnvironmentFor(config Config, environ []string) []string {
+	result := []string{}
+	if !config.InheritEnvironment {
+		return result
+	}
+	for _, entry := range environ {
+		name := strings.SplitN(entry, "=", 2)[0]
+		isConfiguration := strings.HasPrefix(strings.ToUpper(name), configurationEnvironmentPrefix)
+		included := !isConfiguration && len(config.InheritEnvironmentInclude) == 0
+		for _, pattern := range config.InheritEnvironmentInclude {
+			if matchesEnvironmentPattern(pattern, name) && (!isConfiguration || strings.HasPrefix(strings.ToUpper(pattern.String()), configurationEnvironmentPrefix)) {
+				included = true
+				break
+			}
+		}
+		for _, pattern := range config.InheritEnvironmentExclude {
+			if matchesEnvironmentPattern(pattern, name) {
+				included = false
+				break
+			}
+		}
+		if included {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func matchesEnvironmentPattern(pattern values.String, name string) bool {
+	matches, err := path.Match(pattern.String(), name)
+	return err == nil && matches
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"strings"
+)
+
+// LoadEnvironmentFile reads the given file in dotenv format and returns the contained variables.
+// If the filename starts with "-" the file may be missing; in this case an empty result is returned.
+func LoadEnvironmentFile(filename values.String) (Environments, error) {
+	name, optional := filename.String(), false
+	if strings.HasPrefix(name, "-") {
+		name, optional = name[1:], true
+	}
+	content, err := os.ReadFile(name)
+	if os.IsNotExist(err) && optional {
+		return Environments{}, nil
+	} else if err != nil {
+		return nil, errors.New("Could not read environment file '%v'.", name).CausedBy(err)
+	}
+	result, err := ParseEnvironmentFile(content)
+	if err != nil {
+		return nil, errors.New("Could not parse environment file '%v'.", name).CausedBy(err)
+	}
+	return result, nil
+}
+
+// ParseEnvironmentFile parses the given content in dotenv format.
+//
+// Every line contains one "KEY=value" pair and could optionally be prefixed with "export ".
+// Empty lines and lines starting with "#" are ignored. Values could be enclosed in single quotes
+// (taken literally) or double quotes (supports the escape sequences \n, \r, \t, \" and \\).
+// Unquoted values are trimmed and everything after " #" is treated as comment.
+func ParseEnvironmentFile(content []byte) (Environments, error) {
+	result := Environments{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || len(key) == 0 || strings.ContainsAny(key, " \t") {
+			return nil, errors.New("Illegal environment settings format in line %d: %s", lineNumber, line)
+		}
+		value, err := parseEnvironmentFileValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, errors.New("Illegal value in line %d.", lineNumber).CausedBy(err)
+		}
+		result[key] = value
+	}
+	return result, scanner.Err()
+}
+
+func parseEnvironmentFileValue(value string) (string, error) {
+	if strings.HasPrefix(value, "'") {
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errors.New("Missing closing quote.")
+		}
+		return value[1 : end+1], nil
+	}
+	if strings.HasPrefix(value, "\"") {
+		result := ""
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			if c == '"' {
+				return result, nil
+			} else if c == '\\' && i+1 < len(value) {
+				i++
+				switch value[i] {
+				case 'n':
+					result += "\n"
+				case 'r':
+					result += "\r"
+				case 't':
+					result += "\t"
+				default:
+					result += string(value[i])
+				}
+			} else {
+				result += string(c)
+			}
+		}
+		return "", errors.New("Missing closing quote.")
+	}
+	if index := strings.Index(value, " #"); index >= 0 {
+		value = value[:index]
+	}
+	return strings.TrimSpace(value), nil
+}
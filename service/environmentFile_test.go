@@ -0,0 +1,90 @@
+package service
+
+import (
+	. "gopkg.in/check.v1"
+	"os"
+	"path/filepath"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type EnvironmentFileTest struct{}
+
+func init() {
+	Suite(&EnvironmentFileTest{})
+}
+
+func (s *EnvironmentFileTest) TestParseEnvironmentFile(c *C) {
+	actual, err := ParseEnvironmentFile([]byte(`
+# A comment
+A=1
+export B = 2
+C=foo bar # comment
+D='lit$eral # no comment'
+E="line1\nline2 \"quoted\" \\"
+F=
+`))
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, Environments{
+		"A": "1",
+		"B": "2",
+		"C": "foo bar",
+		"D": "lit$eral # no comment",
+		"E": "line1\nline2 \"quoted\" \\",
+		"F": "",
+	})
+}
+
+func (s *EnvironmentFileTest) TestParseEnvironmentFileWithIllegalLine(c *C) {
+	_, err := ParseEnvironmentFile([]byte("A=1\nfoo\n"))
+	c.Assert(err, ErrorMatches, "Illegal environment settings format in line 2: foo")
+}
+
+func (s *EnvironmentFileTest) TestParseEnvironmentFileWithMissingQuote(c *C) {
+	_, err := ParseEnvironmentFile([]byte("A=\"foo\n"))
+	c.Assert(err, ErrorMatches, "(?s)Illegal value in line 1.*Missing closing quote.*")
+}
+
+func (s *EnvironmentFileTest) TestLoadEnvironmentFile(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.env")
+	c.Assert(os.WriteFile(filename, []byte("A=1\n"), 0600), IsNil)
+
+	actual, err := LoadEnvironmentFile(values.String(filename))
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, Environments{"A": "1"})
+
+	actual, err = LoadEnvironmentFile(values.String("-" + filename))
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, Environments{"A": "1"})
+}
+
+func (s *EnvironmentFileTest) TestLoadMissingEnvironmentFile(c *C) {
+	filename := filepath.Join(c.MkDir(), "missing.env")
+
+	_, err := LoadEnvironmentFile(values.String(filename))
+	c.Assert(err, ErrorMatches, "(?s)Could not read environment file.*")
+
+	actual, err := LoadEnvironmentFile(values.String("-" + filename))
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, Environments{})
+}
+
+func (s *EnvironmentFileTest) TestServiceEnvironmentPrecedence(c *C) {
+	filename := filepath.Join(c.MkDir(), "test.env")
+	c.Assert(os.WriteFile(filename, []byte("A=file\nB=file\n"), 0600), IsNil)
+	conf := NewConfig()
+	conf.EnvironmentFiles = []values.String{values.String(filename)}
+	conf.Environment = Environments{"B": "inline"}
+	service := &Service{
+		config:            conf,
+		globalEnvironment: Environments{"A": "global", "G": "global"},
+	}
+
+	actual, err := service.Environment()
+	c.Assert(err, IsNil)
+	c.Assert(actual, DeepEquals, Environments{
+		"A": "file",
+		"B": "inline",
+		"G": "global",
+	})
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+)
+
+type EnvironmentTest struct{}
+
+func init() {
+	Suite(&EnvironmentTest{})
+}
+
+var testEnviron = []string{
+	"HOME=/root",
+	"LANG=C",
+	"JAVA_HOME=/opt/java",
+	"JAVA_OPTS=-Xmx1g",
+	"CTD.foo.ENV.SECRET=secret",
+	"ctd.LOG_LEVEL=debug",
+}
+
+func (s *EnvironmentTest) TestInheritedEnvironmentWithoutPatterns(c *C) {
+	conf := NewConfig()
+	c.Assert(InheritedEnvironmentFor(conf, testEnviron), DeepEquals, []string{
+		"HOME=/root",
+		"LANG=C",
+		"JAVA_HOME=/opt/java",
+		"JAVA_OPTS=-Xmx1g",
+	})
+}
+
+func (s *EnvironmentTest) TestInheritedEnvironmentDisabled(c *C) {
+	conf := NewConfig()
+	conf.InheritEnvironment = values.Boolean(false)
+	conf.InheritEnvironmentInclude = []values.String{"*"}
+	c.Assert(InheritedEnvironmentFor(conf, testEnviron), DeepEquals, []string{})
+}
+
+func (s *EnvironmentTest) TestInheritedEnvironmentWithInclude(c *C) {
+	conf := NewConfig()
+	conf.InheritEnvironmentInclude = []values.String{"JAVA_*", "LANG"}
+	c.Assert(InheritedEnvironmentFor(conf, testEnviron), DeepEquals, []string{
+		"LANG=C",
+		"JAVA_HOME=/opt/java",
+		"JAVA_OPTS=-Xmx1g",
+	})
+}
+
+func (s *EnvironmentTest) TestInheritedEnvironmentWithExclude(c *C) {
+	conf := NewConfig()
+	conf.InheritEnvironmentInclude = []values.String{"*"}
+	conf.InheritEnvironmentExclude = []values.String{"JAVA_*"}
+	c.Assert(InheritedEnvironmentFor(conf, testEnviron), DeepEquals, []string{
+		"HOME=/root",
+		"LANG=C",
+	})
+}
+
+func (s *EnvironmentTest) TestInheritedEnvironmentWithExplicitConfigurationInclude(c *C) {
+	conf := NewConfig()
+	conf.InheritEnvironmentInclude = []values.String{"HOME", "CTD.LOG_*", "ctd.*"}
+	conf.InheritEnvironmentExclude = []values.String{"CTD.foo.*"}
+	c.Assert(InheritedEnvironmentFor(conf, testEnviron), DeepEquals, []string{
+		"HOME=/root",
+		"ctd.LOG_LEVEL=debug",
+	})
+}
+
+func (s *EnvironmentTest) TestValidateIllegalEnvironmentPattern(c *C) {
+	conf := NewConfig().WithCommand("foo")
+	conf.InheritEnvironmentExclude = []values.String{"[a"}
+	c.Assert(conf.Validate(), ErrorMatches, "(?s)Illegal environment pattern: \\[a.*")
+}
+
+func (s *EnvironmentTest) TestValidateDoesNotModifyEnvironmentPatterns(c *C) {
+	conf := NewConfig().WithCommand("foo")
+	include := make([]values.String, 1, 2)
+	include[0] = "HOME"
+	conf.InheritEnvironmentInclude = include
+	conf.InheritEnvironmentExclude = []values.String{"CTD_*"}
+	c.Assert(conf.Validate(), IsNil)
+	c.Assert(include[:2], DeepEquals, []values.String{"HOME", ""})
+}
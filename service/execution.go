@@ -5,16 +5,21 @@ import (
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/keyStore"
 	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/seccomp"
 	"github.com/echocat/caretakerd/sync"
 	"github.com/echocat/caretakerd/values"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 )
 
+const readinessCheckInterval = 1 * time.Second
+const readinessPollInterval = 100 * time.Millisecond
+
 // Execution represents an execution of a service.
 // An execution could only be used one times.
 type Execution struct {
@@ -26,76 +31,115 @@ type Execution struct {
 	condition *sync.Condition
 	access    *access.Access
 	syncGroup *sync.Group
+	startedAt *time.Time
+	ready     bool
+
+	environment Environments
 }
 
 // NewExecution creates a new instance of Execution.
 func (instance *Service) NewExecution(sec *keyStore.KeyStore) (*Execution, error) {
+	environment, err := instance.Environment()
+	if err != nil {
+		return nil, errors.New("Could not resolve environment of service '%v'.", instance).CausedBy(err)
+	}
+	cmd, err := generateServiceBasedCmd(instance, instance.access, environment, (*instance).config.Command)
+	if err != nil {
+		return nil, err
+	}
 	syncGroup := instance.syncGroup.NewGroup()
-	cmd := generateServiceBasedCmd(instance, instance.access, (*instance).config.Command)
 	lock := syncGroup.NewMutex()
 	condition := syncGroup.NewCondition(lock)
 	return &Execution{
-		service:   instance,
-		logger:    instance.logger,
-		cmd:       cmd,
-		status:    New,
-		lock:      lock,
-		condition: condition,
-		access:    instance.access,
-		syncGroup: syncGroup,
+		service:     instance,
+		logger:      instance.logger,
+		cmd:         cmd,
+		status:      New,
+		lock:        lock,
+		condition:   condition,
+		access:      instance.access,
+		syncGroup:   syncGroup,
+		environment: environment,
 	}, nil
 }
 
-func (instance *Service) expandValue(ai *access.Access, in string) string {
-	return os.Expand(in, func(key string) string {
-		if value, ok := (*instance).config.Environment[key]; ok {
-			return value
-		} else if key == "CTD_PEM" {
+func expandValue(ai *access.Access, environment Environments, in string) (string, error) {
+	return values.Expand(in, func(key string) (string, bool) {
+		if key == "CTD_PEM" {
 			if ai.Type() == access.GenerateToEnvironment {
-				return string(ai.Pem())
+				return string(ai.Pem()), true
 			}
-			return ""
+			return "", true
+		} else if key == "CTD_TOKEN" {
+			return ai.Token(), true
+		} else if value, ok := environment[key]; ok {
+			return value, true
 		}
-		return os.Getenv(key)
+		return os.LookupEnv(key)
 	})
 }
 
-func getServiceBasedRunArgumentsFor(s *Service, ai *access.Access, command []values.String) []string {
-	args := []string{}
-	for i := 1; i < len(command); i++ {
-		args = append(args, s.expandValue(ai, command[i].String()))
+func expandValues(ai *access.Access, environment Environments, command []values.String) ([]string, error) {
+	result := make([]string, len(command))
+	for i, part := range command {
+		expanded, err := expandValue(ai, environment, part.String())
+		if err != nil {
+			return nil, err
+		}
+		result[i] = expanded
 	}
-	return args
+	return result, nil
 }
 
-func generateServiceBasedCmd(s *Service, ai *access.Access, command []values.String) *exec.Cmd {
+func generateServiceBasedCmd(s *Service, ai *access.Access, environment Environments, command []values.String) (*exec.Cmd, error) {
 	logger := (*s).logger
 	config := (*s).config
-	executable := s.expandValue(ai, command[0].String())
-	cmd := exec.Command(executable, getServiceBasedRunArgumentsFor(s, ai, command)...)
+	expandedCommand, err := expandValues(ai, environment, command)
+	if err != nil {
+		return nil, errors.New("Could not evaluate command of service '%v'.", s).CausedBy(err)
+	}
+	cmd := exec.Command(expandedCommand[0], expandedCommand[1:]...)
 	cmd.Stdout = logger.Stdout()
 	cmd.Stderr = logger.Stderr()
 	cmd.SysProcAttr = s.createSysProcAttr()
 	if !config.Directory.IsTrimmedEmpty() {
-		cmd.Dir = s.expandValue(ai, config.Directory.String())
+		if cmd.Dir, err = expandValue(ai, environment, config.Directory.String()); err != nil {
+			return nil, errors.New("Could not evaluate directory of service '%v'.", s).CausedBy(err)
+		}
 	}
-	for key, value := range config.Environment {
-		cmd.Env = append(cmd.Env, key+"="+value)
+	// The order is important: If a key is contained more than once, the last one wins.
+	cmd.Env = []string{}
+	cmd.Env = append(cmd.Env, InheritedEnvironmentFor(config, os.Environ())...)
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+environment[key])
 	}
 	if ai.Type() == access.GenerateToEnvironment {
 		cmd.Env = append(cmd.Env, "CTD_PEM="+string(ai.Pem()))
 	} else {
 		cmd.Env = append(cmd.Env, "CTD_PEM=")
 	}
-	if config.InheritEnvironment {
-		cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env, "CTD_TOKEN="+ai.Token())
+	if s.keyStore != nil && s.keyStore.IsEnabled() {
+		cmd.Env = append(cmd.Env, "CTD_CA="+string(s.keyStore.CABundle()))
+	} else {
+		cmd.Env = append(cmd.Env, "CTD_CA=")
 	}
 	serviceHandleUsersFor(s, cmd)
-	return cmd
+	if s.seccompProfile != nil {
+		if err := seccomp.WrapCommand(cmd, *s.seccompProfile); err != nil {
+			return nil, errors.New("Could not apply seccomp profile to service '%v'.", s).CausedBy(err)
+		}
+	}
+	return cmd, nil
 }
 
-func (instance *Execution) generateCmd(command []values.String) *exec.Cmd {
-	return generateServiceBasedCmd(instance.service, instance.access, command)
+func (instance *Execution) generateCmd(command []values.String) (*exec.Cmd, error) {
+	return generateServiceBasedCmd(instance.service, instance.access, instance.environment, command)
 }
 
 func (instance *Execution) extractCommandProperties(command []values.String) (cleanCommand []values.String, handleErrors bool) {
@@ -138,7 +182,11 @@ func (instance *Execution) preExecution() (values.ExitCode, error) {
 	for _, preCommand := range preCommands {
 		command, handleErrors := instance.extractCommandProperties(preCommand)
 		if len(command) > 0 {
-			cmd := instance.generateCmd(command)
+			cmd, err := instance.generateCmd(command)
+			if err != nil {
+				instance.logger.LogProblem(err, logger.Error, "Pre command failed.")
+				return values.ExitCode(1), err
+			}
 			instance.logger.Log(logger.Debug, "Execute pre command: %s", instance.commandLineOf(cmd))
 			exitCode, err := instance.runCommand(cmd)
 			if handleErrors {
@@ -160,7 +208,11 @@ func (instance *Execution) postExecution() {
 	for _, preCommand := range postCommands {
 		command, handleErrors := instance.extractCommandProperties(preCommand)
 		if len(command) > 0 {
-			cmd := instance.generateCmd(command)
+			cmd, err := instance.generateCmd(command)
+			if err != nil {
+				instance.logger.LogProblem(err, logger.Warning, "Post command failed.")
+				continue
+			}
 			instance.logger.Log(logger.Debug, "Execute post command: %s", instance.commandLineOf(cmd))
 			exitCode, err := instance.runCommand(cmd)
 			if handleErrors {
@@ -187,6 +239,11 @@ func (instance *Execution) Run() (values.ExitCode, error) {
 	}
 	instance.logger.Log(logger.Debug, "Start service '%s' with command: %s", instance.Name(), instance.commandLineOf(instance.cmd))
 	exitCode, lastState, err := instance.runBare()
+	lastExit := newExitInformationFor(instance.cmd, exitCode, instance.service.seccompProfile != nil)
+	instance.service.setLastExit(lastExit)
+	if lastExit.SeccompViolation {
+		instance.logger.Log(logger.Error, "Service '%s' was killed because it violated its seccomp profile.", instance.Name())
+	}
 	if lastState == Killed {
 		err = StoppedOrKilledError{error: errors.New("Process was killed.")}
 		instance.logger.Log(logger.Debug, "Service '%s' ended after kill: %d", instance.Name(), exitCode)
@@ -217,8 +274,12 @@ type StoppedOrKilledError struct {
 }
 
 func (instance *Execution) runCommand(cmd *exec.Cmd) (values.ExitCode, error) {
+	return exitCodeOf(cmd, cmd.Run())
+}
+
+func exitCodeOf(cmd *exec.Cmd, err error) (values.ExitCode, error) {
 	var waitStatus syscall.WaitStatus
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			waitStatus = exitError.Sys().(syscall.WaitStatus)
 			exitSignal := waitStatus.Signal()
@@ -236,6 +297,7 @@ func (instance *Execution) runCommand(cmd *exec.Cmd) (values.ExitCode, error) {
 func (instance *Execution) runBare() (values.ExitCode, Status, error) {
 	if instance.doTrySetRunningState() {
 		defer instance.doSetDownState()
+		go instance.checkReadiness()
 		exitCode, err := instance.runCommand((*instance).cmd)
 		// This little sleep is required because there is no guarantee anymore that every lock is
 		// respected if the routines are interrupted.
@@ -245,13 +307,97 @@ func (instance *Execution) runBare() (values.ExitCode, Status, error) {
 	return values.ExitCode(0), instance.getSyncedCurrentStatus(), UnrecoverableError{error: errors.New("Cannot run service. Already in status: %v", instance.status)}
 }
 
+// Executes the readiness command of the service until it succeeds or the execution is not running anymore.
+func (instance *Execution) checkReadiness() {
+	command, _ := instance.extractCommandProperties(instance.service.config.ReadinessCommand)
+	if len(command) == 0 {
+		return
+	}
+	for {
+		if err := instance.syncGroup.Sleep(readinessCheckInterval); err != nil || instance.getSyncedCurrentStatus() != Running {
+			return
+		}
+		cmd, err := instance.generateCmd(command)
+		if err != nil {
+			instance.logger.LogProblem(err, logger.Warning, "Readiness command failed.")
+			return
+		}
+		exitCode, err := instance.runReadinessCommand(cmd)
+		if err != nil {
+			instance.logger.LogProblem(err, logger.Debug, "Readiness command failed.")
+		} else if exitCode == 0 {
+			if instance.setReady() {
+				instance.logger.Log(logger.Debug, "Service '%s' is ready.", instance.Name())
+			}
+			return
+		}
+	}
+}
+
+// Runs the given readiness command until it ends. If it does not end within the readinessTimeoutInSeconds or
+// the execution is not running anymore it will be killed.
+func (instance *Execution) runReadinessCommand(cmd *exec.Cmd) (values.ExitCode, error) {
+	if err := cmd.Start(); err != nil {
+		return values.ExitCode(0), UnrecoverableError{error: err}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	var timeout <-chan time.Time
+	if timeoutInSeconds := instance.service.config.ReadinessTimeoutInSeconds; timeoutInSeconds > 0 {
+		timeout = time.After(time.Duration(timeoutInSeconds) * time.Second)
+	}
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return exitCodeOf(cmd, err)
+		case <-timeout:
+			instance.killReadinessCommand(cmd, done)
+			return values.ExitCode(0), errors.New("Readiness command did not respond after %d seconds.", instance.service.config.ReadinessTimeoutInSeconds)
+		case <-ticker.C:
+			if instance.getSyncedCurrentStatus() != Running {
+				instance.killReadinessCommand(cmd, done)
+				return values.ExitCode(0), errors.New("Service '%s' is not running anymore.", instance.Name())
+			}
+		}
+	}
+}
+
+func (instance *Execution) killReadinessCommand(cmd *exec.Cmd, done <-chan error) {
+	if err := sendSignalToService(instance.service, cmd.Process, values.KILL, values.ProcessGroup); err != nil {
+		instance.logger.LogProblem(err, logger.Warning, "Could not kill readiness command.")
+	}
+	<-done
+}
+
+func (instance *Execution) setReady() bool {
+	if instance.doLock() != nil {
+		return false
+	}
+	defer instance.doUnlock()
+	if instance.status != Running {
+		return false
+	}
+	(*instance).ready = true
+	instance.service.notifyChanged()
+	return true
+}
+
 func (instance *Execution) doTrySetRunningState() bool {
 	if instance.doLock() != nil {
 		return false
 	}
 	defer instance.doUnlock()
 	if (*instance).status == New {
+		now := time.Now()
 		(*instance).status = Running
+		(*instance).startedAt = &now
+		(*instance).ready = len(instance.service.config.ReadinessCommand) == 0
+		instance.service.registerStart()
+		instance.service.notifyChanged()
 		return true
 	}
 	return false
@@ -281,6 +427,7 @@ func (instance *Execution) setStateTo(ns Status) bool {
 	if cs != Down || (ns != Killed && ns != Stopped) {
 		(*instance).status = ns
 		_ = instance.condition.Send()
+		instance.service.notifyChanged()
 		if cs == Down {
 			instance.access.Cleanup()
 		}
@@ -321,7 +468,12 @@ func (instance *Execution) sendStop() {
 		c := (*instance).service.config
 		stopCommand, handleErrors := instance.extractCommandProperties(c.StopCommand)
 		if len(stopCommand) > 0 {
-			cmd := instance.generateCmd(stopCommand)
+			cmd, err := instance.generateCmd(stopCommand)
+			if err != nil {
+				instance.logger.LogProblem(err, logger.Warning, "Stop command failed. Sending stop signal instead.")
+				_ = instance.sendSignal(c.StopSignal)
+				return
+			}
 			instance.logger.Log(logger.Debug, "Execute stop command: %s", instance.commandLineOf(cmd))
 			exitCode, err := instance.runCommand(cmd)
 			if handleErrors {
@@ -446,6 +598,25 @@ func (instance *Execution) Status() Status {
 	return instance.status
 }
 
+// StartedAt returns when the process of this execution was started.
+// If the execution was not started yet nil is returned.
+func (instance *Execution) StartedAt() *time.Time {
+	if instance.doLock() != nil {
+		return nil
+	}
+	defer instance.doUnlock()
+	return instance.startedAt
+}
+
+// Ready returns "true" if this execution is running and its readiness command succeeded.
+func (instance *Execution) Ready() bool {
+	if instance.doLock() != nil {
+		return false
+	}
+	defer instance.doUnlock()
+	return instance.status == Running && instance.ready
+}
+
 // Service returns the service this execution belongs to.
 func (instance Execution) Service() *Service {
 	return instance.service
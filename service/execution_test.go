@@ -0,0 +1,65 @@
+package service
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/access"
+	"github.com/echocat/caretakerd/keyStore"
+	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/seccomp"
+	"github.com/echocat/caretakerd/sync"
+	"github.com/echocat/caretakerd/values"
+)
+
+type ExecutionTest struct{}
+
+func init() {
+	Suite(&ExecutionTest{})
+}
+
+func (s *ExecutionTest) newService(c *C, conf Config) *Service {
+	log, err := logger.NewLogger(logger.NewConfig(), "test", sync.NewGroup())
+	c.Assert(err, IsNil)
+	ks, err := keyStore.NewKeyStore(false, keyStore.NewConfig())
+	c.Assert(err, IsNil)
+	acc, err := access.NewAccess(access.NewNoneConfig(), "test", ks)
+	c.Assert(err, IsNil)
+	return &Service{
+		name:      "test",
+		config:    conf,
+		logger:    log,
+		access:    acc,
+		syncGroup: sync.NewGroup(),
+	}
+}
+
+// Creates an execution of a service that is already running.
+func (s *ExecutionTest) newRunningExecution(c *C, conf Config) *Execution {
+	ks, err := keyStore.NewKeyStore(false, keyStore.NewConfig())
+	c.Assert(err, IsNil)
+	service, err := NewService(conf.WithCommand("true"), "test", Environments{}, sync.NewGroup(), ks)
+	c.Assert(err, IsNil)
+	execution, err := service.NewExecution(ks)
+	c.Assert(err, IsNil)
+	execution.status = Running
+	return execution
+}
+
+func (s *ExecutionTest) TestGenerateServiceBasedCmdWithIllegalSeccompProfile(c *C) {
+	service := s.newService(c, NewConfig())
+	service.seccompProfile = &seccomp.Profile{Deny: []string{"caretakerd_unknown"}}
+
+	_, err := generateServiceBasedCmd(service, service.access, Environments{}, []values.String{"true"})
+	c.Assert(err, ErrorMatches, "(?s)Could not apply seccomp profile to service .*")
+}
+
+func (s *ExecutionTest) TestGenerateCmdOfReadinessCommandUsesEnvironmentOfService(c *C) {
+	conf := NewConfig()
+	conf.Environment = Environments{"HEALTH_URL": "http://localhost/health"}
+	conf.ReadinessCommand = []values.String{"curl", "-sf", "${HEALTH_URL}"}
+	execution := s.newRunningExecution(c, conf)
+
+	cmd, err := execution.generateCmd(conf.ReadinessCommand)
+	c.Assert(err, IsNil)
+	c.Assert(cmd.Args[1:], DeepEquals, []string{"-sf", "http://localhost/health"})
+}
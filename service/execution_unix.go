@@ -19,7 +19,9 @@ func serviceHandleUsersFor(service *Service, cmd *exec.Cmd) {
 	config := (*service).config
 	userName := config.User
 	if !userName.IsTrimmedEmpty() {
-		cmd.SysProcAttr = &syscall.SysProcAttr{}
+		if cmd.SysProcAttr == nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
 		uid, gid, err := lookupUser(userName.String())
 		if err != nil {
 			panics.New("Could not run as user '%v'.", userName).CausedBy(err).Throw()
@@ -104,3 +106,8 @@ func lookupUserInPasswd(uid string) (*user.User, error) {
 	}
 	return nil, errors.New("User not found in /etc/passwd")
 }
+
+func checkUser(username string) error {
+	_, _, err := lookupUser(username)
+	return err
+}
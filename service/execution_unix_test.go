@@ -0,0 +1,78 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package service
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func (s *ExecutionTest) TestRunReadinessCommand(c *C) {
+	execution := s.newRunningExecution(c, NewConfig())
+	cmd, err := execution.generateCmd([]values.String{"sh", "-c", "exit 3"})
+	c.Assert(err, IsNil)
+	exitCode, err := execution.runReadinessCommand(cmd)
+	c.Assert(err, IsNil)
+	c.Assert(exitCode, Equals, values.ExitCode(3))
+}
+
+func (s *ExecutionTest) TestRunReadinessCommandKillsItAfterTimeout(c *C) {
+	conf := NewConfig()
+	conf.ReadinessTimeoutInSeconds = values.NonNegativeInteger(1)
+	execution := s.newRunningExecution(c, conf)
+	pidFile := filepath.Join(c.MkDir(), "child.pid")
+	cmd, err := execution.generateCmd([]values.String{"sh", "-c", "sleep 60 & echo $! > " + values.String(pidFile) + "; wait"})
+	c.Assert(err, IsNil)
+
+	started := time.Now()
+	_, err = execution.runReadinessCommand(cmd)
+	c.Assert(err, ErrorMatches, "Readiness command did not respond after 1 seconds.")
+	c.Assert(time.Since(started) < 10*time.Second, Equals, true)
+	c.Assert(cmd.ProcessState, NotNil)
+
+	// The children of the readiness command are killed, too.
+	plainPid, err := os.ReadFile(pidFile)
+	c.Assert(err, IsNil)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(plainPid)))
+	c.Assert(err, IsNil)
+	for i := 0; i < 100 && isProcessAlive(pid); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(isProcessAlive(pid), Equals, false)
+}
+
+// Orphaned processes are not always reaped inside of containers. Because of this zombies are treated as dead.
+func isProcessAlive(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
+
+func (s *ExecutionTest) TestRunReadinessCommandKillsItIfNotRunningAnymore(c *C) {
+	conf := NewConfig()
+	conf.ReadinessTimeoutInSeconds = values.NonNegativeInteger(0)
+	execution := s.newRunningExecution(c, conf)
+	cmd, err := execution.generateCmd([]values.String{"sleep", "60"})
+	c.Assert(err, IsNil)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		execution.doSetDownState()
+	}()
+
+	started := time.Now()
+	_, err = execution.runReadinessCommand(cmd)
+	c.Assert(err, ErrorMatches, "Service 'test' is not running anymore.")
+	c.Assert(time.Since(started) < 10*time.Second, Equals, true)
+	c.Assert(cmd.ProcessState, NotNil)
+}
@@ -121,3 +121,7 @@ func (instance *Service) createSysProcAttr() *syscall.SysProcAttr {
 		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
 	}
 }
+
+func checkUser(username string) error {
+	return errors.New("Users are not supported under windows.")
+}
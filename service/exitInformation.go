@@ -0,0 +1,31 @@
+package service
+
+import (
+	"github.com/echocat/caretakerd/values"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ExitInformation describes how the last execution of a service ended.
+type ExitInformation struct {
+	Code             values.ExitCode `json:"code"`
+	Signal           *values.Signal  `json:"signal,omitempty"`
+	SeccompViolation bool            `json:"seccompViolation,omitempty"`
+	Time             time.Time       `json:"time"`
+}
+
+func newExitInformationFor(cmd *exec.Cmd, exitCode values.ExitCode, withSeccompProfile bool) *ExitInformation {
+	result := &ExitInformation{
+		Code: exitCode,
+		Time: time.Now(),
+	}
+	if ps := cmd.ProcessState; ps != nil {
+		if waitStatus, ok := ps.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() && waitStatus.Signal() > 0 {
+			signal := values.Signal(waitStatus.Signal())
+			(*result).Signal = &signal
+			(*result).SeccompViolation = withSeccompProfile && signal == values.SYS
+		}
+	}
+	return result
+}
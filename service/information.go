@@ -2,6 +2,7 @@ package service
 
 import (
 	"github.com/echocat/caretakerd/values"
+	"time"
 )
 
 // Information represents the current status of a running execution of a service.
@@ -9,6 +10,11 @@ type Information struct {
 	Config Config         `json:"config"`
 	Status Status         `json:"status"`
 	PID    values.Integer `json:"pid"`
+	Ready  bool           `json:"ready"`
+
+	StartedAt *time.Time       `json:"startedAt,omitempty"`
+	Restarts  values.Integer   `json:"restarts"`
+	LastExit  *ExitInformation `json:"lastExit,omitempty"`
 }
 
 // NewInformationForExecution creates a new information instance for the given execution.
@@ -17,6 +23,11 @@ func NewInformationForExecution(e *Execution) Information {
 		Config: e.service.config,
 		Status: e.status,
 		PID:    values.Integer(e.PID()),
+		Ready:  e.Ready(),
+
+		StartedAt: e.StartedAt(),
+		Restarts:  values.Integer(e.service.Restarts()),
+		LastExit:  e.service.LastExit(),
 	}
 }
 
@@ -27,5 +38,8 @@ func NewInformationForService(s *Service) Information {
 		Config: s.config,
 		Status: Down,
 		PID:    0,
+
+		Restarts: values.Integer(s.Restarts()),
+		LastExit: s.LastExit(),
 	}
 }
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Lint returns warnings for every service config that is valid but will probably not
+// behave like expected. The warnings are sorted by the name of the service.
+func (instance Configs) Lint() []string {
+	names := make([]string, 0, len(instance))
+	for name := range instance {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []string{}
+	for _, name := range names {
+		for _, warning := range instance[name].Lint() {
+			result = append(result, fmt.Sprintf("Service '%s': %s", name, warning))
+		}
+	}
+	return result
+}
+
+// Lint returns warnings for settings of this config that are valid but will probably not
+// behave like expected. Values that contain variables are not checked because they
+// are only resolved on execution.
+func (instance Config) Lint() []string {
+	result := []string{}
+	if instance.CronExpression.IsEnabled() && instance.AutoRestart == values.Always {
+		result = append(result, "autoRestart 'always' has no effect because the service is already started again by its cronExpression.")
+	}
+	if len(instance.StopCommand) > 0 && instance.StopSignal != defaultStopSignal() {
+		result = append(result, fmt.Sprintf("stopSignal '%v' is never used because a stopCommand is configured.", instance.StopSignal))
+	}
+	if !instance.User.IsTrimmedEmpty() && !containsVariable(instance.User.String()) {
+		if err := checkUser(instance.User.String()); err != nil {
+			result = append(result, fmt.Sprintf("user '%v' could not be resolved: %v", instance.User, err))
+		}
+	}
+	if !instance.Directory.IsTrimmedEmpty() && !containsVariable(instance.Directory.String()) {
+		if fileInfo, err := os.Stat(instance.Directory.String()); err != nil {
+			result = append(result, fmt.Sprintf("directory '%v' does not exist.", instance.Directory))
+		} else if !fileInfo.IsDir() {
+			result = append(result, fmt.Sprintf("directory '%v' is not a directory.", instance.Directory))
+		}
+	}
+	result = instance.lintCommand("command", instance.Command, result)
+	for _, preCommand := range instance.PreCommands {
+		result = instance.lintCommand("preCommand", preCommand, result)
+	}
+	for _, postCommand := range instance.PostCommands {
+		result = instance.lintCommand("postCommand", postCommand, result)
+	}
+	result = instance.lintCommand("readinessCommand", instance.ReadinessCommand, result)
+	return instance.lintCommand("stopCommand", instance.StopCommand, result)
+}
+
+func (instance Config) lintCommand(kind string, command []values.String, result []string) []string {
+	if len(command) == 0 {
+		return result
+	}
+	executable := strings.TrimPrefix(command[0].String(), "-")
+	if len(executable) == 0 || containsVariable(executable) {
+		return result
+	}
+	candidate := executable
+	if strings.ContainsRune(executable, filepath.Separator) && !filepath.IsAbs(executable) && !instance.Directory.IsTrimmedEmpty() {
+		candidate = filepath.Join(instance.Directory.String(), executable)
+	}
+	if _, err := exec.LookPath(candidate); err != nil {
+		return append(result, fmt.Sprintf("%s '%s' could not be found or is not executable.", kind, executable))
+	}
+	return result
+}
+
+func containsVariable(what string) bool {
+	return strings.Contains(what, "$")
+}
@@ -0,0 +1,101 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package service
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/echocat/caretakerd/values"
+	"os"
+	"path/filepath"
+)
+
+type LintTest struct{}
+
+func init() {
+	Suite(&LintTest{})
+}
+
+func (s *LintTest) TestLintWithoutWarnings(c *C) {
+	conf := NewConfig()
+	conf.Command = []values.String{"sh", "-c", "exit 0"}
+	c.Assert(conf.Lint(), DeepEquals, []string{})
+}
+
+func (s *LintTest) TestLintCronWithAutoRestartAlways(c *C) {
+	conf := NewConfig()
+	conf.Command = []values.String{"sh"}
+	c.Assert(conf.CronExpression.Set("0 * * * * *"), IsNil)
+	conf.AutoRestart = values.Always
+	c.Assert(conf.Lint(), DeepEquals, []string{
+		"autoRestart 'always' has no effect because the service is already started again by its cronExpression.",
+	})
+}
+
+func (s *LintTest) TestLintUnusedStopSignal(c *C) {
+	conf := NewConfig()
+	conf.Command = []values.String{"sh"}
+	conf.StopCommand = []values.String{"sh", "-c", "exit 0"}
+	conf.StopSignal = values.KILL
+	c.Assert(conf.Lint(), DeepEquals, []string{
+		"stopSignal 'KILL' is never used because a stopCommand is configured.",
+	})
+}
+
+func (s *LintTest) TestLintUnknownUser(c *C) {
+	conf := NewConfig()
+	conf.Command = []values.String{"sh"}
+	conf.User = "caretakerd-lint-test-does-not-exist"
+	warnings := conf.Lint()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(warnings[0], Matches, "user 'caretakerd-lint-test-does-not-exist' could not be resolved: .*")
+}
+
+func (s *LintTest) TestLintDirectory(c *C) {
+	dir := c.MkDir()
+	file := filepath.Join(dir, "file")
+	c.Assert(os.WriteFile(file, []byte{}, 0644), IsNil)
+
+	conf := NewConfig()
+	conf.Command = []values.String{"sh"}
+	conf.Directory = values.String(filepath.Join(dir, "missing"))
+	c.Assert(conf.Lint(), DeepEquals, []string{
+		"directory '" + filepath.Join(dir, "missing") + "' does not exist.",
+	})
+
+	conf.Directory = values.String(file)
+	c.Assert(conf.Lint(), DeepEquals, []string{
+		"directory '" + file + "' is not a directory.",
+	})
+
+	conf.Directory = "${HOME}/missing"
+	c.Assert(conf.Lint(), DeepEquals, []string{})
+}
+
+func (s *LintTest) TestLintCommands(c *C) {
+	dir := c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0755), IsNil)
+
+	conf := NewConfig()
+	conf.Directory = values.String(dir)
+	conf.Command = []values.String{"./run.sh"}
+	conf.PreCommands = [][]values.String{{"-caretakerd-lint-test-missing"}}
+	conf.PostCommands = [][]values.String{{"${TOOL}"}}
+	conf.StopCommand = []values.String{"./missing.sh"}
+	c.Assert(conf.Lint(), DeepEquals, []string{
+		"preCommand 'caretakerd-lint-test-missing' could not be found or is not executable.",
+		"stopCommand './missing.sh' could not be found or is not executable.",
+	})
+}
+
+func (s *LintTest) TestLintConfigs(c *C) {
+	a := NewConfig()
+	a.Command = []values.String{"caretakerd-lint-test-missing"}
+	b := NewConfig()
+	b.Command = []values.String{"sh"}
+	configs := Configs{"b": b, "a": a}
+	c.Assert(configs.Lint(), DeepEquals, []string{
+		"Service 'a': command 'caretakerd-lint-test-missing' could not be found or is not executable.",
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"github.com/echocat/caretakerd/errors"
 	"github.com/echocat/caretakerd/keyStore"
 	"github.com/echocat/caretakerd/logger"
+	"github.com/echocat/caretakerd/seccomp"
 	usync "github.com/echocat/caretakerd/sync"
 	"runtime"
+	"sync"
 )
 
 // Service represents a service instance in caretakerd that was created from a Config object.
@@ -16,6 +18,14 @@ type Service struct {
 	name      string
 	syncGroup *usync.Group
 	access    *access.Access
+	keyStore  *keyStore.KeyStore
+
+	globalEnvironment Environments
+	seccompProfile    *seccomp.Profile
+	lastExit          *ExitInformation
+	stateLock         *sync.Mutex
+	starts            int
+	changed           chan struct{}
 }
 
 func finalize(what *Service) {
@@ -23,7 +33,8 @@ func finalize(what *Service) {
 }
 
 // NewService creates a new service instance from the given Config.
-func NewService(conf Config, name string, syncGroup *usync.Group, sec *keyStore.KeyStore) (*Service, error) {
+// The given globalEnvironment is passed to the service process.
+func NewService(conf Config, name string, globalEnvironment Environments, syncGroup *usync.Group, sec *keyStore.KeyStore) (*Service, error) {
 	err := conf.Validate()
 	if err != nil {
 		return nil, errors.New("Config of service '%v' is not valid.", name).CausedBy(err)
@@ -32,6 +43,15 @@ func NewService(conf Config, name string, syncGroup *usync.Group, sec *keyStore.
 	if err != nil {
 		return nil, errors.New("Could not create access for service '%v'.", name).CausedBy(err)
 	}
+	seccompProfile, err := seccomp.LoadProfile(conf.SeccompProfile.String())
+	if err != nil {
+		return nil, errors.New("Could not load seccomp profile of service '%v'.", name).CausedBy(err)
+	}
+	if seccompProfile != nil {
+		if _, err := seccompProfile.Compile(); err != nil {
+			return nil, errors.New("Could not use seccomp profile of service '%v'.", name).CausedBy(err)
+		}
+	}
 	log, err := logger.NewLogger(conf.Logger, name, syncGroup)
 	if err != nil {
 		return nil, errors.New("Could not create logger for service '%v'.", name).CausedBy(err)
@@ -42,6 +62,12 @@ func NewService(conf Config, name string, syncGroup *usync.Group, sec *keyStore.
 		name:      name,
 		syncGroup: syncGroup,
 		access:    acc,
+		keyStore:  sec,
+
+		globalEnvironment: globalEnvironment,
+		seccompProfile:    seccompProfile,
+		stateLock:         new(sync.Mutex),
+		changed:           make(chan struct{}),
 	}
 	runtime.SetFinalizer(result, finalize)
 	return result, nil
@@ -75,3 +101,72 @@ func (instance Service) Logger() *logger.Logger {
 func (instance *Service) Access() *access.Access {
 	return instance.access
 }
+
+// LastExit returns information about how the last execution of this service ended.
+// If this service was never executed nil is returned.
+func (instance *Service) LastExit() *ExitInformation {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	return instance.lastExit
+}
+
+func (instance *Service) setLastExit(what *ExitInformation) {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	(*instance).lastExit = what
+}
+
+// Restarts returns how often this service was started again after its first start.
+// This includes automatic restarts and restarts that were requested using the control.
+func (instance *Service) Restarts() int {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	if instance.starts > 1 {
+		return instance.starts - 1
+	}
+	return 0
+}
+
+func (instance *Service) registerStart() {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	(*instance).starts++
+}
+
+// Changed returns a channel that is closed at the next change of the state of this service.
+// For every following change this method has to be called again.
+func (instance *Service) Changed() <-chan struct{} {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	return instance.changed
+}
+
+func (instance *Service) notifyChanged() {
+	instance.stateLock.Lock()
+	defer instance.stateLock.Unlock()
+	close(instance.changed)
+	(*instance).changed = make(chan struct{})
+}
+
+// Environment returns the environment variables of this service - without the ones of caretakerd itself.
+// This includes the global environment, the content of every environment file and the
+// environment of the service config itself.
+func (instance *Service) Environment() (Environments, error) {
+	result := Environments{}
+	for key, value := range instance.globalEnvironment {
+		result[key] = value
+	}
+	for _, file := range instance.config.EnvironmentFiles {
+		fromFile, err := LoadEnvironmentFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fromFile {
+			result[key] = value
+		}
+	}
+	for key, value := range instance.config.Environment {
+		result[key] = value
+	}
+	return result, nil
+}
@@ -10,14 +10,15 @@ import (
 type Services map[string]*Service
 
 // NewServices creates a new instance of Services from the given Configs.
-func NewServices(configs Configs, syncGroup *usync.Group, sec *keyStore.KeyStore) (*Services, error) {
+// The given globalEnvironment is passed to every service.
+func NewServices(configs Configs, globalEnvironment Environments, syncGroup *usync.Group, sec *keyStore.KeyStore) (*Services, error) {
 	err := configs.Validate()
 	if err != nil {
 		return nil, err
 	}
 	result := Services{}
 	for name, conf := range configs {
-		newService, err := NewService(conf, name, syncGroup.NewGroup(), sec)
+		newService, err := NewService(conf, name, globalEnvironment, syncGroup.NewGroup(), sec)
 		if err != nil {
 			return nil, err
 		}
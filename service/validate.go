@@ -2,6 +2,8 @@ package service
 
 import (
 	"github.com/echocat/caretakerd/errors"
+	"github.com/echocat/caretakerd/values"
+	"path"
 	"strings"
 )
 
@@ -62,12 +64,30 @@ func (instance Config) Validate() error {
 	if err == nil {
 		err = instance.StopWaitInSeconds.Validate()
 	}
+	if err == nil {
+		err = instance.ReadinessTimeoutInSeconds.Validate()
+	}
 	if err == nil {
 		err = instance.AutoRestart.Validate()
 	}
+	if err == nil {
+		err = instance.validateInheritEnvironmentPatterns()
+	}
 	return err
 }
 
+func (instance Config) validateInheritEnvironmentPatterns() error {
+	patterns := make([]values.String, 0, len(instance.InheritEnvironmentInclude)+len(instance.InheritEnvironmentExclude))
+	patterns = append(patterns, instance.InheritEnvironmentInclude...)
+	patterns = append(patterns, instance.InheritEnvironmentExclude...)
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern.String(), ""); err != nil {
+			return errors.New("Illegal environment pattern: %v", pattern).CausedBy(err)
+		}
+	}
+	return nil
+}
+
 func (instance Config) validateCommand() error {
 	if len(instance.Command) <= 0 {
 		return errors.New("There is no command defined.")
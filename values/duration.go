@@ -0,0 +1,103 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration represents a period of time with more features as the primitive type - like 8760h or 30m.
+// Possible units are h, m, s, ms, us and ns. It could not be negative.
+// @inline
+type Duration string
+
+func (instance Duration) String() string {
+	result, err := instance.CheckedString()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CheckedString is like String but also returns an optional error if there are any
+// validation errors.
+func (instance Duration) CheckedString() (string, error) {
+	if _, err := instance.CheckedDuration(); err != nil {
+		return "", err
+	}
+	return string(instance), nil
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are any problems while transforming the string.
+func (instance *Duration) Set(value string) error {
+	candidate := Duration(value)
+	if _, err := candidate.CheckedDuration(); err != nil {
+		return err
+	}
+	*instance = candidate
+	return nil
+}
+
+// Duration returns this value as time.Duration.
+func (instance Duration) Duration() time.Duration {
+	result, err := instance.CheckedDuration()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// CheckedDuration is like Duration but also returns an optional error if there are any
+// validation errors.
+func (instance Duration) CheckedDuration() (time.Duration, error) {
+	if instance == "" {
+		return 0, nil
+	}
+	result, err := time.ParseDuration(string(instance))
+	if err != nil {
+		return 0, fmt.Errorf("illegal duration: %v", string(instance))
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("duration should not be negative: %v", string(instance))
+	}
+	return result, nil
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance Duration) MarshalYAML() (interface{}, error) {
+	return instance.CheckedString()
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Duration) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Duration) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Duration) Validate() error {
+	_, err := instance.CheckedString()
+	return err
+}
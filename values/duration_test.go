@@ -0,0 +1,56 @@
+package values
+
+import (
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v3"
+)
+
+type DurationTest struct{}
+
+func init() {
+	Suite(&DurationTest{})
+}
+
+func (s *DurationTest) TestSet(c *C) {
+	var actual Duration
+	c.Assert(actual.Set("8760h"), IsNil)
+	c.Assert(actual.Duration(), Equals, 8760*time.Hour)
+	c.Assert(actual.String(), Equals, "8760h")
+
+	c.Assert(actual.Set("1h30m"), IsNil)
+	c.Assert(actual.Duration(), Equals, 90*time.Minute)
+
+	c.Assert(actual.Set("foo"), ErrorMatches, "illegal duration: foo")
+	c.Assert(actual.Set("-1h"), ErrorMatches, "duration should not be negative: -1h")
+	c.Assert(actual.Duration(), Equals, 90*time.Minute)
+}
+
+func (s *DurationTest) TestEmpty(c *C) {
+	var actual Duration
+	c.Assert(actual.Duration(), Equals, time.Duration(0))
+	c.Assert(actual.Validate(), IsNil)
+}
+
+func (s *DurationTest) TestMarshal(c *C) {
+	plainJSON, err := json.Marshal(Duration("720h"))
+	c.Assert(err, IsNil)
+	c.Assert(string(plainJSON), Equals, `"720h"`)
+
+	plainYAML, err := yaml.Marshal(Duration("30s"))
+	c.Assert(err, IsNil)
+	c.Assert(string(plainYAML), Equals, "30s\n")
+}
+
+func (s *DurationTest) TestUnmarshal(c *C) {
+	var actual Duration
+	c.Assert(json.Unmarshal([]byte(`"2h"`), &actual), IsNil)
+	c.Assert(actual.Duration(), Equals, 2*time.Hour)
+
+	c.Assert(yaml.Unmarshal([]byte("10m"), &actual), IsNil)
+	c.Assert(actual.Duration(), Equals, 10*time.Minute)
+
+	c.Assert(yaml.Unmarshal([]byte("bar"), &actual), ErrorMatches, "illegal duration: bar")
+}
@@ -26,7 +26,7 @@ func (i *ExitCode) Set(value string) error {
 
 // MarshalYAML is used until yaml marshalling. Do not call this method directly.
 func (i ExitCode) MarshalYAML() (interface{}, error) {
-	return i.String(), nil
+	return int(i), nil
 }
 
 // UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
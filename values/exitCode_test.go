@@ -0,0 +1,23 @@
+package values
+
+import (
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v3"
+)
+
+type ExitCodeTest struct{}
+
+func init() {
+	Suite(&ExitCodeTest{})
+}
+
+func (s *ExitCodeTest) TestYaml(c *C) {
+	content, err := yaml.Marshal(ExitCodes{0, 12})
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "- 0\n- 12\n")
+
+	var actual ExitCodes
+	c.Assert(yaml.Unmarshal([]byte("[0, \"12\"]"), &actual), IsNil)
+	c.Assert(actual, DeepEquals, ExitCodes{0, 12})
+	c.Assert(yaml.Unmarshal([]byte("[foo]"), &actual), ErrorMatches, "illegal exit Code value: foo")
+}
@@ -0,0 +1,120 @@
+package values
+
+import (
+	"github.com/echocat/caretakerd/errors"
+	"strings"
+)
+
+// MissingVariableError is returned by Expand if a required variable is not set.
+type MissingVariableError struct {
+	error
+	// Name of the missing variable.
+	Name string
+}
+
+// Expand replaces variables inside of the given string using the given lookup function.
+//
+// Supported syntax:
+//
+//	$VAR or ${VAR}            Value of VAR or an empty string if VAR is not set.
+//	${VAR:-default}           Value of VAR or default if VAR is not set or empty.
+//	${VAR-default}            Value of VAR or default if VAR is not set.
+//	${VAR:?message}           Value of VAR or an error with message if VAR is not set or empty.
+//	${VAR?message}            Value of VAR or an error with message if VAR is not set.
+//	$$                        A literal $.
+//
+// Defaults and messages could contain variables, too.
+func Expand(in string, lookup func(key string) (string, bool)) (string, error) {
+	result := new(strings.Builder)
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		if c != '$' || i+1 >= len(in) {
+			result.WriteByte(c)
+			continue
+		}
+		next := in[i+1]
+		if next == '$' {
+			result.WriteByte('$')
+			i++
+		} else if next == '{' {
+			end := findClosingBrace(in, i+2)
+			if end < 0 {
+				return "", errors.New("Missing closing brace in '%s'.", in)
+			}
+			value, err := expandExpression(in[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(value)
+			i = end
+		} else if isVariableNameCharacter(next) {
+			end := i + 1
+			for end < len(in) && isVariableNameCharacter(in[end]) {
+				end++
+			}
+			value, _ := lookup(in[i+1 : end])
+			result.WriteString(value)
+			i = end - 1
+		} else {
+			result.WriteByte(c)
+		}
+	}
+	return result.String(), nil
+}
+
+func expandExpression(expression string, lookup func(key string) (string, bool)) (string, error) {
+	nameEnd := 0
+	for nameEnd < len(expression) && isVariableNameCharacter(expression[nameEnd]) {
+		nameEnd++
+	}
+	name := expression[:nameEnd]
+	if len(name) == 0 {
+		return "", errors.New("Illegal variable expression '${%s}'.", expression)
+	}
+	value, set := lookup(name)
+	operator := expression[nameEnd:]
+	if len(operator) == 0 {
+		return value, nil
+	}
+	checkEmpty := strings.HasPrefix(operator, ":")
+	if checkEmpty {
+		operator = operator[1:]
+	}
+	if len(operator) == 0 || (operator[0] != '-' && operator[0] != '?') {
+		return "", errors.New("Illegal variable expression '${%s}'.", expression)
+	}
+	if set && (!checkEmpty || len(value) > 0) {
+		return value, nil
+	}
+	argument, err := Expand(operator[1:], lookup)
+	if err != nil {
+		return "", err
+	}
+	if operator[0] == '-' {
+		return argument, nil
+	}
+	if len(argument) == 0 {
+		return "", MissingVariableError{error: errors.New("Required variable '%s' is not set.", name), Name: name}
+	}
+	return "", MissingVariableError{error: errors.New("Required variable '%s' is not set: %s", name, argument), Name: name}
+}
+
+func findClosingBrace(in string, start int) int {
+	depth := 0
+	for i := start; i < len(in); i++ {
+		switch in[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func isVariableNameCharacter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
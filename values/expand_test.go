@@ -0,0 +1,64 @@
+package values
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ExpandTest struct{}
+
+func init() {
+	Suite(&ExpandTest{})
+}
+
+func testLookup(key string) (string, bool) {
+	value, ok := map[string]string{
+		"A":     "a",
+		"EMPTY": "",
+		"NAME":  "B",
+	}[key]
+	return value, ok
+}
+
+func (s *ExpandTest) TestExpand(c *C) {
+	for in, expected := range map[string]string{
+		"plain":                  "plain",
+		"$A ${A} x$A.y":          "a a xa.y",
+		"$MISSING${MISSING}":     "",
+		"${A:-d} ${MISSING:-d}":  "a d",
+		"${EMPTY:-d}|${EMPTY-d}": "d|",
+		"${MISSING-d}":           "d",
+		"${MISSING:-${A}-x}":     "a-x",
+		"${MISSING:-}":           "",
+		"${A:?msg}":              "a",
+		"$$A $$ $":               "$A $ $",
+		"100$ $-":                "100$ $-",
+	} {
+		actual, err := Expand(in, testLookup)
+		c.Assert(err, IsNil, Commentf("%s", in))
+		c.Assert(actual, Equals, expected, Commentf("%s", in))
+	}
+}
+
+func (s *ExpandTest) TestExpandRequired(c *C) {
+	_, err := Expand("${MISSING:?Please provide it.}", testLookup)
+	c.Assert(err, ErrorMatches, "Required variable 'MISSING' is not set: Please provide it.")
+	c.Assert(err.(MissingVariableError).Name, Equals, "MISSING")
+
+	_, err = Expand("${EMPTY:?}", testLookup)
+	c.Assert(err, ErrorMatches, "Required variable 'EMPTY' is not set.")
+
+	actual, err := Expand("${EMPTY?}", testLookup)
+	c.Assert(err, IsNil)
+	c.Assert(actual, Equals, "")
+}
+
+func (s *ExpandTest) TestExpandIllegalExpressions(c *C) {
+	_, err := Expand("${A", testLookup)
+	c.Assert(err, ErrorMatches, "Missing closing brace in '\\$\\{A'.")
+
+	_, err = Expand("${}", testLookup)
+	c.Assert(err, ErrorMatches, "Illegal variable expression '\\$\\{\\}'.")
+
+	_, err = Expand("${A:x}", testLookup)
+	c.Assert(err, ErrorMatches, "Illegal variable expression '\\$\\{A:x\\}'.")
+}
@@ -0,0 +1,34 @@
+package values
+
+import (
+	"sort"
+	"strings"
+)
+
+// Labels represents a couple of key value pairs which describe something - like a service.
+// @inline
+type Labels map[string]string
+
+func (instance Labels) String() string {
+	keys := make([]string, 0, len(instance))
+	for key := range instance {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + instance[key]
+	}
+	return strings.Join(parts, ",")
+}
+
+// Matches returns "true" if these labels contain every key value pair of the given selector.
+// An empty selector matches every labels.
+func (instance Labels) Matches(selector Labels) bool {
+	for key, value := range selector {
+		if candidate, ok := instance[key]; !ok || candidate != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package values
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type LabelsTest struct{}
+
+func init() {
+	Suite(&LabelsTest{})
+}
+
+func (s *LabelsTest) TestString(c *C) {
+	c.Assert(Labels{"b": "2", "a": "1"}.String(), Equals, "a=1,b=2")
+	c.Assert(Labels{}.String(), Equals, "")
+}
+
+func (s *LabelsTest) TestMatches(c *C) {
+	labels := Labels{"group": "workers", "tier": "backend"}
+	c.Assert(labels.Matches(Labels{}), Equals, true)
+	c.Assert(labels.Matches(Labels{"group": "workers"}), Equals, true)
+	c.Assert(labels.Matches(Labels{"group": "workers", "tier": "backend"}), Equals, true)
+	c.Assert(labels.Matches(Labels{"group": "web"}), Equals, false)
+	c.Assert(labels.Matches(Labels{"group": "workers", "zone": "a"}), Equals, false)
+	c.Assert(Labels(nil).Matches(Labels{"group": "workers"}), Equals, false)
+}
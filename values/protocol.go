@@ -17,12 +17,15 @@ const (
 	TCP Protocol = 0
 	// Unix represents a Unix socket files based protocol type.
 	Unix Protocol = 1
+	// FD represents an already opened socket that was passed in by the parent process as file descriptor.
+	FD Protocol = 2
 )
 
 // AllProtocols contains all possible variants of Protocol.
 var AllProtocols = []Protocol{
 	TCP,
 	Unix,
+	FD,
 }
 
 func (instance Protocol) String() string {
@@ -41,6 +44,8 @@ func (instance Protocol) CheckedString() (string, error) {
 		return "tcp", nil
 	case Unix:
 		return "unix", nil
+	case FD:
+		return "fd", nil
 	}
 	return "", errors.New("Illegal protocol: %d", instance)
 }
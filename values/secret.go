@@ -0,0 +1,69 @@
+package values
+
+import (
+	"encoding/json"
+)
+
+const redactedSecret = "********"
+
+// Secret represents a string that should never be revealed like a password or a token.
+// It is redacted if it is printed or marshalled. Use Reveal to access the content.
+// @inline
+type Secret string
+
+func (instance Secret) String() string {
+	if instance.IsEmpty() {
+		return ""
+	}
+	return redactedSecret
+}
+
+// Reveal returns the content of this secret.
+func (instance Secret) Reveal() string {
+	return string(instance)
+}
+
+// Set sets the given string to the current object from a string.
+// Returns an error object if there are any problems while transforming the string.
+func (instance *Secret) Set(value string) error {
+	*instance = Secret(value)
+	return nil
+}
+
+// MarshalYAML is used until yaml marshalling. Do not call this method directly.
+func (instance Secret) MarshalYAML() (interface{}, error) {
+	return instance.String(), nil
+}
+
+// UnmarshalYAML is used until yaml unmarshalling. Do not call this method directly.
+func (instance *Secret) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(instance.String())
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *Secret) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
+// Validate validates actions on this object and returns an error object if there are any.
+func (instance Secret) Validate() error {
+	return nil
+}
+
+// IsEmpty returns "true" if the current secret has no content.
+func (instance Secret) IsEmpty() bool {
+	return len(instance) <= 0
+}
@@ -0,0 +1,42 @@
+package values
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v3"
+)
+
+type SecretTest struct{}
+
+func init() {
+	Suite(&SecretTest{})
+}
+
+func (s *SecretTest) TestRedacted(c *C) {
+	secret := Secret("foo")
+	c.Assert(secret.Reveal(), Equals, "foo")
+	c.Assert(secret.String(), Equals, "********")
+	c.Assert(fmt.Sprintf("%v", secret), Equals, "********")
+	c.Assert(Secret("").String(), Equals, "")
+}
+
+func (s *SecretTest) TestMarshal(c *C) {
+	plainJSON, err := json.Marshal(Secret("foo"))
+	c.Assert(err, IsNil)
+	c.Assert(string(plainJSON), Equals, `"********"`)
+
+	plainYAML, err := yaml.Marshal(Secret("foo"))
+	c.Assert(err, IsNil)
+	c.Assert(string(plainYAML), Equals, "'********'\n")
+}
+
+func (s *SecretTest) TestUnmarshal(c *C) {
+	var actual Secret
+	c.Assert(json.Unmarshal([]byte(`"foo"`), &actual), IsNil)
+	c.Assert(actual.Reveal(), Equals, "foo")
+
+	c.Assert(yaml.Unmarshal([]byte("bar"), &actual), IsNil)
+	c.Assert(actual.Reveal(), Equals, "bar")
+}
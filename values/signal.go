@@ -12,6 +12,7 @@ import (
 
 // Signal represents an system signal.
 // @inline
+// @serializedAs string
 type Signal syscall.Signal
 
 const (
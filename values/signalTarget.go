@@ -50,6 +50,8 @@ func (instance SignalTarget) CheckedString() (string, error) {
 		return "process", nil
 	case ProcessGroup:
 		return "processGroup", nil
+	case Mixed:
+		return "mixed", nil
 	}
 	return "", errors.New("Illegal signal target: %d", instance)
 }
@@ -1,7 +1,7 @@
 package values
 
 import (
-	"fmt"
+	"encoding/json"
 	"github.com/echocat/caretakerd/errors"
 	"net"
 	"regexp"
@@ -18,15 +18,24 @@ var uriPattern = regexp.MustCompile("^([a-zA-Z0-9]+)://(.*)$")
 //
 // # Protocols
 //
-//   - **“tcp“** This address connects or binds to a TCP socket. The “target“ should be of format “<host>:<port>“.<br>
+//   - **“tcp“** This address connects or binds to a TCP socket. The “target“ should be of format “<host>:<port>“.
+//     IPv6 addresses have to be enclosed in brackets.<br>
 //     Examples:
 //   - “tcp://localhost:57955“: Listen on IPv4 and IPv6 local addresses
 //   - “tcp://[::1]:57955“: Listen on IPv6 local address
 //   - “tcp://0.0.0.0:57955“: Listen on all addresses - this includes IPv4 and IPv6
 //   - “tcp://192.168.0.1:57955“: Listen on specific IPv4 address
-//   - **“unix“** This address connects or binds to a UNIX file socket. The “target“ should be the location of the socket file.<br>
-//     Example:
+//   - **“unix“** This address connects or binds to a UNIX file socket. The “target“ should be the location of the socket file.
+//     If the target starts with “@“ an abstract socket (Linux only) without a file is used.<br>
+//     Examples:
 //   - “unix:///var/run/caretakerd.sock“
+//   - “unix://@caretakerd“
+//   - **“fd“** This address binds to a socket that was already opened by the parent process (like systemd socket activation).
+//     The “target“ is either the number of the file descriptor or its name in “LISTEN_FDNAMES“.
+//     It could only be used to listen - not to connect.<br>
+//     Examples:
+//   - “fd://3“: Use file descriptor 3
+//   - “fd://caretakerd.socket“: Use the file descriptor that systemd passed with this name
 type SocketAddress struct {
 	Protocol Protocol
 	Target   string
@@ -83,7 +92,7 @@ func (instance SocketAddress) checkedStringWithoutProtocol() (string, error) {
 		if err := validateHost(instance.Target); err != nil {
 			return "", errors.New("Illegal host for protocol %v: %s", instance.Protocol, instance.Target)
 		}
-		return fmt.Sprintf("%s:%d", instance.Target, instance.Port), nil
+		return net.JoinHostPort(instance.Target, strconv.Itoa(instance.Port)), nil
 	case Unix:
 		if instance.Port != 0 {
 			return "", errors.New("For protocol %v is no port allowed.", instance.Protocol)
@@ -92,6 +101,14 @@ func (instance SocketAddress) checkedStringWithoutProtocol() (string, error) {
 			return "", errors.New("For protocol %v is no target file defined.", instance.Protocol)
 		}
 		return instance.Target, nil
+	case FD:
+		if instance.Port != 0 {
+			return "", errors.New("For protocol %v is no port allowed.", instance.Protocol)
+		}
+		if len(strings.TrimSpace(instance.Target)) == 0 {
+			return "", errors.New("For protocol %v is no file descriptor defined.", instance.Protocol)
+		}
+		return instance.Target, nil
 	}
 	return "", errors.New("Unknown protocol: %v", instance.Protocol)
 }
@@ -111,6 +128,8 @@ func (instance *SocketAddress) Set(value string) error {
 			return instance.SetTCP(match[2])
 		case Unix:
 			return instance.SetUnix(match[2])
+		case FD:
+			return instance.SetFD(match[2])
 		}
 		return errors.New("Unknown protocol %v in address '%v'.", protocol, value)
 	}
@@ -123,7 +142,7 @@ func (instance *SocketAddress) SetTCP(value string) error {
 	if lastDoubleDot <= 0 || lastDoubleDot+2 >= len(value) {
 		return errors.New("No port specified for address '%v'.", value)
 	}
-	host := value[:lastDoubleDot]
+	host := strings.TrimSuffix(strings.TrimPrefix(value[:lastDoubleDot], "["), "]")
 	plainPort := value[lastDoubleDot+1:]
 	port, err := strconv.Atoi(plainPort)
 	if err != nil || !isValidPort(port) {
@@ -146,6 +165,22 @@ func (instance *SocketAddress) SetUnix(value string) error {
 	return nil
 }
 
+// SetFD set this SocketAddress instance to the given file descriptor number or name (without leading fd:// scheme)
+func (instance *SocketAddress) SetFD(value string) error {
+	if len(strings.TrimSpace(value)) == 0 {
+		return errors.New("No file descriptor specified.")
+	}
+	(*instance).Protocol = FD
+	(*instance).Target = value
+	(*instance).Port = 0
+	return nil
+}
+
+// IsAbstract returns true if this SocketAddress is an abstract unix socket without a file.
+func (instance SocketAddress) IsAbstract() bool {
+	return instance.Protocol == Unix && strings.HasPrefix(instance.Target, "@")
+}
+
 // MarshalYAML is used until yaml marshalling. Do not call this method directly.
 func (instance SocketAddress) MarshalYAML() (interface{}, error) {
 	return instance.String(), nil
@@ -160,6 +195,24 @@ func (instance *SocketAddress) UnmarshalYAML(unmarshal func(interface{}) error)
 	return instance.Set(value)
 }
 
+// MarshalJSON is used until json marshalling. Do not call this method directly.
+func (instance SocketAddress) MarshalJSON() ([]byte, error) {
+	s, err := instance.CheckedString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON is used until json unmarshalling. Do not call this method directly.
+func (instance *SocketAddress) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	return instance.Set(value)
+}
+
 // Validate validates actions on this object and returns an error object if there are any.
 func (instance SocketAddress) Validate() error {
 	_, err := instance.CheckedString()
@@ -0,0 +1,55 @@
+package values
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type SocketAddressTest struct{}
+
+func init() {
+	Suite(&SocketAddressTest{})
+}
+
+func (s *SocketAddressTest) TestSetTCP(c *C) {
+	var actual SocketAddress
+	c.Assert(actual.Set("tcp://localhost:57955"), IsNil)
+	c.Assert(actual, DeepEquals, SocketAddress{Protocol: TCP, Target: "localhost", Port: 57955})
+	c.Assert(actual.String(), Equals, "tcp://localhost:57955")
+	c.Assert(actual.AsAddress(), Equals, "localhost:57955")
+}
+
+func (s *SocketAddressTest) TestSetIPv6(c *C) {
+	var actual SocketAddress
+	c.Assert(actual.Set("tcp://[::1]:57955"), IsNil)
+	c.Assert(actual, DeepEquals, SocketAddress{Protocol: TCP, Target: "::1", Port: 57955})
+	c.Assert(actual.String(), Equals, "tcp://[::1]:57955")
+	c.Assert(actual.AsAddress(), Equals, "[::1]:57955")
+}
+
+func (s *SocketAddressTest) TestSetUnix(c *C) {
+	var actual SocketAddress
+	c.Assert(actual.Set("unix:///var/run/caretakerd.sock"), IsNil)
+	c.Assert(actual, DeepEquals, SocketAddress{Protocol: Unix, Target: "/var/run/caretakerd.sock"})
+	c.Assert(actual.IsAbstract(), Equals, false)
+
+	c.Assert(actual.Set("unix://@caretakerd"), IsNil)
+	c.Assert(actual, DeepEquals, SocketAddress{Protocol: Unix, Target: "@caretakerd"})
+	c.Assert(actual.IsAbstract(), Equals, true)
+	c.Assert(actual.String(), Equals, "unix://@caretakerd")
+}
+
+func (s *SocketAddressTest) TestSetFD(c *C) {
+	var actual SocketAddress
+	c.Assert(actual.Set("fd://3"), IsNil)
+	c.Assert(actual, DeepEquals, SocketAddress{Protocol: FD, Target: "3"})
+	c.Assert(actual.String(), Equals, "fd://3")
+
+	c.Assert(actual.Set("fd://"), ErrorMatches, "No file descriptor specified.")
+}
+
+func (s *SocketAddressTest) TestSetIllegal(c *C) {
+	var actual SocketAddress
+	c.Assert(actual.Set("localhost:57955"), ErrorMatches, "Illegal socket address: localhost:57955")
+	c.Assert(actual.Set("tcp://localhost"), ErrorMatches, "No port specified for address 'localhost'.")
+	c.Assert(actual.Set("udp://localhost:57955"), ErrorMatches, "illegal protocol: udp")
+}